@@ -5,6 +5,7 @@ go 1.18
 require (
 	github.com/gogap/logrus v0.8.2
 	github.com/op/go-logging v0.0.0-20160315200505-970db520ece7
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require github.com/stretchr/testify v1.7.1 // indirect
@@ -0,0 +1,256 @@
+package cli
+
+import (
+	"ECDC_SIM/pkg/simulator"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/gogap/logrus"
+	"io"
+	"os"
+	"time"
+)
+
+const usage = `ecdcsim is a reliability simulator for erasure-coded data centers.
+
+Usage:
+  ecdcsim <command> [options]
+
+Commands:
+  run       run N iterations of one configuration and report durability statistics
+  sweep     run every point of a parameter grid and report one row per point
+  replay    re-run one iteration from an event log written by run -event-log
+  validate  check a configuration without running it
+
+Run "ecdcsim <command> -h" for the options of a command.
+`
+
+type command struct {
+	name string
+	run  func(args []string, stdout, stderr io.Writer) error
+}
+
+var commands = []command{
+	{name: "run", run: runCommand},
+	{name: "sweep", run: sweepCommand},
+	{name: "replay", run: replayCommand},
+	{name: "validate", run: validateCommand},
+}
+
+// Main 命令行入口，返回进程退出码
+func Main(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" || args[0] == "help" {
+		fmt.Fprint(stderr, usage)
+		if len(args) == 0 {
+			return 2
+		}
+		return 0
+	}
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			err := cmd.run(args[1:], stdout, stderr)
+			if err == flag.ErrHelp {
+				return 0
+			}
+			if err != nil {
+				fmt.Fprintf(stderr, "ecdcsim %s: %v\n", cmd.name, err)
+				return 1
+			}
+			return 0
+		}
+	}
+	fmt.Fprintf(stderr, "ecdcsim: unknown command %q\n\n%s", args[0], usage)
+	return 2
+}
+
+// logFlags 日志相关的选项，模拟过程的日志量很大，默认只输出警告及以上级别
+type logFlags struct {
+	level    string
+	file     string
+	progress time.Duration
+}
+
+func (l *logFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&l.level, "log-level", "warning", "logrus level: debug, info, warning, error")
+	fs.StringVar(&l.file, "log-file", "", "write logs to this file instead of stderr")
+	fs.DurationVar(&l.progress, "progress", 0, "report progress to stderr at this interval, e.g. 30s, 0 disables")
+}
+
+// setup 配置全局 logrus，返回需在结束时关闭的日志文件
+func (l *logFlags) setup(stderr io.Writer) (io.Closer, error) {
+	level, err := logrus.ParseLevel(l.level)
+	if err != nil {
+		return nil, err
+	}
+	logrus.SetLevel(level)
+	if l.file == "" {
+		logrus.SetOutput(stderr)
+		return nil, nil
+	}
+	logFile, err := os.OpenFile(l.file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	logrus.SetOutput(logFile)
+	return logFile, nil
+}
+
+// outputFlags 结果输出相关的选项
+type outputFlags struct {
+	path   string
+	format string
+}
+
+func (o *outputFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.path, "o", "", "write results to this file instead of stdout")
+	fs.StringVar(&o.format, "format", "text", "output format: text or json")
+}
+
+// open 打开结果输出，返回的 close 函数需在写完后调用
+func (o *outputFlags) open(stdout io.Writer) (io.Writer, func() error, error) {
+	if o.format != "text" && o.format != "json" {
+		return nil, nil, fmt.Errorf("unknown output format %q", o.format)
+	}
+	if o.path == "" {
+		return stdout, func() error { return nil }, nil
+	}
+	file, err := os.Create(o.path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, file.Close, nil
+}
+
+// recordFlags 逐迭代结果导出的选项
+type recordFlags struct {
+	path   string
+	format string
+}
+
+func (r *recordFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&r.path, "records", "", "stream per-iteration results to this file")
+	fs.StringVar(&r.format, "records-format", "ndjson", "per-iteration result format: ndjson or csv")
+}
+
+// recordOutput 逐迭代结果的输出文件
+type recordOutput struct {
+	file   *os.File
+	writer simulator.RecordWriter
+}
+
+// open 打开逐迭代结果输出，未指定 -records 时返回 nil。offset 大于 0 时为从检查点恢复：
+// 文件截断到检查点时的长度后继续追加，使输出与不中断的运行相同
+func (r *recordFlags) open(offset int64) (*recordOutput, error) {
+	if r.path == "" {
+		return nil, nil
+	}
+	file, err := openAt(r.path, offset)
+	if err != nil {
+		return nil, err
+	}
+	newWriter := simulator.NewRecordWriter
+	if offset > 0 {
+		newWriter = simulator.AppendRecordWriter
+	}
+	writer, err := newWriter(file, r.format)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &recordOutput{file: file, writer: writer}, nil
+}
+
+// openAt 打开输出文件，offset 为 0 时新建，否则截断到 offset 并从该处继续写
+func openAt(path string, offset int64) (*os.File, error) {
+	if offset == 0 {
+		return os.Create(path)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err = file.Truncate(offset); err == nil {
+		_, err = file.Seek(offset, io.SeekStart)
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+func (o *recordOutput) write(record *simulator.IterationRecord) error {
+	return o.writer.Write(record)
+}
+
+// offset 写出缓冲的记录，返回文件当前的长度
+func (o *recordOutput) offset() (int64, error) {
+	if err := o.writer.Flush(); err != nil {
+		return 0, err
+	}
+	return o.file.Seek(0, io.SeekCurrent)
+}
+
+func (o *recordOutput) close() error {
+	err := o.writer.Flush()
+	if closeErr := o.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// eventLogFlags 事件日志相关的选项
+type eventLogFlags struct {
+	path string
+	all  bool
+}
+
+func (e *eventLogFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&e.path, "event-log", "", "write every handled event of iterations that lose data to this NDJSON file")
+	fs.BoolVar(&e.all, "event-log-all", false, "write the events of every iteration to -event-log, not only those that lose data")
+}
+
+// open 打开事件日志，未指定 -event-log 时返回 nil。offset 的含义与 recordFlags.open 相同
+func (e *eventLogFlags) open(offset int64) (*os.File, error) {
+	if e.path == "" {
+		return nil, nil
+	}
+	return openAt(e.path, offset)
+}
+
+// checkpointFlags 检查点相关的选项
+type checkpointFlags struct {
+	path     string
+	interval time.Duration
+	resume   bool
+}
+
+func (c *checkpointFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&c.path, "checkpoint", "", "periodically save completed iterations and statistics to this file")
+	fs.DurationVar(&c.interval, "checkpoint-interval", time.Minute, "minimum time between two checkpoints")
+	fs.BoolVar(&c.resume, "resume", false, "continue from the -checkpoint file, giving the same results as an uninterrupted run")
+}
+
+// load 恢复时读取检查点并校验其配置与种子，不恢复时返回空的检查点
+func (c *checkpointFlags) load(configHash string, seed int64) (*simulator.Checkpoint, error) {
+	if !c.resume {
+		return simulator.NewCheckpoint(configHash, seed, simulator.NewAggregate()), nil
+	}
+	if c.path == "" {
+		return nil, errors.New("-resume requires -checkpoint")
+	}
+	checkpoint, err := simulator.LoadCheckpoint(c.path)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkpoint.Check(configHash, seed); err != nil {
+		return nil, err
+	}
+	return checkpoint, nil
+}
+
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("ecdcsim "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	return fs
+}
@@ -0,0 +1,341 @@
+package cli
+
+import (
+	"ECDC_SIM/internal/pkg/config"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var smallConfArgs = []string{"-racks", "12", "-nodes-per-rack", "4", "-stripes", "200", "-log-level", "error"}
+
+func TestMain_Commands(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStdout string
+	}{
+		{name: "TestNoCommand", args: nil, wantCode: 2},
+		{name: "TestUnknownCommand", args: []string{"simulate"}, wantCode: 2},
+		{name: "TestValidateOK", args: []string{"validate", "-racks", "12", "-nodes-per-rack", "4", "-stripes", "200"}, wantCode: 0, wantStdout: "OK"},
+		{name: "TestValidateTooFewRacks", args: []string{"validate", "-racks", "8"}, wantCode: 1},
+		{name: "TestValidateHierarchical", args: []string{"validate", "-racks", "3", "-nodes-per-rack", "4", "-stripes", "200", "-placement", "HIERARCHICAL"}, wantCode: 0, wantStdout: "rack failures tolerated  1"},
+		{name: "TestValidateLRC", args: []string{"validate", "-racks", "4", "-nodes-per-rack", "4", "-stripes", "200", "-code", "LRC", "-k", "6", "-l", "2", "-g", "1"}, wantCode: 0, wantStdout: "LRC(k=6,l=2,g=1)"},
+		{name: "TestValidateBadWeibull", args: []string{"validate", "-disk-fail", "1,2"}, wantCode: 1},
+		{name: "TestRun", args: append([]string{"run", "-iterations", "4"}, smallConfArgs...), wantCode: 0, wantStdout: "PDL"},
+		{name: "TestRunTimeBudget", args: append([]string{"run", "-iterations", "1000000", "-time-budget", "200ms"}, smallConfArgs...), wantCode: 0, wantStdout: "PDL"},
+		{name: "TestRunBadTimeBudget", args: append([]string{"run", "-time-budget", "soon"}, smallConfArgs...), wantCode: 1},
+		{name: "TestSweepWithoutAxis", args: append([]string{"sweep"}, smallConfArgs...), wantCode: 1},
+		{name: "TestSweepUnknownAxis", args: append([]string{"sweep", "-axis", "foo=1,2"}, smallConfArgs...), wantCode: 1},
+		{name: "TestSweep", args: append([]string{"sweep", "-iterations", "2", "-axis", "k=5,6"}, smallConfArgs...), wantCode: 0, wantStdout: "blocked ratio"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+			if got := Main(tt.args, stdout, stderr); got != tt.wantCode {
+				t.Errorf("Main() = %d, want %d, stderr=%s", got, tt.wantCode, stderr)
+			}
+			if !strings.Contains(stdout.String(), tt.wantStdout) {
+				t.Errorf("Main() stdout = %s, want %q", stdout, tt.wantStdout)
+			}
+		})
+	}
+}
+
+func TestRunCommand_JSON(t *testing.T) {
+	stdout := new(bytes.Buffer)
+	if err := runCommand(append([]string{"-iterations", "3", "-format", "json", "-seed", "5"}, smallConfArgs...), stdout, new(bytes.Buffer)); err != nil {
+		t.Fatalf("runCommand() error = %v", err)
+	}
+	var summary struct {
+		ConfigHash string
+		Seed       int64
+		Report     map[string]interface{}
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &summary); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v, output=%s", err, stdout)
+	}
+	if summary.Report["Iterations"] != float64(3) {
+		t.Errorf("Iterations = %v, want 3", summary.Report["Iterations"])
+	}
+	if len(summary.ConfigHash) != 64 || summary.Seed != 5 {
+		t.Errorf("ConfigHash = %q, Seed = %d", summary.ConfigHash, summary.Seed)
+	}
+}
+
+func TestRunCommand_Records(t *testing.T) {
+	dir := t.TempDir()
+	for _, format := range []string{"ndjson", "csv"} {
+		path := filepath.Join(dir, "records."+format)
+		args := append([]string{"-iterations", "3", "-records", path, "-records-format", format}, smallConfArgs...)
+		if err := runCommand(args, new(bytes.Buffer), new(bytes.Buffer)); err != nil {
+			t.Fatalf("runCommand() error = %v", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantLines := 3
+		if format == "csv" {
+			wantLines++
+		}
+		if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != wantLines {
+			t.Errorf("%s has %d lines, want %d:\n%s", format, len(lines), wantLines, data)
+		}
+	}
+	if err := runCommand(append([]string{"-records", filepath.Join(dir, "r"), "-records-format", "xml"}, smallConfArgs...), new(bytes.Buffer), new(bytes.Buffer)); err == nil {
+		t.Error("runCommand() with unknown records format want error")
+	}
+}
+
+func TestAxisFlag_Set(t *testing.T) {
+	var axes axisFlag
+	for _, value := range []string{"n/k=9/6,14/10", "DCConf.RacksNum=16"} {
+		if err := axes.Set(value); err != nil {
+			t.Fatalf("Set(%q) error = %v", value, err)
+		}
+	}
+	if got := axes.String(); got != "n/k=9/6,14/10 DCConf.RacksNum=16" {
+		t.Errorf("String() = %s", got)
+	}
+	for _, value := range []string{"n", "n/k=9"} {
+		if err := axes.Set(value); err == nil {
+			t.Errorf("Set(%q) want error", value)
+		}
+	}
+}
+
+func TestSetOption(t *testing.T) {
+	tests := []struct {
+		name    string
+		option  string
+		value   string
+		check   func(cfg *config.Config) bool
+		wantErr bool
+	}{
+		{name: "TestFlagName", option: "cross-rack-bandwidth", value: "10", check: func(cfg *config.Config) bool { return cfg.Network.CrossRackBandwidth == 10 }},
+		{name: "TestFlagWeibull", option: "disk-fail", value: "1,100,0", check: func(cfg *config.Config) bool { return cfg.Failure.Disk.Scale == 100 }},
+		{name: "TestConfigPath", option: "topology.racks", value: "16", check: func(cfg *config.Config) bool { return cfg.Topology.Racks == 16 }},
+		{name: "TestFieldName", option: "ErasureCodeConf.K", value: "5", check: func(cfg *config.Config) bool { return cfg.ErasureCode.K == 5 }},
+		{name: "TestUnknown", option: "foo", value: "1", wantErr: true},
+		{name: "TestBadValue", option: "racks", value: "x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default()
+			err := setOption(cfg, tt.option, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("setOption() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !tt.check(cfg) {
+				t.Errorf("setOption(%s, %s) not applied", tt.option, tt.value)
+			}
+		})
+	}
+}
+
+func TestSweepCommand_Cache(t *testing.T) {
+	dir := t.TempDir()
+	args := append([]string{"-iterations", "2", "-axis", "n/k=9/6,10/7", "-cache", dir, "-format", "json"}, smallConfArgs...)
+	for round, wantCached := range []bool{false, true} {
+		stdout := new(bytes.Buffer)
+		if err := sweepCommand(args, stdout, new(bytes.Buffer)); err != nil {
+			t.Fatalf("sweepCommand() error = %v", err)
+		}
+		var rows []struct {
+			Point  map[string]string
+			Cached bool
+		}
+		if err := json.Unmarshal(stdout.Bytes(), &rows); err != nil {
+			t.Fatalf("json.Unmarshal() error = %v, output=%s", err, stdout)
+		}
+		if len(rows) != 2 || rows[1].Point["k"] != "7" {
+			t.Fatalf("round %d rows = %+v", round, rows)
+		}
+		for _, row := range rows {
+			if row.Cached != wantCached {
+				t.Errorf("round %d point %v Cached = %t, want %t", round, row.Point, row.Cached, wantCached)
+			}
+		}
+	}
+}
+
+func TestConfFlags_parse(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "conf.yaml")
+	if err := os.WriteFile(path, []byte("topology: {racks: 16, stripes: 100}\nerasure_code: {n: 14, k: 10}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		args      []string
+		wantRacks int
+		wantK     int
+	}{
+		{name: "TestConfigFile", args: []string{"-config", path}, wantRacks: 16, wantK: 10},
+		{name: "TestFlagOverridesFile", args: []string{"-k", "11", "-config", path}, wantRacks: 16, wantK: 11},
+		{name: "TestNoConfigFile", args: []string{"-k", "5"}, wantRacks: 32, wantK: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := newConfFlags()
+			fs := newFlagSet("test", new(bytes.Buffer))
+			conf.register(fs)
+			if err := conf.parse(fs, tt.args); err != nil {
+				t.Fatalf("parse() error = %v", err)
+			}
+			if conf.cfg.Topology.Racks != tt.wantRacks || conf.cfg.ErasureCode.K != tt.wantK {
+				t.Errorf("parse() racks=%d, k=%d, want %d, %d", conf.cfg.Topology.Racks, conf.cfg.ErasureCode.K, tt.wantRacks, tt.wantK)
+			}
+		})
+	}
+}
+
+func TestValidateCommand_ListsErrors(t *testing.T) {
+	stderr := new(bytes.Buffer)
+	err := validateCommand([]string{"-racks", "8", "-k", "9", "-cross-rack-bandwidth", "0"}, new(bytes.Buffer), stderr)
+	if err == nil {
+		t.Fatal("validateCommand() error = nil, want configuration errors")
+	}
+	for _, field := range []string{"ErasureCodeConf.K", "DCConf.RacksNum", "DCConf.MaxCrossRackRepairBandwidth"} {
+		if !strings.Contains(stderr.String(), field) {
+			t.Errorf("validateCommand() stderr = %s, want %s", stderr, field)
+		}
+	}
+}
+
+func TestRunCommand_Resume(t *testing.T) {
+	dir := t.TempDir()
+	run := func(iterations string, extra ...string) (string, string) {
+		records := filepath.Join(dir, "records.csv")
+		args := append([]string{"-iterations", iterations, "-seed", "3", "-format", "json", "-records", records, "-records-format", "csv",
+			"-disk-fail", "1,2000,0"}, smallConfArgs...)
+		stdout := new(bytes.Buffer)
+		if err := runCommand(append(args, extra...), stdout, new(bytes.Buffer)); err != nil {
+			t.Fatalf("runCommand() error = %v", err)
+		}
+		data, err := os.ReadFile(records)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return stdout.String(), string(data)
+	}
+	wantSummary, wantRecords := run("6")
+
+	checkpoint := filepath.Join(dir, "run.ckpt")
+	run("4", "-checkpoint", checkpoint)
+	// 模拟进程在检查点之后又写出了部分记录才退出
+	file, err := os.OpenFile(filepath.Join(dir, "records.csv"), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString("partial,row\n")
+	file.Close()
+	gotSummary, gotRecords := run("6", "-checkpoint", checkpoint, "-resume")
+	if gotSummary != wantSummary {
+		t.Errorf("resumed summary = %s, want %s", gotSummary, wantSummary)
+	}
+	if gotRecords != wantRecords {
+		t.Errorf("resumed records = %s, want %s", gotRecords, wantRecords)
+	}
+
+	if err = runCommand(append([]string{"-resume"}, smallConfArgs...), new(bytes.Buffer), new(bytes.Buffer)); err == nil {
+		t.Error("runCommand() -resume without -checkpoint want error")
+	}
+	if err = runCommand(append([]string{"-resume", "-checkpoint", checkpoint, "-seed", "4"}, smallConfArgs...), new(bytes.Buffer), new(bytes.Buffer)); err == nil {
+		t.Error("runCommand() -resume with another seed want error")
+	}
+}
+
+func TestRunCommand_Progress(t *testing.T) {
+	stderr := new(bytes.Buffer)
+	if err := runCommand(append([]string{"-iterations", "3", "-progress", "1ns"}, smallConfArgs...), new(bytes.Buffer), stderr); err != nil {
+		t.Fatalf("runCommand() error = %v", err)
+	}
+	if !strings.Contains(stderr.String(), "iterations 3/3") || !strings.Contains(stderr.String(), "events/s") {
+		t.Errorf("runCommand() stderr = %s, want progress reports", stderr)
+	}
+}
+
+func TestRunCommand_Trace(t *testing.T) {
+	dir := t.TempDir()
+	trace := filepath.Join(dir, "trace.csv")
+	var records strings.Builder
+	records.WriteString("time,event,device\n")
+	for nodeId := 0; nodeId < 16; nodeId++ {
+		fmt.Fprintf(&records, "10,NodeFail,%d\n", nodeId)
+	}
+	if err := os.WriteFile(trace, []byte(records.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	conf := filepath.Join(dir, "conf.yaml")
+	if err := os.WriteFile(conf, []byte("running: {trace: trace.csv}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stdout := new(bytes.Buffer)
+	if err := runCommand(append([]string{"-config", conf, "-iterations", "2", "-format", "json"}, smallConfArgs...), stdout, new(bytes.Buffer)); err != nil {
+		t.Fatalf("runCommand() error = %v", err)
+	}
+	var summary struct{ Report struct{ DataLossIterations int } }
+	if err := json.Unmarshal(stdout.Bytes(), &summary); err != nil {
+		t.Fatal(err)
+	}
+	if summary.Report.DataLossIterations != 2 {
+		t.Errorf("DataLossIterations = %d, want every replay to lose data", summary.Report.DataLossIterations)
+	}
+	if err := runCommand(append([]string{"-trace", filepath.Join(dir, "missing.csv")}, smallConfArgs...), new(bytes.Buffer), new(bytes.Buffer)); err == nil {
+		t.Error("runCommand() with a missing trace want error")
+	}
+}
+
+func TestReplayCommand(t *testing.T) {
+	dir := t.TempDir()
+	eventLog, records := filepath.Join(dir, "events.ndjson"), filepath.Join(dir, "records.ndjson")
+	args := append([]string{"-iterations", "3", "-seed", "2", "-disk-fail", "1,2000,0"}, smallConfArgs...)
+	if err := runCommand(append([]string{"-event-log", eventLog, "-event-log-all", "-records", records}, args...), new(bytes.Buffer), new(bytes.Buffer)); err != nil {
+		t.Fatalf("runCommand() error = %v", err)
+	}
+	data, err := os.ReadFile(records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	for iteration, line := range lines {
+		var want, got map[string]interface{}
+		if err = json.Unmarshal([]byte(line), &want); err != nil {
+			t.Fatal(err)
+		}
+		stdout := new(bytes.Buffer)
+		replayArgs := append([]string{"-event-log", eventLog, "-iteration", fmt.Sprint(iteration), "-format", "json"}, args...)
+		if err = replayCommand(replayArgs, stdout, new(bytes.Buffer)); err != nil {
+			t.Fatalf("replayCommand() error = %v", err)
+		}
+		if err = json.Unmarshal(stdout.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("replay of iteration %d = %v, want %v", iteration, got, want)
+		}
+	}
+
+	stdout := new(bytes.Buffer)
+	if err = replayCommand(append([]string{"-event-log", eventLog, "-iteration", "0"}, args...), stdout, new(bytes.Buffer)); err != nil {
+		t.Fatalf("replayCommand() error = %v", err)
+	}
+	for _, want := range []string{"DiskFail", "Normal->Crashed", "MissionEnd", "data loss"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("replayCommand() stdout missing %q", want)
+		}
+	}
+	if err = replayCommand(append([]string{"-event-log", eventLog, "-iteration", "9"}, args...), new(bytes.Buffer), new(bytes.Buffer)); err == nil {
+		t.Error("replayCommand() of an iteration missing from the log want error")
+	}
+	if err = replayCommand(args, new(bytes.Buffer), new(bytes.Buffer)); err == nil {
+		t.Error("replayCommand() without -event-log want error")
+	}
+}
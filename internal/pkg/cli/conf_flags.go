@@ -0,0 +1,181 @@
+package cli
+
+import (
+	"ECDC_SIM/internal/pkg/config"
+	"ECDC_SIM/internal/pkg/data_center"
+	"ECDC_SIM/internal/pkg/event_trigger"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// weibullValue 以 "shape,scale,location" 形式指定的 Weibull 分布，"none" 表示不设置
+type weibullValue struct {
+	weibull **config.WeibullConfig
+}
+
+func (w weibullValue) String() string {
+	if w.weibull == nil || *w.weibull == nil {
+		return "none"
+	}
+	return fmt.Sprintf("%g,%g,%g", (*w.weibull).Shape, (*w.weibull).Scale, (*w.weibull).Location)
+}
+
+func (w weibullValue) Set(value string) error {
+	weibull, err := config.ParseWeibull(value)
+	if err != nil {
+		return err
+	}
+	*w.weibull = weibull
+	return nil
+}
+
+// generatorValue 以 "1,0;0,1;1,1" 形式（";" 分隔各行）指定的生成矩阵
+type generatorValue struct {
+	generator *[][]int
+}
+
+func (g generatorValue) String() string {
+	if g.generator == nil {
+		return ""
+	}
+	rows := make([]string, 0, len(*g.generator))
+	for _, row := range *g.generator {
+		fields := make([]string, 0, len(row))
+		for _, element := range row {
+			fields = append(fields, strconv.Itoa(element))
+		}
+		rows = append(rows, strings.Join(fields, ","))
+	}
+	return strings.Join(rows, ";")
+}
+
+func (g generatorValue) Set(value string) error {
+	generator, err := config.ParseGenerator(value)
+	if err != nil {
+		return err
+	}
+	*g.generator = generator
+	return nil
+}
+
+// confFlags 配置对应的命令行选项。-config 指定的配置文件作为默认值，命令行上显式给出的选项覆盖文件中的值
+type confFlags struct {
+	path string
+	cfg  *config.Config
+}
+
+func newConfFlags() *confFlags {
+	return &confFlags{cfg: config.Default()}
+}
+
+func (c *confFlags) register(fs *flag.FlagSet) {
+	cfg := c.cfg
+	fs.StringVar(&c.path, "config", c.path, "YAML or JSON configuration file, options given on the command line override it")
+
+	fs.IntVar(&cfg.Topology.Racks, "racks", cfg.Topology.Racks, "number of racks")
+	fs.IntVar(&cfg.Topology.NodesPerRack, "nodes-per-rack", cfg.Topology.NodesPerRack, "number of nodes per rack")
+	fs.IntVar(&cfg.Topology.DisksPerNode, "disks-per-node", cfg.Topology.DisksPerNode, "number of disks per node")
+	fs.IntVar(&cfg.Topology.DiskCapacity, "disk-capacity", cfg.Topology.DiskCapacity, "disk capacity in GB")
+	fs.IntVar(&cfg.Topology.Stripes, "stripes", cfg.Topology.Stripes, "number of stripes")
+	fs.IntVar(&cfg.Topology.Chunks, "chunks", cfg.Topology.Chunks, "number of chunks, 0 means stripes*n")
+	fs.IntVar(&cfg.Topology.ChunkSize, "chunk-size", cfg.Topology.ChunkSize, "chunk size in MB")
+	fs.IntVar(&cfg.Topology.DataChunks, "data-chunks", cfg.Topology.DataChunks, "number of data chunks")
+	fs.Float64Var(&cfg.Topology.MissionTime, "mission-time", cfg.Topology.MissionTime, "mission time in hours")
+
+	fs.Var(weibullValue{&cfg.Failure.Node}, "node-fail", "node failure distribution shape,scale,location (hours)")
+	fs.Var(weibullValue{&cfg.Failure.NodeTransient}, "node-transient-fail", "node transient failure distribution shape,scale,location (hours)")
+	fs.Var(weibullValue{&cfg.Repair.NodeTransient}, "node-transient-repair", "node transient repair distribution shape,scale,location (hours)")
+	fs.Var(weibullValue{&cfg.Failure.Disk}, "disk-fail", "disk failure distribution shape,scale,location (hours)")
+	fs.Var(weibullValue{&cfg.Repair.Disk}, "disk-repair", "disk repair distribution shape,scale,location (hours), or none; required with -use-network=false")
+	fs.Var(weibullValue{&cfg.Failure.Rack}, "rack-fail", "rack failure distribution shape,scale,location (hours)")
+	fs.Var(weibullValue{&cfg.Repair.Rack}, "rack-repair", "rack repair distribution shape,scale,location (hours)")
+	fs.IntVar(&cfg.Topology.PowerDomains, "power-domains", cfg.Topology.PowerDomains, "number of power domains the racks are split into, 0 means one for the whole data center")
+	fs.Var(weibullValue{&cfg.Failure.PowerOutage}, "power-outage", "time between power outages of a power domain shape,scale,location (hours)")
+	fs.Var(weibullValue{&cfg.Repair.PowerOutage}, "power-restore", "power restoration time distribution shape,scale,location (hours)")
+	fs.Float64Var(&cfg.Failure.PowerUpNode, "power-up-node-fail", cfg.Failure.PowerUpNode, "fraction of nodes that fail permanently when power is restored")
+	fs.Float64Var(&cfg.Failure.PowerUpDisk, "power-up-disk-fail", cfg.Failure.PowerUpDisk, "fraction of disks that fail permanently when power is restored")
+
+	fs.StringVar(&cfg.ErasureCode.Type, "code", cfg.ErasureCode.Type, "redundancy scheme: RS, LRC, REPLICATION, LINEAR, or the regenerating codes MSR, MBR and CLAY")
+	fs.IntVar(&cfg.ErasureCode.N, "n", cfg.ErasureCode.N, "number of chunks per stripe, k+l+g for LRC with -l set")
+	fs.IntVar(&cfg.ErasureCode.K, "k", cfg.ErasureCode.K, "number of data chunks per stripe")
+	fs.IntVar(&cfg.ErasureCode.L, "l", cfg.ErasureCode.L, "number of LRC local groups, each with one local parity chunk")
+	fs.IntVar(&cfg.ErasureCode.G, "g", cfg.ErasureCode.G, "number of LRC global parity chunks")
+	fs.IntVar(&cfg.ErasureCode.Replicas, "replicas", cfg.ErasureCode.Replicas, "number of replicas per object with -code REPLICATION, which ignores -n and -k")
+	fs.IntVar(&cfg.ErasureCode.D, "d", cfg.ErasureCode.D, "number of helpers a regenerating code downloads from to repair a chunk, 0 means n-1")
+	fs.Var(generatorValue{&cfg.ErasureCode.Generator}, "generator", "GF(2^8) generator matrix of -code LINEAR, one row of k coefficients per chunk separated by ';', e.g. 1,0;0,1;1,1")
+	fs.StringVar(&cfg.Placement.Type, "placement", cfg.Placement.Type, "chunk placement: FLAT or HIERARCHICAL")
+	fs.IntVar(&cfg.Placement.ChunksPerRack, "chunks-per-rack", cfg.Placement.ChunksPerRack, "most chunks of a stripe in one rack with HIERARCHICAL placement")
+
+	fs.BoolVar(&cfg.Network.Enabled, "use-network", cfg.Network.Enabled, "model repair bandwidth")
+	fs.Float64Var(&cfg.Network.CrossRackBandwidth, "cross-rack-bandwidth", cfg.Network.CrossRackBandwidth, "cross-rack repair bandwidth in MB/s")
+	fs.Float64Var(&cfg.Network.IntraRackBandwidth, "intra-rack-bandwidth", cfg.Network.IntraRackBandwidth, "intra-rack repair bandwidth in MB/s")
+	fs.StringVar(&cfg.Network.Sharing, "bandwidth-sharing", cfg.Network.Sharing, "how concurrent repairs share bandwidth: EXCLUSIVE (one at a time), MAXMIN (fair share) or WEIGHTED (by stripe criticality); with -stripe-repair it needs -stripe-repair-slots above 1")
+
+	fs.Int64Var(&cfg.Running.Seed, "seed", cfg.Running.Seed, "random seed")
+	fs.IntVar(&cfg.Running.Iterations, "iterations", cfg.Running.Iterations, "maximum number of iterations")
+	fs.IntVar(&cfg.Running.Workers, "workers", cfg.Running.Workers, "number of parallel workers, 0 means the number of CPUs")
+	fs.BoolVar(&cfg.Running.UseTrace, "use-trace", cfg.Running.UseTrace, "replay failures from the -trace file instead of drawing them")
+	fs.StringVar(&cfg.Running.Trace, "trace", cfg.Running.Trace, "CSV or JSON file of timestamped failure records to replay, implies -use-trace")
+	fs.BoolVar(&cfg.Running.UsePowerOutage, "use-power-outage", cfg.Running.UsePowerOutage, "model correlated power outages")
+	fs.BoolVar(&cfg.Running.TransientFailure, "transient-failure", cfg.Running.TransientFailure, "model transient node and rack failures")
+	fs.BoolVar(&cfg.Running.ContinueAfterLoss, "continue-after-loss", cfg.Running.ContinueAfterLoss, "record every data loss and run on to the mission time")
+	fs.BoolVar(&cfg.Running.RestoreLostStripes, "restore-lost-stripes", cfg.Running.RestoreLostStripes, "with -continue-after-loss, restore lost stripes once their chunks are repaired")
+	fs.BoolVar(&cfg.Running.StripeRepair, "stripe-repair", cfg.Running.StripeRepair, "repair degraded stripes, most critical first, instead of whole disks (needs -use-network)")
+	fs.IntVar(&cfg.Running.StripeRepairSlots, "stripe-repair-slots", cfg.Running.StripeRepairSlots, "with -stripe-repair, most stripes repaired at once; above 1 the repairs share bandwidth and need -bandwidth-sharing MAXMIN or WEIGHTED")
+	fs.StringVar(&cfg.Running.RepairPolicy, "repair-policy", cfg.Running.RepairPolicy, "with -stripe-repair, when degraded stripes are repaired: EAGER, LAZY or BATCHED")
+	fs.IntVar(&cfg.Running.RepairThreshold, "repair-threshold", cfg.Running.RepairThreshold, "LAZY repair starts once a stripe has lost this many chunks, at most the code's fault tolerance")
+	fs.Float64Var(&cfg.Running.RepairBatchInterval, "repair-batch-interval", cfg.Running.RepairBatchInterval, "BATCHED repair releases degraded stripes every this many hours")
+	fs.Float64Var(&cfg.Running.ConfidenceLevel, "confidence", cfg.Running.ConfidenceLevel, "confidence level of the reported intervals, 0 means 0.95")
+	fs.Float64Var(&cfg.Running.TargetRelativeError, "target-relative-error", cfg.Running.TargetRelativeError, "stop once the relative error of PDL falls below this value, 0 disables")
+	fs.StringVar(&cfg.Running.TimeBudget, "time-budget", cfg.Running.TimeBudget, "stop cleanly after this much wall-clock time, e.g. 90m, and report the iterations done so far")
+}
+
+// parse 解析命令行参数。给出 -config 时先加载配置文件，再把命令行上显式设置的选项重新应用到加载的配置上
+func (c *confFlags) parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if c.path == "" {
+		return nil
+	}
+	cfg, err := config.Load(c.path)
+	if err != nil {
+		return err
+	}
+	explicit := make(map[string]string)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = f.Value.String()
+	})
+	c.cfg = cfg
+	confFs := flag.NewFlagSet("config", flag.ContinueOnError)
+	c.register(confFs)
+	for name, value := range explicit {
+		if confFs.Lookup(name) == nil {
+			continue
+		}
+		if err = confFs.Set(name, value); err != nil {
+			return fmt.Errorf("-%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// setOption 设置 cfg 中的一个配置项，name 为命令行选项名（如 racks）或 config.Config.Set 接受的字段名
+func setOption(cfg *config.Config, name, value string) error {
+	optionFs := flag.NewFlagSet("option", flag.ContinueOnError)
+	(&confFlags{cfg: cfg}).register(optionFs)
+	if name != "config" && optionFs.Lookup(name) != nil {
+		if err := optionFs.Set(name, value); err != nil {
+			return fmt.Errorf("%s=%s: %w", name, value, err)
+		}
+		return nil
+	}
+	return cfg.Set(name, value)
+}
+
+// build 生成模拟器使用的配置
+func (c *confFlags) build() (*data_center.DCConf, *data_center.ErasureCodeConf, *event_trigger.RunningConfig, error) {
+	return c.cfg.Build()
+}
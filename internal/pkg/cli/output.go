@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"ECDC_SIM/pkg/simulator"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+func writeJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// writeReport 以文本表格输出统计报告
+func writeReport(w io.Writer, summary *simulator.Summary) error {
+	report := summary.Report
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "rack failures tolerated\t%d\n", summary.RackFaultTolerance)
+	fmt.Fprintf(tw, "iterations\t%d\n", report.Iterations)
+	fmt.Fprintf(tw, "data loss iterations\t%d\n", report.DataLossIterations)
+	fmt.Fprintf(tw, "metric\tmean\t%g%% CI\n", report.ConfidenceLevel*100)
+	for _, row := range []struct {
+		name     string
+		estimate simulator.Estimate
+	}{
+		{"PDL", report.PDL},
+		{"loss events", report.LossEventNum},
+		{"failed stripes", report.FailedStripesNum},
+		{"lost chunks", report.LostChunkNum},
+		{"data bytes lost", report.DataBytesLost},
+		{"parity bytes lost", report.ParityBytesLost},
+		{"NOMDL (bytes/TB)", report.NOMDL},
+		{"parity NOMDL (bytes/TB)", report.ParityNOMDL},
+		{"blocked ratio", report.BlockedRatio},
+		{"single chunk repair ratio", report.SingleChunkRepairRatio},
+		{"repair traffic (bytes)", report.RepairTrafficBytes},
+		{"repair disk read (bytes)", report.RepairDiskReadBytes},
+		{"mean repair time (hours)", report.MeanRepairHours},
+		{"mean degraded time (hours)", report.MeanDegradedHours},
+	} {
+		fmt.Fprintf(tw, "%s\t%.6g\t[%.6g, %.6g]\n", row.name, row.estimate.Mean, row.estimate.Lower, row.estimate.Upper)
+	}
+	if report.RepairSavedBytes.Mean != 0 {
+		fmt.Fprintf(tw, "joint rebuild saving (bytes)\t%.6g\t[%.6g, %.6g]\n", report.RepairSavedBytes.Mean, report.RepairSavedBytes.Lower, report.RepairSavedBytes.Upper)
+	}
+	if report.LocalRepairRatio.Mean > 0 {
+		fmt.Fprintf(tw, "local repair ratio\t%.6g\t[%.6g, %.6g]\n", report.LocalRepairRatio.Mean, report.LocalRepairRatio.Lower, report.LocalRepairRatio.Upper)
+	}
+	fmt.Fprintf(tw, "PDL relative error\t%.6g\n", report.PDLRelativeError)
+	if report.PowerOutageNum.Mean > 0 {
+		fmt.Fprintf(tw, "power outage data loss iterations\t%d\n", report.PowerDataLossIterations)
+		for _, row := range []struct {
+			name     string
+			estimate simulator.Estimate
+		}{
+			{"power outage PDL", report.PowerPDL},
+			{"power outages", report.PowerOutageNum},
+			{"power blocked ratio", report.PowerBlockedRatio},
+		} {
+			fmt.Fprintf(tw, "%s\t%.6g\t[%.6g, %.6g]\n", row.name, row.estimate.Mean, row.estimate.Lower, row.estimate.Upper)
+		}
+	}
+	return tw.Flush()
+}
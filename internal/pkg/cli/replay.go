@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"ECDC_SIM/internal/pkg/event_trigger"
+	"ECDC_SIM/pkg/simulator"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+)
+
+func replayCommand(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("replay", stderr)
+	conf, logs, output := newConfFlags(), new(logFlags), new(outputFlags)
+	conf.register(fs)
+	logs.register(fs)
+	output.register(fs)
+	eventLog := fs.String("event-log", "", "NDJSON event log written by run -event-log")
+	iteration := fs.Int("iteration", 0, "iteration of the event log to replay")
+	if err := conf.parse(fs, args); err != nil {
+		return err
+	}
+	if *eventLog == "" {
+		return errors.New("replay requires -event-log")
+	}
+	dcConf, ecConf, runningConf, err := conf.build()
+	if err != nil {
+		return err
+	}
+	sim, err := simulator.NewSimulator(dcConf, ecConf, runningConf)
+	if err != nil {
+		return err
+	}
+	configHash, err := conf.cfg.Hash()
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(*eventLog)
+	if err != nil {
+		return err
+	}
+	events, err := simulator.ReadEventLog(file, *iteration)
+	file.Close()
+	if err != nil {
+		return fmt.Errorf("%s: %w", *eventLog, err)
+	}
+	logFile, err := logs.setup(stderr)
+	if err != nil {
+		return err
+	}
+	if logFile != nil {
+		defer logFile.Close()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	result, replayed, err := sim.ReplayIteration(ctx, *iteration, events)
+	if err != nil {
+		return err
+	}
+	w, closeOutput, err := output.open(stdout)
+	if err != nil {
+		return err
+	}
+	if output.format == "json" {
+		err = writeJSON(w, simulator.NewIterationRecord(configHash, runningConf.Seed, *iteration, result))
+	} else {
+		err = writeReplay(w, replayed, result)
+	}
+	if closeErr := closeOutput(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// writeReplay 以文本表格逐个列出重放的事件及其引起的状态变化，最后给出迭代结果
+func writeReplay(w io.Writer, events []*event_trigger.EventRecord, result *simulator.SimResult) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "time\tevent\tdevices\tchanges\n")
+	for _, event := range events {
+		changes := make([]string, 0, len(event.Changes))
+		for _, change := range event.Changes {
+			changes = append(changes, fmt.Sprintf("%s %d %s->%s", change.Device, change.Id, change.From, change.To))
+		}
+		fmt.Fprintf(tw, "%g\t%s\t%v\t%s\n", event.Time, event.Event, event.Devices, strings.Join(changes, ", "))
+	}
+	fmt.Fprintf(tw, "\ndata loss\t%t\n", result.DataLoss)
+	for _, lossEvent := range result.LossEvents {
+		fmt.Fprintf(tw, "loss at\t%g\t%d stripes, %d chunks\n", lossEvent.Time, len(lossEvent.FailedStripes), lossEvent.LostChunkNum)
+	}
+	return tw.Flush()
+}
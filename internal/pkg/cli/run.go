@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"ECDC_SIM/internal/pkg/event_trigger"
+	"ECDC_SIM/pkg/simulator"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+)
+
+func runCommand(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("run", stderr)
+	conf, logs, output, records, checkpoints := newConfFlags(), new(logFlags), new(outputFlags), new(recordFlags), new(checkpointFlags)
+	eventLog := new(eventLogFlags)
+	conf.register(fs)
+	logs.register(fs)
+	output.register(fs)
+	records.register(fs)
+	eventLog.register(fs)
+	checkpoints.register(fs)
+	if err := conf.parse(fs, args); err != nil {
+		return err
+	}
+	dcConf, ecConf, runningConf, err := conf.build()
+	if err != nil {
+		return err
+	}
+	sim, err := simulator.NewSimulator(dcConf, ecConf, runningConf)
+	if err != nil {
+		return err
+	}
+	configHash, err := conf.cfg.Hash()
+	if err != nil {
+		return err
+	}
+	logFile, err := logs.setup(stderr)
+	if err != nil {
+		return err
+	}
+	if logFile != nil {
+		defer logFile.Close()
+	}
+
+	checkpoint, err := checkpoints.load(configHash, runningConf.Seed)
+	if err != nil {
+		return err
+	}
+	recordOut, err := records.open(checkpoint.RecordsOffset)
+	if err != nil {
+		return err
+	}
+	if recordOut != nil {
+		sim.SetResultHandler(func(iteration int, result *simulator.SimResult) error {
+			return recordOut.write(simulator.NewIterationRecord(configHash, runningConf.Seed, iteration, result))
+		})
+	}
+	eventLogFile, err := eventLog.open(checkpoint.EventsOffset)
+	if err != nil {
+		if recordOut != nil {
+			recordOut.close()
+		}
+		return err
+	}
+	if eventLogFile != nil {
+		sim.SetEventHandler(func(iteration int, events []*event_trigger.EventRecord, result *simulator.SimResult) error {
+			if !eventLog.all && !result.DataLoss {
+				return nil
+			}
+			return simulator.WriteEventLog(eventLogFile, iteration, events)
+		})
+	}
+	if logs.progress > 0 {
+		sim.SetProgressHandler(logs.progress, func(progress simulator.Progress) {
+			fmt.Fprintln(stderr, progress)
+		})
+	}
+	if checkpoints.path != "" {
+		sim.SetCheckpointHandler(checkpoints.interval, func(aggregate *simulator.Aggregate) error {
+			checkpoint.Aggregate = aggregate
+			if recordOut != nil {
+				if checkpoint.RecordsOffset, err = recordOut.offset(); err != nil {
+					return err
+				}
+			}
+			if eventLogFile != nil {
+				if checkpoint.EventsOffset, err = eventLogFile.Seek(0, io.SeekCurrent); err != nil {
+					return err
+				}
+			}
+			return checkpoint.Save(checkpoints.path)
+		})
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	aggregate, err := sim.Resume(ctx, checkpoint.Aggregate, conf.cfg.Running.Iterations, conf.cfg.Running.Workers)
+	if recordOut != nil {
+		if closeErr := recordOut.close(); err == nil {
+			err = closeErr
+		}
+	}
+	if eventLogFile != nil {
+		if closeErr := eventLogFile.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil && !errors.Is(err, context.Canceled) {
+		return err
+	}
+	w, closeOutput, openErr := output.open(stdout)
+	if openErr != nil {
+		return openErr
+	}
+	summary := &simulator.Summary{ConfigHash: configHash, Seed: runningConf.Seed, RackFaultTolerance: sim.RackFaultTolerance(),
+		Report: aggregate.Report(runningConf.ConfidenceLevel)}
+	if output.format == "json" {
+		err = simulator.WriteSummary(w, summary)
+	} else {
+		err = writeReport(w, summary)
+	}
+	if closeErr := closeOutput(); err == nil {
+		err = closeErr
+	}
+	return err
+}
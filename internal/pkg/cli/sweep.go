@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"ECDC_SIM/pkg/simulator"
+	"ECDC_SIM/pkg/sweep"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+)
+
+// axisFlag 可重复的 -axis name=v1,v2,...，name 为 run 命令的任一配置选项名或配置字段名，格式见 sweep.ParseAxis
+type axisFlag []sweep.Axis
+
+func (a *axisFlag) String() string {
+	axes := make([]string, 0, len(*a))
+	for _, axis := range *a {
+		axes = append(axes, axis.String())
+	}
+	return strings.Join(axes, " ")
+}
+
+func (a *axisFlag) Set(value string) error {
+	axis, err := sweep.ParseAxis(value)
+	if err != nil {
+		return err
+	}
+	*a = append(*a, axis)
+	return nil
+}
+
+func sweepCommand(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("sweep", stderr)
+	conf, logs, output, records := newConfFlags(), new(logFlags), new(outputFlags), new(recordFlags)
+	conf.register(fs)
+	logs.register(fs)
+	output.register(fs)
+	records.register(fs)
+	var axes axisFlag
+	var cacheDir string
+	fs.Var(&axes, "axis", "sweep axis name=v1,v2,..., where name is any configuration option or field such as erasure_code.n or DCConf.RacksNum; use n/k=9/6,14/10 to vary fields together; repeat for a grid")
+	fs.StringVar(&cacheDir, "cache", "", "directory caching finished grid points, so an interrupted sweep resumes where it stopped")
+	if err := conf.parse(fs, args); err != nil {
+		return err
+	}
+	logFile, err := logs.setup(stderr)
+	if err != nil {
+		return err
+	}
+	if logFile != nil {
+		defer logFile.Close()
+	}
+	sw := &sweep.Sweep{
+		Base: conf.cfg,
+		Axes: axes,
+		Set:  setOption,
+		OnResult: func(result *sweep.Result) {
+			if result.Err != nil {
+				fmt.Fprintf(stderr, "skip point %v: %v\n", result.Point, result.Err)
+			}
+		},
+	}
+	if logs.progress > 0 {
+		sw.ProgressInterval = logs.progress
+		sw.OnProgress = func(point *sweep.Result, progress simulator.Progress) {
+			fmt.Fprintf(stderr, "%v: %v\n", point.Point, progress)
+		}
+	}
+	if cacheDir != "" {
+		if sw.Cache, err = sweep.NewCache(cacheDir); err != nil {
+			return err
+		}
+	}
+
+	recordOut, err := records.open(0)
+	if err != nil {
+		return err
+	}
+	if recordOut != nil {
+		sw.OnIteration = func(point *sweep.Result, iteration int, result *simulator.SimResult) error {
+			return recordOut.write(simulator.NewIterationRecord(point.ConfigHash, point.Config.Running.Seed, iteration, result))
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	results, err := sw.Run(ctx)
+	if recordOut != nil {
+		if closeErr := recordOut.close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil && !errors.Is(err, context.Canceled) {
+		return err
+	}
+	rows := make([]*sweep.Result, 0, len(results))
+	for _, result := range results {
+		if result.Err == nil {
+			rows = append(rows, result)
+		}
+	}
+
+	w, closeOutput, err := output.open(stdout)
+	if err != nil {
+		return err
+	}
+	if output.format == "json" {
+		err = writeJSON(w, rows)
+	} else {
+		err = sweep.WriteTable(w, axes, rows)
+	}
+	if closeErr := closeOutput(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"ECDC_SIM/internal/pkg/data_center"
+	"ECDC_SIM/internal/pkg/enum_error"
+	"ECDC_SIM/internal/pkg/util"
+	"ECDC_SIM/pkg/simulator"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+func validateCommand(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("validate", stderr)
+	conf := newConfFlags()
+	conf.register(fs)
+	if err := conf.parse(fs, args); err != nil {
+		return err
+	}
+	dcConf, ecConf, runningConf, err := conf.build()
+	if err != nil {
+		return err
+	}
+	if err = simulator.ValidateConf(dcConf, ecConf, runningConf); err != nil {
+		var errs enum_error.ConfigErrors
+		if errors.As(err, &errs) {
+			for _, e := range errs {
+				fmt.Fprintf(stderr, "invalid: %v\n", e)
+			}
+			return fmt.Errorf("%d configuration error(s)", len(errs))
+		}
+		return err
+	}
+	dcManager := data_center.NewDCManager(dcConf, ecConf)
+	if err = dcManager.GeneratePlacementByArchType(util.NewIterationRand(runningConf.Seed, 0)); err != nil {
+		return fmt.Errorf("placement %s with racks=%d, n=%d: %w", ecConf.ChunkPlaceType, dcConf.RacksNum, ecConf.N, err)
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "configuration\tOK\n")
+	code := dcManager.ErasureCode()
+	fmt.Fprintf(tw, "code\t%s, placement %s\n", code, ecConf.ChunkPlaceType)
+	fmt.Fprintf(tw, "chunk losses tolerated\t%d\n", code.FaultTolerance())
+	fmt.Fprintf(tw, "repair read (chunks)\t%.4g\n", repairReadChunks(code))
+	if ecConf.ChunkPlaceType == data_center.HIERARCHICAL {
+		fmt.Fprintf(tw, "racks per stripe\t%d, at most %d chunks per rack\n", ecConf.HierarchicalRacksNum(), ecConf.ChunksPerRack)
+	}
+	fmt.Fprintf(tw, "rack failures tolerated\t%d\n", dcManager.RackFaultTolerance())
+	fmt.Fprintf(tw, "racks/nodes/disks\t%d/%d/%d\n", dcManager.RackManager().GetRackNum(), dcManager.NodeManager().GetNodeNum(), dcManager.DiskManager().GetDiskNum())
+	fmt.Fprintf(tw, "stripes/chunks\t%d/%d\n", dcConf.StripesNum, dcConf.ChunkNum)
+	fmt.Fprintf(tw, "storage overhead\t%.3f\n", code.StorageOverhead())
+	fmt.Fprintf(tw, "user data (TB)\t%.6g\n", dcManager.GetUsableCapacityTB())
+	return tw.Flush()
+}
+
+// repairReadChunks 条带其余块均可用时修复第一个块读取的数据量，以块为单位
+func repairReadChunks(code data_center.ErasureCode) float64 {
+	alive := make([]int, 0, code.N()-1)
+	for idx := 1; idx < code.N(); idx++ {
+		alive = append(alive, idx)
+	}
+	helpers, helperRatio := code.RepairPlan(0, alive)
+	return float64(len(helpers)) * helperRatio
+}
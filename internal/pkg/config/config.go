@@ -0,0 +1,364 @@
+package config
+
+import (
+	"ECDC_SIM/internal/pkg/data_center"
+	"ECDC_SIM/internal/pkg/event_trigger"
+	"ECDC_SIM/internal/pkg/util"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Config 一次模拟的完整配置，可由 YAML 或 JSON 文件描述，时间单位为小时，带宽单位为 MB/s
+type Config struct {
+	Topology    TopologyConfig    `yaml:"topology" json:"topology"`
+	Failure     FailureConfig     `yaml:"failure" json:"failure"`
+	Repair      RepairConfig      `yaml:"repair" json:"repair"`
+	ErasureCode ErasureCodeConfig `yaml:"erasure_code" json:"erasure_code"`
+	Placement   PlacementConfig   `yaml:"placement" json:"placement"`
+	Network     NetworkConfig     `yaml:"network" json:"network"`
+	Running     RunningConfig     `yaml:"running" json:"running"`
+}
+
+type TopologyConfig struct {
+	Racks        int     `yaml:"racks" json:"racks"`
+	NodesPerRack int     `yaml:"nodes_per_rack" json:"nodes_per_rack"`
+	DisksPerNode int     `yaml:"disks_per_node" json:"disks_per_node"`
+	DiskCapacity int     `yaml:"disk_capacity" json:"disk_capacity"`
+	Stripes      int     `yaml:"stripes" json:"stripes"`
+	Chunks       int     `yaml:"chunks" json:"chunks"` // 为 0 时取 stripes*n
+	ChunkSize    int     `yaml:"chunk_size" json:"chunk_size"`
+	DataChunks   int     `yaml:"data_chunks" json:"data_chunks"`
+	MissionTime  float64 `yaml:"mission_time" json:"mission_time"`
+	PowerDomains int     `yaml:"power_domains" json:"power_domains"` // 供电域数，为 0 时整个数据中心为一个供电域
+}
+
+// WeibullConfig Weibull 分布参数
+type WeibullConfig struct {
+	Shape    float64 `yaml:"shape" json:"shape"`
+	Scale    float64 `yaml:"scale" json:"scale"`
+	Location float64 `yaml:"location" json:"location"`
+}
+
+type FailureConfig struct {
+	Node          *WeibullConfig `yaml:"node" json:"node"`
+	NodeTransient *WeibullConfig `yaml:"node_transient" json:"node_transient"`
+	Disk          *WeibullConfig `yaml:"disk" json:"disk"`
+	Rack          *WeibullConfig `yaml:"rack" json:"rack"`
+	PowerOutage   *WeibullConfig `yaml:"power_outage" json:"power_outage"`
+	PowerUpNode   float64        `yaml:"power_up_node" json:"power_up_node"` // 恢复供电时永久故障的节点比例
+	PowerUpDisk   float64        `yaml:"power_up_disk" json:"power_up_disk"` // 恢复供电时永久故障的磁盘比例
+}
+
+type RepairConfig struct {
+	NodeTransient *WeibullConfig `yaml:"node_transient" json:"node_transient"`
+	Disk          *WeibullConfig `yaml:"disk" json:"disk"`
+	Rack          *WeibullConfig `yaml:"rack" json:"rack"`
+	PowerOutage   *WeibullConfig `yaml:"power_outage" json:"power_outage"` // 断电后恢复供电所需的时间
+}
+
+type ErasureCodeConfig struct {
+	Type     string `yaml:"type" json:"type"`
+	N        int    `yaml:"n" json:"n"`               // LRC 设置了 L 时 N 由 K+L+G 得出，REPLICATION 时取 Replicas
+	K        int    `yaml:"k" json:"k"`               // REPLICATION 时取 1
+	L        int    `yaml:"l" json:"l"`               // LRC 的局部组数
+	G        int    `yaml:"g" json:"g"`               // LRC 的全局校验块数
+	Replicas int    `yaml:"replicas" json:"replicas"` // REPLICATION 的副本数
+	D        int    `yaml:"d" json:"d"`               // MSR、MBR、CLAY 修复时的协助块数，0 表示 n-1
+	// LINEAR 的生成矩阵，每行为一个块的 K 个 GF(2^8) 系数（0-255），N、K 由矩阵的行数与列数得出
+	Generator [][]int `yaml:"generator" json:"generator"`
+}
+
+type PlacementConfig struct {
+	Type          string `yaml:"type" json:"type"`
+	ChunksPerRack int    `yaml:"chunks_per_rack" json:"chunks_per_rack"` // HIERARCHICAL 放置时一个条带在每个机架上最多放置的块数
+}
+
+type NetworkConfig struct {
+	Enabled            bool    `yaml:"enabled" json:"enabled"`
+	CrossRackBandwidth float64 `yaml:"cross_rack_bandwidth" json:"cross_rack_bandwidth"`
+	IntraRackBandwidth float64 `yaml:"intra_rack_bandwidth" json:"intra_rack_bandwidth"`
+	Sharing            string  `yaml:"sharing" json:"sharing"` // 同时进行的修复分配带宽的方式：EXCLUSIVE、MAXMIN 或 WEIGHTED
+}
+
+type RunningConfig struct {
+	Seed                int64   `yaml:"seed" json:"seed"`
+	Iterations          int     `yaml:"iterations" json:"iterations"`
+	Workers             int     `yaml:"workers" json:"workers"`
+	UseTrace            bool    `yaml:"use_trace" json:"use_trace"`
+	Trace               string  `yaml:"trace" json:"trace"` // 故障记录文件（.csv 或 .json），给出时即开启 UseTrace
+	UsePowerOutage      bool    `yaml:"use_power_outage" json:"use_power_outage"`
+	TransientFailure    bool    `yaml:"transient_failure" json:"transient_failure"`
+	ContinueAfterLoss   bool    `yaml:"continue_after_loss" json:"continue_after_loss"`
+	RestoreLostStripes  bool    `yaml:"restore_lost_stripes" json:"restore_lost_stripes"`
+	StripeRepair        bool    `yaml:"stripe_repair" json:"stripe_repair"`
+	StripeRepairSlots   int     `yaml:"stripe_repair_slots" json:"stripe_repair_slots"`
+	RepairPolicy        string  `yaml:"repair_policy" json:"repair_policy"`
+	RepairThreshold     int     `yaml:"repair_threshold" json:"repair_threshold"`
+	RepairBatchInterval float64 `yaml:"repair_batch_interval" json:"repair_batch_interval"` // 单位小时
+	ConfidenceLevel     float64 `yaml:"confidence_level" json:"confidence_level"`
+	TargetRelativeError float64 `yaml:"target_relative_error" json:"target_relative_error"`
+	TimeBudget          string  `yaml:"time_budget" json:"time_budget"` // 运行时间预算，如 "90m"，为空时不限制
+}
+
+// Default 默认配置，与 simulator_test 中的基准场景一致
+func Default() *Config {
+	return &Config{
+		Topology: TopologyConfig{
+			Racks:        32,
+			NodesPerRack: 32,
+			DisksPerNode: 1,
+			DiskCapacity: int(math.Pow(2, 10)),
+			Stripes:      340000,
+			ChunkSize:    256,
+			MissionTime:  87600,
+		},
+		Failure: FailureConfig{
+			Node:          &WeibullConfig{Shape: 1, Scale: 91250},
+			NodeTransient: &WeibullConfig{Shape: 1, Scale: 2890.8},
+			Disk:          &WeibullConfig{Shape: 1.12, Scale: 87600},
+			Rack:          &WeibullConfig{Shape: 1, Scale: 87600},
+			PowerOutage:   &WeibullConfig{Shape: 1, Scale: 43800},
+		},
+		Repair: RepairConfig{
+			NodeTransient: &WeibullConfig{Shape: 1, Scale: 0.25},
+			Rack:          &WeibullConfig{Shape: 1, Scale: 24, Location: 10},
+			PowerOutage:   &WeibullConfig{Shape: 1, Scale: 2},
+		},
+		ErasureCode: ErasureCodeConfig{Type: data_center.RS.String(), N: 9, K: 6, Replicas: 3},
+		Placement:   PlacementConfig{Type: data_center.FLAT.String(), ChunksPerRack: 3},
+		Network: NetworkConfig{
+			Enabled:            true,
+			CrossRackBandwidth: 125,
+			IntraRackBandwidth: 125,
+			Sharing:            data_center.BandwidthExclusive.String(),
+		},
+		Running: RunningConfig{
+			Iterations:          1000,
+			StripeRepairSlots:   1,
+			RepairPolicy:        event_trigger.RepairEager.String(),
+			RepairThreshold:     2,
+			RepairBatchInterval: 24,
+		},
+	}
+}
+
+// Load 读取配置文件，按扩展名识别 YAML（.yaml/.yml）或 JSON（.json），文件中未出现的字段保留默认值
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var format string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		format = "yaml"
+	case ".json":
+		format = "json"
+	default:
+		return nil, fmt.Errorf("config %s: unknown file extension, want .yaml, .yml or .json", path)
+	}
+	cfg, err := Parse(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+	// 故障记录文件的相对路径相对于配置文件所在目录
+	if cfg.Running.Trace != "" && !filepath.IsAbs(cfg.Running.Trace) {
+		cfg.Running.Trace = filepath.Join(filepath.Dir(path), cfg.Running.Trace)
+	}
+	return cfg, nil
+}
+
+// Parse 解析 yaml 或 json 格式的配置内容，未知字段视为错误
+func Parse(data []byte, format string) (*Config, error) {
+	cfg := Default()
+	switch format {
+	case "yaml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+	case "json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(cfg); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown config format %q", format)
+	}
+	return cfg, nil
+}
+
+// Clone 深拷贝配置
+func (c *Config) Clone() *Config {
+	clone := *c
+	for _, weibull := range []**WeibullConfig{
+		&clone.Failure.Node, &clone.Failure.NodeTransient, &clone.Failure.Disk, &clone.Failure.Rack, &clone.Failure.PowerOutage,
+		&clone.Repair.NodeTransient, &clone.Repair.Disk, &clone.Repair.Rack, &clone.Repair.PowerOutage,
+	} {
+		if *weibull != nil {
+			copied := **weibull
+			*weibull = &copied
+		}
+	}
+	if c.ErasureCode.Generator != nil {
+		clone.ErasureCode.Generator = make([][]int, len(c.ErasureCode.Generator))
+		for i, row := range c.ErasureCode.Generator {
+			clone.ErasureCode.Generator[i] = append([]int(nil), row...)
+		}
+	}
+	return &clone
+}
+
+// Hash 配置内容的 SHA-256 摘要，用于标识结果对应的模型配置。
+// 迭代次数、并行度、置信水平与停止规则只决定运行多少次迭代、如何汇总，不影响每次迭代的结果，不参与计算
+func (c *Config) Hash() (string, error) {
+	keyed := c.Clone()
+	keyed.Running.Iterations, keyed.Running.Workers = 0, 0
+	keyed.Running.ConfidenceLevel, keyed.Running.TargetRelativeError, keyed.Running.TimeBudget = 0, 0, ""
+	data, err := json.Marshal(keyed)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Build 生成模拟器使用的 DCConf、ErasureCodeConf 与 RunningConfig
+func (c *Config) Build() (*data_center.DCConf, *data_center.ErasureCodeConf, *event_trigger.RunningConfig, error) {
+	codeType, err := data_center.ParseErasureCodeType(c.ErasureCode.Type)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	placeType, err := data_center.ParseChunkPlaceType(c.Placement.Type)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	sharing, err := data_center.ParseBandwidthSharing(c.Network.Sharing)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	repairPolicy, err := event_trigger.ParseRepairPolicy(c.Running.RepairPolicy)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var trace *event_trigger.Trace
+	if c.Running.Trace != "" {
+		if trace, err = event_trigger.LoadTrace(c.Running.Trace); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	var timeBudget time.Duration
+	if c.Running.TimeBudget != "" {
+		if timeBudget, err = time.ParseDuration(c.Running.TimeBudget); err != nil {
+			return nil, nil, nil, fmt.Errorf("running.time_budget: %w", err)
+		}
+	}
+	dcConf := &data_center.DCConf{
+		RacksNum:                    c.Topology.Racks,
+		StripesNum:                  c.Topology.Stripes,
+		DisksPerNode:                c.Topology.DisksPerNode,
+		DiskCapacity:                c.Topology.DiskCapacity,
+		NodesPerRack:                c.Topology.NodesPerRack,
+		ChunkNum:                    c.Topology.Chunks,
+		ChunkSize:                   c.Topology.ChunkSize,
+		DataChunksNum:               c.Topology.DataChunks,
+		NFailD:                      c.Failure.Node.weibull(),
+		NTFailD:                     c.Failure.NodeTransient.weibull(),
+		NTRepairD:                   c.Repair.NodeTransient.weibull(),
+		DFailD:                      c.Failure.Disk.weibull(),
+		DRepairD:                    c.Repair.Disk.weibull(),
+		RFailD:                      c.Failure.Rack.weibull(),
+		RRepairD:                    c.Repair.Rack.weibull(),
+		MaxCrossRackRepairBandwidth: c.Network.CrossRackBandwidth,
+		MaxIntraRackRepairBandwidth: c.Network.IntraRackBandwidth,
+		MissionTime:                 c.Topology.MissionTime,
+		UseNetwork:                  c.Network.Enabled,
+		BandwidthSharing:            sharing,
+		PowerDomainsNum:             c.Topology.PowerDomains,
+		POutageD:                    c.Failure.PowerOutage.weibull(),
+		PRestoreD:                   c.Repair.PowerOutage.weibull(),
+		PowerUpFailure:              data_center.PowerUpFailure{NodeRatio: c.Failure.PowerUpNode, DiskRatio: c.Failure.PowerUpDisk},
+	}
+	ecConf := &data_center.ErasureCodeConf{
+		CodeType:       codeType,
+		ChunkPlaceType: placeType,
+		N:              c.ErasureCode.N,
+		K:              c.ErasureCode.K,
+		ChunksPerRack:  c.Placement.ChunksPerRack,
+		D:              c.ErasureCode.D,
+	}
+	switch codeType {
+	case data_center.LRC:
+		ecConf.L, ecConf.G = c.ErasureCode.L, c.ErasureCode.G
+		if ecConf.L > 0 {
+			ecConf.N = ecConf.K + ecConf.L + ecConf.G
+		}
+	case data_center.REPLICATION:
+		ecConf.N, ecConf.K = c.ErasureCode.Replicas, 1
+	case data_center.LINEAR:
+		if ecConf.Generator, err = c.ErasureCode.generator(); err != nil {
+			return nil, nil, nil, err
+		}
+		ecConf.N, ecConf.K = len(ecConf.Generator), 0
+		if ecConf.N > 0 {
+			ecConf.K = len(ecConf.Generator[0])
+		}
+	}
+	if dcConf.ChunkNum == 0 {
+		dcConf.ChunkNum = dcConf.StripesNum * ecConf.N
+	}
+	runningConf := &event_trigger.RunningConfig{
+		Seed:                   c.Running.Seed,
+		UseTrace:               c.Running.UseTrace || trace != nil,
+		Trace:                  trace,
+		UsePowerOutage:         c.Running.UsePowerOutage,
+		EnableTransientFailure: c.Running.TransientFailure,
+		ContinueAfterLoss:      c.Running.ContinueAfterLoss,
+		RestoreLostStripes:     c.Running.RestoreLostStripes,
+		StripeRepair:           c.Running.StripeRepair,
+		StripeRepairSlots:      c.Running.StripeRepairSlots,
+		RepairPolicy:           repairPolicy,
+		RepairThreshold:        c.Running.RepairThreshold,
+		RepairBatchInterval:    c.Running.RepairBatchInterval,
+		ConfidenceLevel:        c.Running.ConfidenceLevel,
+		TargetRelativeError:    c.Running.TargetRelativeError,
+		TimeBudget:             timeBudget,
+	}
+	return dcConf, ecConf, runningConf, nil
+}
+
+// generator 将生成矩阵转换为 GF(2^8) 元素，各行长度由 ErasureCodeConf.Validate 检查
+func (e *ErasureCodeConfig) generator() ([][]byte, error) {
+	generator := make([][]byte, 0, len(e.Generator))
+	for i, row := range e.Generator {
+		converted := make([]byte, 0, len(row))
+		for _, value := range row {
+			if value < 0 || value > 255 {
+				return nil, fmt.Errorf("erasure_code.generator: row %d has %d, want values in [0, 255]", i, value)
+			}
+			converted = append(converted, byte(value))
+		}
+		generator = append(generator, converted)
+	}
+	return generator, nil
+}
+
+func (w *WeibullConfig) weibull() *util.Weibull {
+	if w == nil {
+		return nil
+	}
+	return util.NewWeibull(w.Shape, w.Scale, w.Location)
+}
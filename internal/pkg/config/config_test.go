@@ -0,0 +1,204 @@
+package config
+
+import (
+	"ECDC_SIM/internal/pkg/data_center"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		format  string
+		want    func(cfg *Config)
+		wantErr bool
+	}{
+		{name: "TestEmptyYAML", data: "", format: "yaml", want: func(cfg *Config) {}},
+		{name: "TestYAML", format: "yaml", data: `
+topology:
+  racks: 16
+erasure_code: {type: RS, n: 14, k: 10}
+failure:
+  disk: {shape: 1.5, scale: 1000}
+repair:
+  rack: null
+`, want: func(cfg *Config) {
+			cfg.Topology.Racks = 16
+			cfg.ErasureCode.N, cfg.ErasureCode.K = 14, 10
+			cfg.Failure.Disk = &WeibullConfig{Shape: 1.5, Scale: 1000}
+			cfg.Repair.Rack = nil
+		}},
+		{name: "TestJSON", format: "json", data: `{"network": {"cross_rack_bandwidth": 10}, "running": {"seed": 3}}`, want: func(cfg *Config) {
+			cfg.Network.CrossRackBandwidth = 10
+			cfg.Running.Seed = 3
+		}},
+		{name: "TestUnknownYAMLField", format: "yaml", data: "topology:\n  rack: 16\n", wantErr: true},
+		{name: "TestUnknownJSONField", format: "json", data: `{"erasure": {}}`, wantErr: true},
+		{name: "TestUnknownFormat", format: "toml", data: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse([]byte(tt.data), tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			want := Default()
+			tt.want(want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Parse() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"conf.yml":  "erasure_code: {n: 12, k: 8}\n",
+		"conf.json": `{"erasure_code": {"n": 12, "k": 8}}`,
+		"conf.toml": "",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"conf.yml", "conf.json"} {
+		cfg, err := Load(filepath.Join(dir, name))
+		if err != nil || cfg.ErasureCode.N != 12 || cfg.ErasureCode.K != 8 {
+			t.Errorf("Load(%s) = %+v, %v", name, cfg, err)
+		}
+	}
+	if _, err := Load(filepath.Join(dir, "conf.toml")); err == nil {
+		t.Error("Load(conf.toml) want error")
+	}
+	if _, err := Load("../../../configs/example.yaml"); err != nil {
+		t.Errorf("Load(example.yaml) error = %v", err)
+	}
+}
+
+func TestConfig_Build(t *testing.T) {
+	cfg := Default()
+	cfg.Topology.Stripes = 100
+	cfg.ErasureCode.Type, cfg.Placement.Type = "lrc", "hierarchical"
+	dcConf, ecConf, runningConf, err := cfg.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if dcConf.ChunkNum != 100*9 || dcConf.DRepairD != nil || dcConf.DFailD.Shape() != 1.12 || dcConf.RRepairD.Location() != 10 {
+		t.Errorf("Build() dcConf = %+v", dcConf)
+	}
+	if ecConf.CodeType != data_center.LRC || ecConf.ChunkPlaceType != data_center.HIERARCHICAL {
+		t.Errorf("Build() ecConf = %+v", ecConf)
+	}
+	if runningConf.Seed != 0 || runningConf.UseTrace {
+		t.Errorf("Build() runningConf = %+v", runningConf)
+	}
+	cfg.ErasureCode.K, cfg.ErasureCode.L, cfg.ErasureCode.G = 12, 2, 2
+	if dcConf, ecConf, _, err = cfg.Build(); err != nil || ecConf.N != 16 || ecConf.L != 2 || ecConf.G != 2 || dcConf.ChunkNum != 100*16 {
+		t.Errorf("Build() LRC ecConf = %+v, ChunkNum = %d, err = %v, want N=k+l+g", ecConf, dcConf.ChunkNum, err)
+	}
+	cfg.ErasureCode.Type, cfg.ErasureCode.Replicas = "replication", 2
+	if dcConf, ecConf, _, err = cfg.Build(); err != nil || ecConf.N != 2 || ecConf.K != 1 || dcConf.ChunkNum != 100*2 {
+		t.Errorf("Build() REPLICATION ecConf = %+v, ChunkNum = %d, err = %v, want N=replicas, K=1", ecConf, dcConf.ChunkNum, err)
+	}
+	cfg.ErasureCode.Type, cfg.ErasureCode.Generator = "linear", [][]int{{1, 0}, {0, 1}, {1, 1}}
+	if dcConf, ecConf, _, err = cfg.Build(); err != nil || ecConf.N != 3 || ecConf.K != 2 || !reflect.DeepEqual(ecConf.Generator, [][]byte{{1, 0}, {0, 1}, {1, 1}}) || dcConf.ChunkNum != 100*3 {
+		t.Errorf("Build() LINEAR ecConf = %+v, ChunkNum = %d, err = %v, want N, K from the generator", ecConf, dcConf.ChunkNum, err)
+	}
+	cfg.ErasureCode.Generator[2][1] = 256
+	if _, _, _, err = cfg.Build(); err == nil {
+		t.Error("Build() with a generator element out of GF(2^8) want error")
+	}
+	cfg.ErasureCode.Type = "XOR"
+	if _, _, _, err = cfg.Build(); err == nil {
+		t.Error("Build() with unknown code want error")
+	}
+}
+
+func TestConfig_Clone(t *testing.T) {
+	cfg := Default()
+	clone := cfg.Clone()
+	clone.Failure.Disk.Scale = 1
+	clone.Topology.Racks = 1
+	if cfg.Failure.Disk.Scale == 1 || cfg.Topology.Racks == 1 {
+		t.Error("Clone() shares state with the original")
+	}
+}
+
+func TestConfig_Set(t *testing.T) {
+	tests := []struct {
+		name    string
+		field   string
+		value   string
+		want    func(cfg *Config)
+		wantErr bool
+	}{
+		{name: "TestPath", field: "topology.racks", value: "16", want: func(cfg *Config) { cfg.Topology.Racks = 16 }},
+		{name: "TestStructField", field: "DCConf.MaxCrossRackRepairBandwidth", value: "10", want: func(cfg *Config) { cfg.Network.CrossRackBandwidth = 10 }},
+		{name: "TestBareField", field: "racksnum", value: "16", want: func(cfg *Config) { cfg.Topology.Racks = 16 }},
+		{name: "TestErasureCode", field: "ErasureCodeConf.K", value: "5", want: func(cfg *Config) { cfg.ErasureCode.K = 5 }},
+		{name: "TestString", field: "ErasureCodeConf.ChunkPlaceType", value: "HIERARCHICAL", want: func(cfg *Config) { cfg.Placement.Type = "HIERARCHICAL" }},
+		{name: "TestBool", field: "network.enabled", value: "false", want: func(cfg *Config) { cfg.Network.Enabled = false }},
+		{name: "TestWeibull", field: "DFailD", value: "1.2, 100, 0", want: func(cfg *Config) { cfg.Failure.Disk = &WeibullConfig{Shape: 1.2, Scale: 100} }},
+		{name: "TestWeibullNone", field: "repair.rack", value: "none", want: func(cfg *Config) { cfg.Repair.Rack = nil }},
+		{name: "TestGenerator", field: "ErasureCodeConf.Generator", value: "1,0; 0,1; 1,1", want: func(cfg *Config) { cfg.ErasureCode.Generator = [][]int{{1, 0}, {0, 1}, {1, 1}} }},
+		{name: "TestBadGenerator", field: "erasure_code.generator", value: "1,x", wantErr: true},
+		{name: "TestUnknownSection", field: "foo.racks", value: "1", wantErr: true},
+		{name: "TestUnknownField", field: "topology.foo", value: "1", wantErr: true},
+		{name: "TestSection", field: "topology", value: "1", wantErr: true},
+		{name: "TestBadInt", field: "topology.racks", value: "1.5", wantErr: true},
+		{name: "TestBadWeibull", field: "failure.disk", value: "1,2", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Default()
+			err := got.Set(tt.field, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Set() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			want := Default()
+			tt.want(want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Set() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestConfig_Hash(t *testing.T) {
+	hash, err := Default().Hash()
+	if err != nil || len(hash) != 64 {
+		t.Fatalf("Hash() = %s, %v", hash, err)
+	}
+	tests := []struct {
+		name     string
+		modify   func(cfg *Config)
+		wantSame bool
+	}{
+		{name: "TestIterations", modify: func(cfg *Config) { cfg.Running.Iterations = 5 }, wantSame: true},
+		{name: "TestWorkers", modify: func(cfg *Config) { cfg.Running.Workers = 8 }, wantSame: true},
+		{name: "TestStoppingRule", modify: func(cfg *Config) { cfg.Running.TargetRelativeError = 0.1 }, wantSame: true},
+		{name: "TestSeed", modify: func(cfg *Config) { cfg.Running.Seed = 1 }},
+		{name: "TestRacks", modify: func(cfg *Config) { cfg.Topology.Racks = 16 }},
+		{name: "TestWeibull", modify: func(cfg *Config) { cfg.Failure.Disk.Scale = 1 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			tt.modify(cfg)
+			if got, _ := cfg.Hash(); (got == hash) != tt.wantSame {
+				t.Errorf("Hash() = %s, base %s, wantSame %t", got, hash, tt.wantSame)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// fieldAliases 模拟器配置结构体中的字段名到配置路径的映射，便于直接按 DCConf、ErasureCodeConf、RunningConfig 的字段名设置
+var fieldAliases = map[string]string{
+	"dcconf.racksnum":                      "topology.racks",
+	"dcconf.stripesnum":                    "topology.stripes",
+	"dcconf.diskspernode":                  "topology.disks_per_node",
+	"dcconf.diskcapacity":                  "topology.disk_capacity",
+	"dcconf.nodesperrack":                  "topology.nodes_per_rack",
+	"dcconf.chunknum":                      "topology.chunks",
+	"dcconf.chunksize":                     "topology.chunk_size",
+	"dcconf.datachunksnum":                 "topology.data_chunks",
+	"dcconf.missiontime":                   "topology.mission_time",
+	"dcconf.nfaild":                        "failure.node",
+	"dcconf.ntfaild":                       "failure.node_transient",
+	"dcconf.ntrepaird":                     "repair.node_transient",
+	"dcconf.dfaild":                        "failure.disk",
+	"dcconf.drepaird":                      "repair.disk",
+	"dcconf.rfaild":                        "failure.rack",
+	"dcconf.rrepaird":                      "repair.rack",
+	"dcconf.maxcrossrackrepairbandwidth":   "network.cross_rack_bandwidth",
+	"dcconf.maxintrarackrepairbandwidth":   "network.intra_rack_bandwidth",
+	"dcconf.usenetwork":                    "network.enabled",
+	"dcconf.bandwidthsharing":              "network.sharing",
+	"dcconf.powerdomainsnum":               "topology.power_domains",
+	"dcconf.poutaged":                      "failure.power_outage",
+	"dcconf.prestored":                     "repair.power_outage",
+	"dcconf.powerupfailure.noderatio":      "failure.power_up_node",
+	"dcconf.powerupfailure.diskratio":      "failure.power_up_disk",
+	"erasurecodeconf.codetype":             "erasure_code.type",
+	"erasurecodeconf.chunkplacetype":       "placement.type",
+	"erasurecodeconf.chunksperrack":        "placement.chunks_per_rack",
+	"erasurecodeconf.n":                    "erasure_code.n",
+	"erasurecodeconf.k":                    "erasure_code.k",
+	"erasurecodeconf.l":                    "erasure_code.l",
+	"erasurecodeconf.g":                    "erasure_code.g",
+	"erasurecodeconf.generator":            "erasure_code.generator",
+	"erasurecodeconf.d":                    "erasure_code.d",
+	"runningconfig.seed":                   "running.seed",
+	"runningconfig.usetrace":               "running.use_trace",
+	"runningconfig.trace":                  "running.trace",
+	"runningconfig.usepoweroutage":         "running.use_power_outage",
+	"runningconfig.enabletransientfailure": "running.transient_failure",
+	"runningconfig.continueafterloss":      "running.continue_after_loss",
+	"runningconfig.restoreloststripes":     "running.restore_lost_stripes",
+	"runningconfig.striperepairslots":      "running.stripe_repair_slots",
+	"runningconfig.striperepair":           "running.stripe_repair",
+	"runningconfig.repairpolicy":           "running.repair_policy",
+	"runningconfig.repairthreshold":        "running.repair_threshold",
+	"runningconfig.repairbatchinterval":    "running.repair_batch_interval",
+	"runningconfig.confidencelevel":        "running.confidence_level",
+	"runningconfig.targetrelativeerror":    "running.target_relative_error",
+	"runningconfig.timebudget":             "running.time_budget",
+}
+
+// resolvePath 将字段名解析为配置路径。name 可以是配置路径（如 topology.racks），
+// 也可以是模拟器配置的字段名，带或不带结构体前缀（如 DCConf.RacksNum、RacksNum、ErasureCodeConf.N）
+func resolvePath(name string) string {
+	lower := strings.ToLower(name)
+	for alias, path := range fieldAliases {
+		if lower == alias || lower == alias[strings.Index(alias, ".")+1:] {
+			return path
+		}
+	}
+	return lower
+}
+
+// Set 将 value 解析后写入 name 指定的配置项，name 的格式见 resolvePath。
+// Weibull 分布的取值格式为 "shape,scale,location"，"none" 表示不设置
+func (c *Config) Set(name, value string) error {
+	path := resolvePath(name)
+	section, field, ok := strings.Cut(path, ".")
+	if !ok {
+		return fmt.Errorf("unknown config field %q", name)
+	}
+	sectionValue, ok := lookupField(reflect.ValueOf(c).Elem(), section)
+	if !ok || sectionValue.Kind() != reflect.Struct {
+		return fmt.Errorf("unknown config field %q", name)
+	}
+	fieldValue, ok := lookupField(sectionValue, field)
+	if !ok {
+		return fmt.Errorf("unknown config field %q", name)
+	}
+	if err := setValue(fieldValue, value); err != nil {
+		return fmt.Errorf("%s=%s: %w", name, value, err)
+	}
+	return nil
+}
+
+// lookupField 按 yaml 标签查找结构体字段
+func lookupField(v reflect.Value, tag string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("yaml") == tag {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func setValue(v reflect.Value, value string) error {
+	value = strings.TrimSpace(value)
+	switch v.Interface().(type) {
+	case *WeibullConfig:
+		weibull, err := ParseWeibull(value)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(weibull))
+		return nil
+	case [][]int:
+		generator, err := ParseGenerator(value)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(generator))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.String:
+		v.SetString(value)
+	default:
+		return fmt.Errorf("unsupported field type %s", v.Type())
+	}
+	return nil
+}
+
+// ParseGenerator 解析以 ";" 分隔各行、"," 分隔各列的矩阵，如 "1,0;0,1;1,1"，空字符串返回 nil
+func ParseGenerator(value string) ([][]int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var generator [][]int
+	for _, rowValue := range strings.Split(value, ";") {
+		fields := strings.Split(rowValue, ",")
+		row := make([]int, 0, len(fields))
+		for _, field := range fields {
+			element, err := strconv.Atoi(strings.TrimSpace(field))
+			if err != nil {
+				return nil, err
+			}
+			row = append(row, element)
+		}
+		generator = append(generator, row)
+	}
+	return generator, nil
+}
+
+// ParseWeibull 解析 "shape,scale,location" 形式的 Weibull 分布，"none" 返回 nil
+func ParseWeibull(value string) (*WeibullConfig, error) {
+	if strings.EqualFold(value, "none") {
+		return nil, nil
+	}
+	fields := strings.Split(value, ",")
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("want shape,scale,location, got %q", value)
+	}
+	params := make([]float64, 0, len(fields))
+	for _, field := range fields {
+		param, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, param)
+	}
+	return &WeibullConfig{Shape: params[0], Scale: params[1], Location: params[2]}, nil
+}
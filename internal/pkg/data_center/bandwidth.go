@@ -0,0 +1,171 @@
+package data_center
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// BandwidthSharing 同时进行的多个磁盘修复如何分配修复带宽
+type BandwidthSharing int8
+
+const (
+	BandwidthExclusive BandwidthSharing = iota // 一个修复独占全部跨机架带宽与所在机架的机架内带宽，其余修复排队等待
+	BandwidthMaxMin                            // 各修复平分所用链路的带宽（max-min 公平分配）
+	BandwidthWeighted                          // 各修复按权重分配所用链路的带宽
+)
+
+var bandwidthSharingNames = map[BandwidthSharing]string{
+	BandwidthExclusive: "EXCLUSIVE",
+	BandwidthMaxMin:    "MAXMIN",
+	BandwidthWeighted:  "WEIGHTED",
+}
+
+func (s BandwidthSharing) String() string {
+	if name, ok := bandwidthSharingNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("BandwidthSharing(%d)", int8(s))
+}
+
+// ParseBandwidthSharing 根据名称（不区分大小写）解析带宽分配方式
+func ParseBandwidthSharing(name string) (BandwidthSharing, error) {
+	for sharing, sharingName := range bandwidthSharingNames {
+		if strings.EqualFold(sharingName, name) {
+			return sharing, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown bandwidth sharing %q", name)
+}
+
+// flowEpsilon 剩余数据量（MB）小于该值时视为读取完成，避免浮点误差产生极短的推进步长
+const flowEpsilon = 1e-9
+
+// repairFlow 共享带宽时一个正在进行的修复，跨机架与机架内的读取分别占用对应链路的带宽同时进行
+type repairFlow struct {
+	id     int
+	rackId int
+	weight float64
+	cross  float64 // 尚未完成的跨机架读取量（MB）
+	intra  float64 // 尚未完成的机架内读取量（MB）
+}
+
+func (n *NetworkManager) Sharing() BandwidthSharing {
+	return n.sharing
+}
+
+// StartRepair 共享带宽时在 now 时刻开始编号为 id 的修复，crossMB、intraMB 为需要跨机架与在机架 rackId 内读取的数据量，
+// weight 为 WEIGHTED 分配时的权重
+func (n *NetworkManager) StartRepair(id, rackId int, crossMB, intraMB, weight, now float64) {
+	n.advance(now)
+	n.flows = append(n.flows, &repairFlow{id: id, rackId: rackId, weight: weight, cross: crossMB, intra: intraMB})
+}
+
+// FinishRepair 修复 id 在 now 时刻结束，不再占用带宽
+func (n *NetworkManager) FinishRepair(id int, now float64) {
+	n.advance(now)
+	for idx, flow := range n.flows {
+		if flow.id == id {
+			n.flows = append(n.flows[:idx], n.flows[idx+1:]...)
+			return
+		}
+	}
+}
+
+// RepairCompletionTimes 假设不再有修复开始或结束，按流体模型推算各个正在进行的修复完成的时刻（小时）
+func (n *NetworkManager) RepairCompletionTimes() map[int]float64 {
+	flows := make([]*repairFlow, 0, len(n.flows))
+	for _, flow := range n.flows {
+		flowCopy := *flow
+		flows = append(flows, &flowCopy)
+	}
+	times := make(map[int]float64, len(flows))
+	n.drain(flows, n.flowTime, math.Inf(1), func(flow *repairFlow, t float64) {
+		times[flow.id] = t
+	})
+	return times
+}
+
+// advance 把正在进行的修复推进到 now 时刻
+func (n *NetworkManager) advance(now float64) {
+	if now > n.flowTime {
+		n.flows = n.drain(n.flows, n.flowTime, now, nil)
+		n.flowTime = now
+	}
+}
+
+// drain 按流体模型把 flows 从 from 时刻推进到 until 时刻或全部完成，返回尚未完成的修复。
+// 每当有一路读取完成都重新分配带宽，修复完成时以完成时刻调用 done
+func (n *NetworkManager) drain(flows []*repairFlow, from, until float64, done func(flow *repairFlow, t float64)) []*repairFlow {
+	now := from
+	for {
+		active := make([]*repairFlow, 0, len(flows))
+		for _, flow := range flows {
+			if flow.cross > 0 || flow.intra > 0 {
+				active = append(active, flow)
+			} else if done != nil {
+				done(flow, now)
+			}
+		}
+		flows = active
+		if len(flows) == 0 || now >= until {
+			return flows
+		}
+		crossRate, intraRate := n.shareRates(flows)
+		// 推进到下一路读取完成或 until，期间各修复的速率不变
+		step, next := until-now, until
+		for idx, flow := range flows {
+			if flow.cross > 0 && flow.cross/crossRate[idx] < step {
+				step = flow.cross / crossRate[idx]
+				next = now + step
+			}
+			if flow.intra > 0 && flow.intra/intraRate[idx] < step {
+				step = flow.intra / intraRate[idx]
+				next = now + step
+			}
+		}
+		for idx, flow := range flows {
+			flow.cross = remainingMB(flow.cross, crossRate[idx]*step)
+			flow.intra = remainingMB(flow.intra, intraRate[idx]*step)
+		}
+		now = next
+	}
+}
+
+// shareRates 各修复当前跨机架与机架内读取的速率（MB/小时）。每条链路的带宽在仍有数据要读取的修复间按权重分配，
+// 每路读取只经过一条链路，因此 MAXMIN 下的平分即为 max-min 公平分配
+func (n *NetworkManager) shareRates(flows []*repairFlow) (crossRate, intraRate []float64) {
+	crossWeight, intraWeight := 0.0, make(map[int]float64)
+	for _, flow := range flows {
+		if flow.cross > 0 {
+			crossWeight += n.flowWeight(flow)
+		}
+		if flow.intra > 0 {
+			intraWeight[flow.rackId] += n.flowWeight(flow)
+		}
+	}
+	crossRate, intraRate = make([]float64, len(flows)), make([]float64, len(flows))
+	for idx, flow := range flows {
+		if flow.cross > 0 {
+			crossRate[idx] = n.maxCrossRackRepairBandwidth * 3600 * n.flowWeight(flow) / crossWeight
+		}
+		if flow.intra > 0 {
+			intraRate[idx] = n.maxIntraRackRepairBandwidth * 3600 * n.flowWeight(flow) / intraWeight[flow.rackId]
+		}
+	}
+	return
+}
+
+func (n *NetworkManager) flowWeight(flow *repairFlow) float64 {
+	if n.sharing == BandwidthWeighted && flow.weight > 0 {
+		return flow.weight
+	}
+	return 1
+}
+
+func remainingMB(size, sent float64) float64 {
+	if size-sent < flowEpsilon {
+		return 0
+	}
+	return size - sent
+}
@@ -0,0 +1,86 @@
+package data_center
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNetworkManager_RepairCompletionTimes(t *testing.T) {
+	type flow struct {
+		id, rackId           int
+		cross, intra, weight float64
+		start                float64
+	}
+	type finish struct {
+		id int
+		at float64
+	}
+	// 带宽均为 1 MB/s，3600 MB 的读取独占链路时用时 1 小时
+	tests := []struct {
+		name    string
+		sharing BandwidthSharing
+		flows   []flow
+		finish  []finish
+		want    map[int]float64
+	}{
+		{name: "TestSingleRepair", sharing: BandwidthMaxMin, flows: []flow{
+			{id: 1, cross: 3600, intra: 7200},
+		}, want: map[int]float64{1: 2}},
+		{name: "TestEqualShare", sharing: BandwidthMaxMin, flows: []flow{
+			{id: 1, cross: 3600},
+			{id: 2, cross: 3600},
+		}, want: map[int]float64{1: 2, 2: 2}},
+		{name: "TestReleaseOnCompletion", sharing: BandwidthMaxMin, flows: []flow{
+			{id: 1, cross: 3600},
+			{id: 2, cross: 10800},
+		}, want: map[int]float64{1: 2, 2: 4}},
+		{name: "TestCrossReleasedBeforeIntra", sharing: BandwidthMaxMin, flows: []flow{
+			{id: 1, cross: 1800, intra: 7200},
+			{id: 2, cross: 5400},
+		}, want: map[int]float64{1: 2, 2: 2}},
+		{name: "TestWeighted", sharing: BandwidthWeighted, flows: []flow{
+			{id: 1, cross: 3600, weight: 3},
+			{id: 2, cross: 3600, weight: 1},
+		}, want: map[int]float64{1: 4.0 / 3, 2: 2}},
+		{name: "TestMaxMinIgnoresWeight", sharing: BandwidthMaxMin, flows: []flow{
+			{id: 1, cross: 3600, weight: 3},
+			{id: 2, cross: 3600, weight: 1},
+		}, want: map[int]float64{1: 2, 2: 2}},
+		{name: "TestIntraRackPerRack", sharing: BandwidthMaxMin, flows: []flow{
+			{id: 1, rackId: 0, intra: 3600},
+			{id: 2, rackId: 1, intra: 3600},
+			{id: 3, rackId: 1, intra: 3600},
+		}, want: map[int]float64{1: 1, 2: 2, 3: 2}},
+		{name: "TestLateStart", sharing: BandwidthMaxMin, flows: []flow{
+			{id: 1, cross: 7200},
+			{id: 2, cross: 3600, start: 1},
+		}, want: map[int]float64{1: 3, 2: 3}},
+		{name: "TestFinishReleases", sharing: BandwidthMaxMin, flows: []flow{
+			{id: 1, cross: 7200},
+			{id: 2, cross: 7200},
+		}, finish: []finish{{id: 2, at: 1}}, want: map[int]float64{1: 2.5}},
+		{name: "TestNothingToRead", sharing: BandwidthMaxMin, flows: []flow{
+			{id: 1, start: 1},
+		}, want: map[int]float64{1: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := NewNetworkManager(2, true, tt.sharing, 1, 1)
+			for _, f := range tt.flows {
+				n.StartRepair(f.id, f.rackId, f.cross, f.intra, f.weight, f.start)
+			}
+			for _, f := range tt.finish {
+				n.FinishRepair(f.id, f.at)
+			}
+			got := n.RepairCompletionTimes()
+			if len(got) != len(tt.want) {
+				t.Fatalf("RepairCompletionTimes() = %v, want %v", got, tt.want)
+			}
+			for id, want := range tt.want {
+				if math.Abs(got[id]-want) > 1e-9 {
+					t.Errorf("RepairCompletionTimes()[%d] = %v, want %v", id, got[id], want)
+				}
+			}
+		})
+	}
+}
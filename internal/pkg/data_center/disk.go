@@ -28,6 +28,13 @@ func (d *Disk) ResetState() {
 	d.state = DiskStateNormal
 }
 
+// ResetStripes 清空磁盘上的条带信息，重新生成放置策略前调用
+func (d *Disk) ResetStripes() {
+	d.stripeId = nil
+	d.stripeIndex = nil
+	d.chunkNum = 0
+}
+
 func (d *Disk) GetState() DiskState {
 	return d.state
 }
@@ -111,6 +118,7 @@ func (dm *DisksManager) Reset(currentTime float64) {
 	for _, disk := range dm.disks {
 		disk.diskClock.Init(currentTime)
 		disk.ResetState()
+		disk.ResetStripes()
 	}
 	dm.failedDiskNum = 0
 	dm.unavailableDiskNum = 0
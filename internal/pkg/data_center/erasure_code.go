@@ -1,12 +1,50 @@
 package data_center
 
+import (
+	"ECDC_SIM/internal/pkg/enum_error"
+	"fmt"
+	"strings"
+)
+
 type ErasureCodeType int8
 
 const (
 	RS ErasureCodeType = iota
 	LRC
+	REPLICATION
+	LINEAR
+	MSR
+	MBR
+	CLAY
 )
 
+var erasureCodeTypeNames = map[ErasureCodeType]string{
+	RS:          "RS",
+	LRC:         "LRC",
+	REPLICATION: "REPLICATION",
+	LINEAR:      "LINEAR",
+	MSR:         "MSR",
+	MBR:         "MBR",
+	CLAY:        "CLAY",
+}
+
+func (t ErasureCodeType) String() string {
+	if name, ok := erasureCodeTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("ErasureCodeType(%d)", int8(t))
+}
+
+// ParseErasureCodeType 根据名称（不区分大小写）解析纠删码类型
+func ParseErasureCodeType(name string) (ErasureCodeType, error) {
+	for codeType, codeName := range erasureCodeTypeNames {
+		if strings.EqualFold(codeName, name) {
+			return codeType, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown erasure code type %q", name)
+}
+
 type ChunkPlaceType int8
 
 const (
@@ -14,9 +52,128 @@ const (
 	HIERARCHICAL
 )
 
+var chunkPlaceTypeNames = map[ChunkPlaceType]string{
+	FLAT:         "FLAT",
+	HIERARCHICAL: "HIERARCHICAL",
+}
+
+func (t ChunkPlaceType) String() string {
+	if name, ok := chunkPlaceTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("ChunkPlaceType(%d)", int8(t))
+}
+
+// ParseChunkPlaceType 根据名称（不区分大小写）解析块放置方式
+func ParseChunkPlaceType(name string) (ChunkPlaceType, error) {
+	for placeType, placeName := range chunkPlaceTypeNames {
+		if strings.EqualFold(placeName, name) {
+			return placeType, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown chunk place type %q", name)
+}
+
+// ErasureCodeConf 纠删码配置，L、G 只用于 LRC，Generator 只用于 LINEAR，D 只用于再生码。REPLICATION 的 N 为副本数，K 为 1
 type ErasureCodeConf struct {
 	CodeType       ErasureCodeType
 	ChunkPlaceType ChunkPlaceType
 	N              int
 	K              int
+	L              int      // LRC 的局部组数
+	G              int      // LRC 的全局校验块数
+	ChunksPerRack  int      // HIERARCHICAL 放置时一个条带在每个机架上最多放置的块数
+	Generator      [][]byte // LINEAR 的 N×K 生成矩阵（GF(2^8)），第 i 行为块 i 的编码系数
+	D              int      // MSR、MBR、CLAY 修复时的协助块数，0 表示 N-1
+}
+
+// HierarchicalRacksNum HIERARCHICAL 放置时一个条带使用的机架数 ⌈N/ChunksPerRack⌉
+func (c *ErasureCodeConf) HierarchicalRacksNum() int {
+	return (c.N + c.ChunksPerRack - 1) / c.ChunksPerRack
+}
+
+// ErasureCode 纠删码。条带共 N 个块，下标小于 K 的为数据块，各方法中的块下标含义相同
+type ErasureCode interface {
+	fmt.Stringer
+	N() int
+	K() int
+	// IsRecoverable 下标为 failedIdx 的块失效时能否恢复全部数据
+	IsRecoverable(failedIdx []int) bool
+	// RepairPlan 修复下标为 failedIdx 的块需要读取的协助块。alive 为可用块的下标，按读取代价从低到高排列；
+	// helperRatio 为每个协助块传输的数据量与块大小之比。可用块不足以修复时 helpers 为 nil
+	RepairPlan(failedIdx int, alive []int) (helpers []int, helperRatio float64)
+	// FaultTolerance 任意多少个块同时失效仍能恢复数据
+	FaultTolerance() int
+	// StorageOverhead 存储的总数据量与用户数据量之比
+	StorageOverhead() float64
+}
+
+// LocalGroupCode 具有局部组的纠删码可以实现此接口，放置时同一局部组的块按顺序相邻排列
+type LocalGroupCode interface {
+	LocalGroups() [][]int
+}
+
+// RepairIOCode 协助块从磁盘读取的数据量与传输的数据量不同的纠删码可以实现此接口，未实现时两者相同
+type RepairIOCode interface {
+	// HelperReadRatio 协助块按 RepairPlan 返回的 helperRatio 传输数据时，从磁盘读取的数据量与块大小之比
+	HelperReadRatio(helperRatio float64) float64
+}
+
+// HelperReadRatio 纠删码 code 的协助块传输 helperRatio 的数据时从磁盘读取的数据量与块大小之比
+func HelperReadRatio(code ErasureCode, helperRatio float64) float64 {
+	if ioCode, ok := code.(RepairIOCode); ok {
+		return ioCode.HelperReadRatio(helperRatio)
+	}
+	return helperRatio
+}
+
+// ErasureCodeFactory 根据配置创建纠删码，配置不满足该纠删码的要求时返回全部错误
+type ErasureCodeFactory func(conf *ErasureCodeConf) (ErasureCode, enum_error.ConfigErrors)
+
+var erasureCodeFactories = map[ErasureCodeType]ErasureCodeFactory{
+	RS:          newReedSolomon,
+	LRC:         newLRC,
+	REPLICATION: newReplication,
+	LINEAR:      newLinearCode,
+	MSR:         newRegenerating,
+	MBR:         newRegenerating,
+	CLAY:        newRegenerating,
+}
+
+// RegisterErasureCode 注册一种纠删码并返回其类型，name 可用于配置文件与命令行选项（不区分大小写）。
+// 注册不加锁，应在 init 中完成；名称已被使用时 panic
+func RegisterErasureCode(name string, factory ErasureCodeFactory) ErasureCodeType {
+	if _, err := ParseErasureCodeType(name); err == nil {
+		panic(fmt.Sprintf("erasure code %q already registered", name))
+	}
+	codeType := ErasureCodeType(len(erasureCodeTypeNames))
+	erasureCodeTypeNames[codeType] = name
+	erasureCodeFactories[codeType] = factory
+	return codeType
+}
+
+// NewErasureCode 根据 conf.CodeType 创建纠删码
+func NewErasureCode(conf *ErasureCodeConf) (ErasureCode, enum_error.ConfigErrors) {
+	factory, ok := erasureCodeFactories[conf.CodeType]
+	if !ok {
+		return nil, enum_error.ConfigErrors{enum_error.NewConfigError(enum_error.ParamsOutOfRangeError, "ErasureCodeConf.CodeType", "unknown code type %d", conf.CodeType)}
+	}
+	return factory(conf)
+}
+
+// firstHelpers 从 alive 中取前 num 个块作为协助块，不足时返回 nil
+func firstHelpers(failedIdx int, alive []int, num int) []int {
+	helpers := make([]int, 0, num)
+	for _, idx := range alive {
+		if len(helpers) == num {
+			break
+		}
+		if idx != failedIdx {
+			helpers = append(helpers, idx)
+		}
+	}
+	if len(helpers) < num {
+		return nil
+	}
+	return helpers
 }
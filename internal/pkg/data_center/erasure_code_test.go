@@ -0,0 +1,226 @@
+package data_center
+
+import (
+	"ECDC_SIM/internal/pkg/enum_error"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func mustNewErasureCode(t *testing.T, conf *ErasureCodeConf) ErasureCode {
+	t.Helper()
+	code, errs := NewErasureCode(conf)
+	if len(errs) > 0 {
+		t.Fatalf("NewErasureCode(%+v) error = %v", conf, errs)
+	}
+	return code
+}
+
+func TestErasureCode_IsRecoverable(t *testing.T) {
+	rs := &ErasureCodeConf{CodeType: RS, N: 9, K: 6}
+	// LRC(k=6,l=2,g=2)：数据块 0-2 与局部校验块 6 为第 0 组，数据块 3-5 与局部校验块 7 为第 1 组，8、9 为全局校验块
+	lrc := &ErasureCodeConf{CodeType: LRC, N: 10, K: 6, L: 2, G: 2}
+	rep := &ErasureCodeConf{CodeType: REPLICATION, N: 3, K: 1}
+	tests := []struct {
+		name          string
+		conf          *ErasureCodeConf
+		failedIdxList []int
+		want          bool
+	}{
+		{name: "TestRSWithinParity", conf: rs, failedIdxList: []int{0, 4, 8}, want: true},
+		{name: "TestRSBeyondParity", conf: rs, failedIdxList: []int{0, 1, 2, 3}, want: false},
+		{name: "TestLRCOnePerGroup", conf: lrc, failedIdxList: []int{0, 3}, want: true},
+		{name: "TestLRCThreeInGroup", conf: lrc, failedIdxList: []int{0, 1, 2}, want: true},
+		{name: "TestLRCFourAcrossGroups", conf: lrc, failedIdxList: []int{0, 1, 3, 4}, want: true},
+		{name: "TestLRCFourInGroup", conf: lrc, failedIdxList: []int{0, 1, 2, 6}, want: false},
+		{name: "TestLRCGlobalParityLost", conf: lrc, failedIdxList: []int{0, 1, 2, 8}, want: false},
+		{name: "TestLRCParitiesOnly", conf: lrc, failedIdxList: []int{6, 7, 8, 9}, want: true},
+		{name: "TestLRCLocalParityLost", conf: lrc, failedIdxList: []int{0, 6, 3, 7}, want: true},
+		{name: "TestLRCFiveFailures", conf: lrc, failedIdxList: []int{0, 1, 3, 4, 8}, want: false},
+		{name: "TestReplicationOneLeft", conf: rep, failedIdxList: []int{0, 2}, want: true},
+		{name: "TestReplicationAllLost", conf: rep, failedIdxList: []int{0, 1, 2}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := mustNewErasureCode(t, tt.conf)
+			if got := code.IsRecoverable(tt.failedIdxList); got != tt.want {
+				t.Errorf("%s.IsRecoverable(%v) = %t, want %t", code, tt.failedIdxList, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErasureCode_RepairPlan(t *testing.T) {
+	rs := &ErasureCodeConf{CodeType: RS, N: 9, K: 6}
+	lrc := &ErasureCodeConf{CodeType: LRC, N: 10, K: 6, L: 2, G: 2}
+	rep := &ErasureCodeConf{CodeType: REPLICATION, N: 3, K: 1}
+	msr := &ErasureCodeConf{CodeType: MSR, N: 9, K: 6, D: 7}
+	mbr := &ErasureCodeConf{CodeType: MBR, N: 9, K: 6}
+	tests := []struct {
+		name        string
+		conf        *ErasureCodeConf
+		idx         int
+		alive       []int
+		wantHelpers []int
+		wantRatio   float64
+	}{
+		{name: "TestRSFirstK", conf: rs, idx: 0, alive: []int{8, 7, 1, 2, 3, 4, 5}, wantHelpers: []int{8, 7, 1, 2, 3, 4}},
+		{name: "TestRSTooFewAlive", conf: rs, idx: 0, alive: []int{1, 2, 3, 4, 5}, wantHelpers: nil},
+		{name: "TestLRCDataChunk", conf: lrc, idx: 1, alive: []int{9, 8, 7, 6, 5, 3, 2, 0}, wantHelpers: []int{0, 2, 6}},
+		{name: "TestLRCLocalParity", conf: lrc, idx: 7, alive: []int{1, 2, 3, 4, 5, 6, 8, 9}, wantHelpers: []int{3, 4, 5}},
+		{name: "TestLRCGroupDegraded", conf: lrc, idx: 1, alive: []int{0, 2, 3, 4, 5, 7, 8, 9}, wantHelpers: []int{0, 2, 3, 4, 5, 7}},
+		{name: "TestLRCGlobalParity", conf: lrc, idx: 8, alive: []int{0, 1, 2, 3, 4, 5, 6, 7, 9}, wantHelpers: []int{0, 1, 2, 3, 4, 5}},
+		{name: "TestReplicationNearest", conf: rep, idx: 0, alive: []int{2, 1}, wantHelpers: []int{2}},
+		{name: "TestReplicationNoneAlive", conf: rep, idx: 0, alive: nil, wantHelpers: nil},
+		// MSR 的每个协助块传输 1/(d-K+1) 个块，MBR 传输 1/d 个块
+		{name: "TestMSRHelpers", conf: msr, idx: 0, alive: []int{8, 1, 2, 3, 4, 5, 6, 7}, wantHelpers: []int{8, 1, 2, 3, 4, 5, 6}, wantRatio: 0.5},
+		{name: "TestMSRFewerThanD", conf: msr, idx: 0, alive: []int{8, 1, 2, 3, 4, 5}, wantHelpers: []int{8, 1, 2, 3, 4, 5}, wantRatio: 1},
+		{name: "TestMBRHelpers", conf: mbr, idx: 2, alive: []int{0, 1, 3, 4, 5, 6, 7, 8}, wantHelpers: []int{0, 1, 3, 4, 5, 6, 7, 8}, wantRatio: 0.125},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := mustNewErasureCode(t, tt.conf)
+			helpers, helperRatio := code.RepairPlan(tt.idx, tt.alive)
+			wantRatio := tt.wantRatio
+			if wantRatio == 0 {
+				wantRatio = 1
+			}
+			if !reflect.DeepEqual(helpers, tt.wantHelpers) || (helpers != nil && helperRatio != wantRatio) {
+				t.Errorf("%s.RepairPlan(%d, %v) = %v, %g, want %v, %g", code, tt.idx, tt.alive, helpers, helperRatio, tt.wantHelpers, wantRatio)
+			}
+		})
+	}
+	code := mustNewErasureCode(t, lrc)
+	if got := chunkPlaceOrder(code); len(got) != code.N() || got[3] != 6 || got[7] != 7 {
+		t.Errorf("chunkPlaceOrder() = %v, want local groups followed by global parities", got)
+	}
+}
+
+func TestErasureCode_Properties(t *testing.T) {
+	tests := []struct {
+		name             string
+		conf             *ErasureCodeConf
+		wantString       string
+		wantTolerance    int
+		wantOverhead     float64
+		wantErrorsFields []string
+	}{
+		{name: "TestRS", conf: &ErasureCodeConf{CodeType: RS, N: 9, K: 6}, wantString: "RS(9,6)", wantTolerance: 3, wantOverhead: 1.5},
+		{name: "TestLRC", conf: &ErasureCodeConf{CodeType: LRC, N: 16, K: 12, L: 2, G: 2}, wantString: "LRC(k=12,l=2,g=2)", wantTolerance: 3, wantOverhead: 16.0 / 12},
+		{name: "TestReplication", conf: &ErasureCodeConf{CodeType: REPLICATION, N: 3, K: 1}, wantString: "REPLICATION(3)", wantTolerance: 2, wantOverhead: 3},
+		{name: "TestMSR", conf: &ErasureCodeConf{CodeType: MSR, N: 9, K: 6}, wantString: "MSR(9,6,d=8)", wantTolerance: 3, wantOverhead: 1.5},
+		{name: "TestClay", conf: &ErasureCodeConf{CodeType: CLAY, N: 14, K: 10, D: 12}, wantString: "CLAY(14,10,d=12)", wantTolerance: 4, wantOverhead: 1.4},
+		// MBR 的存储开销为 2Nd/(K(2d-K+1))
+		{name: "TestMBR", conf: &ErasureCodeConf{CodeType: MBR, N: 4, K: 2, D: 3}, wantString: "MBR(4,2,d=3)", wantTolerance: 2, wantOverhead: 2.4},
+		{name: "TestMSRHelpersBelowK", conf: &ErasureCodeConf{CodeType: MSR, N: 9, K: 6, D: 5}, wantErrorsFields: []string{"ErasureCodeConf.D"}},
+		{name: "TestReplicationWithK", conf: &ErasureCodeConf{CodeType: REPLICATION, N: 3, K: 2}, wantErrorsFields: []string{"ErasureCodeConf.K"}},
+		{name: "TestLRCInconsistentN", conf: &ErasureCodeConf{CodeType: LRC, N: 9, K: 6, L: 4}, wantErrorsFields: []string{"ErasureCodeConf.L", "ErasureCodeConf.N"}},
+		{name: "TestUnknownType", conf: &ErasureCodeConf{CodeType: ErasureCodeType(100), N: 9, K: 6}, wantErrorsFields: []string{"ErasureCodeConf.CodeType"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, errs := NewErasureCode(tt.conf)
+			var fields []string
+			for _, err := range errs {
+				fields = append(fields, err.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.wantErrorsFields) {
+				t.Fatalf("NewErasureCode(%+v) error fields = %v, want %v", tt.conf, fields, tt.wantErrorsFields)
+			}
+			if len(errs) > 0 {
+				return
+			}
+			if code.String() != tt.wantString || code.FaultTolerance() != tt.wantTolerance || code.StorageOverhead() != tt.wantOverhead {
+				t.Errorf("NewErasureCode(%+v) = %s tolerating %d with overhead %g, want %s, %d, %g", tt.conf,
+					code, code.FaultTolerance(), code.StorageOverhead(), tt.wantString, tt.wantTolerance, tt.wantOverhead)
+			}
+		})
+	}
+}
+
+// mirror 测试用的镜像码：N 个块互为副本，读取任意一个可用块即可修复
+type mirror struct {
+	n int
+}
+
+func (c *mirror) String() string                     { return fmt.Sprintf("MIRROR(%d)", c.n) }
+func (c *mirror) N() int                             { return c.n }
+func (c *mirror) K() int                             { return 1 }
+func (c *mirror) IsRecoverable(failedIdx []int) bool { return len(failedIdx) < c.n }
+func (c *mirror) FaultTolerance() int                { return c.n - 1 }
+func (c *mirror) StorageOverhead() float64           { return float64(c.n) }
+func (c *mirror) RepairPlan(idx int, alive []int) ([]int, float64) {
+	return firstHelpers(idx, alive, 1), 1
+}
+
+func TestRegisterErasureCode(t *testing.T) {
+	codeType := RegisterErasureCode("test-mirror", func(conf *ErasureCodeConf) (ErasureCode, enum_error.ConfigErrors) {
+		return &mirror{n: conf.N}, nil
+	})
+	if parsed, err := ParseErasureCodeType("TEST-MIRROR"); err != nil || parsed != codeType {
+		t.Fatalf("ParseErasureCodeType() = %v, %v, want %v", parsed, err, codeType)
+	}
+	conf := &ErasureCodeConf{CodeType: codeType, ChunkPlaceType: FLAT, N: 3, K: 1}
+	if errs := conf.Validate(); len(errs) > 0 {
+		t.Fatalf("Validate() = %v, want no errors", errs)
+	}
+	code := mustNewErasureCode(t, conf)
+	if code.String() != "MIRROR(3)" || minFlatRacksNum(code) != 3 {
+		t.Errorf("NewErasureCode() = %s needing %d racks, want MIRROR(3) needing 3", code, minFlatRacksNum(code))
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("RegisterErasureCode() with a registered name did not panic")
+		}
+	}()
+	RegisterErasureCode("rs", newReedSolomon)
+}
+
+func TestHelperReadRatio(t *testing.T) {
+	tests := []struct {
+		name string
+		conf *ErasureCodeConf
+		want float64
+	}{
+		// 协助块传输 1/(d-K+1) 个块，MSR 与 MBR 从磁盘读取整个块，CLAY 只读取要传输的数据
+		{name: "TestRS", conf: &ErasureCodeConf{CodeType: RS, N: 9, K: 6}, want: 1},
+		{name: "TestMSR", conf: &ErasureCodeConf{CodeType: MSR, N: 9, K: 6, D: 7}, want: 1},
+		{name: "TestMBR", conf: &ErasureCodeConf{CodeType: MBR, N: 9, K: 6, D: 7}, want: 1},
+		{name: "TestClay", conf: &ErasureCodeConf{CodeType: CLAY, N: 9, K: 6, D: 7}, want: 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := mustNewErasureCode(t, tt.conf)
+			_, helperRatio := code.RepairPlan(0, []int{1, 2, 3, 4, 5, 6, 7, 8})
+			if got := HelperReadRatio(code, helperRatio); got != tt.want {
+				t.Errorf("HelperReadRatio(%s, %g) = %g, want %g", code, helperRatio, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRackFaultTolerance(t *testing.T) {
+	tests := []struct {
+		name     string
+		conf     *ErasureCodeConf
+		racksNum int
+		want     int
+	}{
+		{name: "TestRSFlat", conf: &ErasureCodeConf{CodeType: RS, ChunkPlaceType: FLAT, N: 9, K: 6}, racksNum: 12, want: 3},
+		{name: "TestRSHierarchical", conf: &ErasureCodeConf{CodeType: RS, ChunkPlaceType: HIERARCHICAL, N: 9, K: 6, ChunksPerRack: 3}, racksNum: 3, want: 1},
+		{name: "TestRSHierarchicalUneven", conf: &ErasureCodeConf{CodeType: RS, ChunkPlaceType: HIERARCHICAL, N: 14, K: 10, ChunksPerRack: 4}, racksNum: 8, want: 1},
+		{name: "TestLRCFlat", conf: &ErasureCodeConf{CodeType: LRC, ChunkPlaceType: FLAT, N: 16, K: 12, L: 2, G: 2}, racksNum: 16, want: 3},
+		// 机架少于 N 时一个机架放置两个局部组各一个块，两个机架故障后每组各用局部校验恢复一个块，其余两个由全局校验恢复
+		{name: "TestLRCFlatFewerRacks", conf: &ErasureCodeConf{CodeType: LRC, ChunkPlaceType: FLAT, N: 16, K: 12, L: 2, G: 2}, racksNum: 8, want: 2},
+		{name: "TestReplicationFlat", conf: &ErasureCodeConf{CodeType: REPLICATION, ChunkPlaceType: FLAT, N: 3, K: 1}, racksNum: 8, want: 2},
+		{name: "TestLRCHierarchicalGroupInRack", conf: &ErasureCodeConf{CodeType: LRC, ChunkPlaceType: HIERARCHICAL, N: 16, K: 12, L: 2, G: 2, ChunksPerRack: 4}, racksNum: 4, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := mustNewErasureCode(t, tt.conf)
+			if got := rackFaultTolerance(code, rackSlots(code, tt.conf, tt.racksNum)); got != tt.want {
+				t.Errorf("rackFaultTolerance(%d racks) = %d, want %d", tt.racksNum, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package data_center
+
+import (
+	"ECDC_SIM/internal/pkg/enum_error"
+	"ECDC_SIM/internal/pkg/gf256"
+	"fmt"
+)
+
+// linearCode 由 GF(2^8) 上 N×K 生成矩阵给出的线性码，第 i 行为块 i 由数据计算的系数。失效块以外的行的秩为 K 时可以恢复，
+// 适用于 LRC、piggyback 等非 MDS 码。可恢复性与修复方案按失效模式缓存，同一实例不能并发使用
+type linearCode struct {
+	generator      gf256.Matrix
+	faultTolerance int // 小于 0 表示尚未计算
+	recoverable    map[string]bool
+	repairPlans    map[repairPlanKey][]int
+}
+
+// maxLinearN 修复方案的缓存键以 uint64 位掩码表示块下标集合，块数不能超过 64
+const maxLinearN = 64
+
+// maxRepairPlans 缓存的修复方案数达到该值时清空缓存，避免长时间运行时无限增长
+const maxRepairPlans = 1 << 14
+
+// repairPlanKey 修复方案的缓存键。调用方按同一机架的块在前、两部分各自下标递增的顺序传入可用块，
+// 由全部可用块与其中第一段递增前缀的位掩码即可确定顺序
+type repairPlanKey struct {
+	failedIdx int
+	alive     uint64
+	prefix    uint64
+}
+
+func newLinearCode(conf *ErasureCodeConf) (ErasureCode, enum_error.ConfigErrors) {
+	var errs enum_error.ConfigErrors
+	if len(conf.Generator) != conf.N {
+		errs = append(errs, enum_error.NewConfigError(enum_error.ParamsInconsistentError, "ErasureCodeConf.Generator", "must have N=%d rows, got %d", conf.N, len(conf.Generator)))
+	}
+	for i, row := range conf.Generator {
+		if len(row) != conf.K {
+			errs = append(errs, enum_error.NewConfigError(enum_error.ParamsInconsistentError, "ErasureCodeConf.Generator", "row %d must have K=%d columns, got %d", i, conf.K, len(row)))
+			break
+		}
+	}
+	if conf.N > maxLinearN {
+		errs = append(errs, enum_error.NewConfigError(enum_error.ParamsOutOfRangeError, "ErasureCodeConf.N", "must be at most %d for LINEAR, got %d", maxLinearN, conf.N))
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	generator := gf256.Matrix(conf.Generator)
+	if rank := generator.Rank(); rank != conf.K {
+		return nil, enum_error.ConfigErrors{enum_error.NewConfigError(enum_error.ParamsInconsistentError, "ErasureCodeConf.Generator", "must have rank K=%d, got %d", conf.K, rank)}
+	}
+	return &linearCode{
+		generator:      generator.Clone(),
+		faultTolerance: -1,
+		recoverable:    make(map[string]bool),
+		repairPlans:    make(map[repairPlanKey][]int),
+	}, nil
+}
+
+func (c *linearCode) String() string {
+	return fmt.Sprintf("LINEAR(%d,%d)", c.N(), c.K())
+}
+
+func (c *linearCode) N() int {
+	return c.generator.Rows()
+}
+
+func (c *linearCode) K() int {
+	return c.generator.Cols()
+}
+
+// patternKey 块下标集合的缓存键，与下标顺序无关
+func (c *linearCode) patternKey(idxList []int) string {
+	key := make([]byte, (c.N()+7)/8)
+	for _, idx := range idxList {
+		key[idx/8] |= 1 << (idx % 8)
+	}
+	return string(key)
+}
+
+func (c *linearCode) IsRecoverable(failedIdx []int) bool {
+	if len(failedIdx) > c.N()-c.K() {
+		return false
+	}
+	key := c.patternKey(failedIdx)
+	if recoverable, ok := c.recoverable[key]; ok {
+		return recoverable
+	}
+	failed := make(map[int]struct{}, len(failedIdx))
+	for _, idx := range failedIdx {
+		failed[idx] = struct{}{}
+	}
+	alive := make([]int, 0, c.N())
+	for idx := 0; idx < c.N(); idx++ {
+		if _, ok := failed[idx]; !ok {
+			alive = append(alive, idx)
+		}
+	}
+	recoverable := c.generator.SubRows(alive).Rank() == c.K()
+	c.recoverable[key] = recoverable
+	return recoverable
+}
+
+// repairPlanKey alive 由至多两段下标递增的序列组成时返回其缓存键，否则返回 false 表示不使用缓存
+func (c *linearCode) repairPlanKey(failedIdx int, alive []int) (repairPlanKey, bool) {
+	key := repairPlanKey{failedIdx: failedIdx}
+	runs := 1
+	for i, idx := range alive {
+		if i > 0 && idx < alive[i-1] {
+			if runs++; runs > 2 {
+				return key, false
+			}
+		}
+		key.alive |= 1 << idx
+		if runs == 1 {
+			key.prefix |= 1 << idx
+		}
+	}
+	return key, true
+}
+
+// RepairPlan 按 alive 的顺序依次加入能提高秩的块，直到失效块的行可由已选的块线性表示
+func (c *linearCode) RepairPlan(failedIdx int, alive []int) ([]int, float64) {
+	key, cacheable := c.repairPlanKey(failedIdx, alive)
+	if helpers, ok := c.repairPlans[key]; ok && cacheable {
+		return helpers, 1
+	}
+	helpers := c.findRepairPlan(failedIdx, alive)
+	if cacheable {
+		if len(c.repairPlans) >= maxRepairPlans {
+			c.repairPlans = make(map[repairPlanKey][]int)
+		}
+		c.repairPlans[key] = helpers
+	}
+	return helpers, 1
+}
+
+func (c *linearCode) findRepairPlan(failedIdx int, alive []int) []int {
+	var helpers []int
+	rank := 0
+	for _, idx := range alive {
+		if idx == failedIdx {
+			continue
+		}
+		rows := append(append(make([]int, 0, len(helpers)+2), helpers...), idx)
+		newRank := c.generator.SubRows(rows).Rank()
+		if newRank == rank {
+			continue
+		}
+		helpers, rank = rows, newRank
+		if c.generator.SubRows(append(rows, failedIdx)).Rank() == rank {
+			return helpers
+		}
+	}
+	return nil
+}
+
+// FaultTolerance 枚举失效模式得到任意多少个块同时失效仍能恢复，首次调用时计算
+func (c *linearCode) FaultTolerance() int {
+	if c.faultTolerance >= 0 {
+		return c.faultTolerance
+	}
+	c.faultTolerance = c.N() - c.K()
+	for failedNum := 1; failedNum <= c.N()-c.K(); failedNum++ {
+		if !c.allRecoverable(failedNum, 0, nil) {
+			c.faultTolerance = failedNum - 1
+			break
+		}
+	}
+	return c.faultTolerance
+}
+
+// allRecoverable 从下标 start 起再选择 failedNum 个块与 failedIdx 一起失效的所有组合是否都能恢复
+func (c *linearCode) allRecoverable(failedNum, start int, failedIdx []int) bool {
+	if failedNum == 0 {
+		return c.IsRecoverable(failedIdx)
+	}
+	for idx := start; idx <= c.N()-failedNum; idx++ {
+		failed := append(append(make([]int, 0, len(failedIdx)+1), failedIdx...), idx)
+		if !c.allRecoverable(failedNum-1, idx+1, failed) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *linearCode) StorageOverhead() float64 {
+	return float64(c.N()) / float64(c.K())
+}
@@ -0,0 +1,146 @@
+package data_center
+
+import (
+	"ECDC_SIM/internal/pkg/gf256"
+	"reflect"
+	"testing"
+)
+
+// azureLRCGenerator LRC(k=6,l=2,g=2) 的生成矩阵：局部校验为组内数据块的异或，全局校验取 Cauchy 矩阵
+func azureLRCGenerator() [][]byte {
+	generator := append(gf256.Identity(6), gf256.Matrix{{1, 1, 1, 0, 0, 0}, {0, 0, 0, 1, 1, 1}}...)
+	return append(generator, gf256.Cauchy(2, 6)...)
+}
+
+func TestLinearCode_IsRecoverable(t *testing.T) {
+	rs := &ErasureCodeConf{CodeType: LINEAR, N: 9, K: 6, Generator: gf256.SystematicCauchy(9, 6)}
+	lrc := &ErasureCodeConf{CodeType: LINEAR, N: 10, K: 6, Generator: azureLRCGenerator()}
+	tests := []struct {
+		name          string
+		conf          *ErasureCodeConf
+		failedIdxList []int
+		want          bool
+	}{
+		{name: "TestMDSWithinParity", conf: rs, failedIdxList: []int{8, 0, 4}, want: true},
+		{name: "TestMDSBeyondParity", conf: rs, failedIdxList: []int{0, 1, 2, 3}, want: false},
+		{name: "TestLRCOnePerGroup", conf: lrc, failedIdxList: []int{0, 3}, want: true},
+		{name: "TestLRCFourAcrossGroups", conf: lrc, failedIdxList: []int{0, 1, 3, 4}, want: true},
+		// 与 N-K 相同的失效数，但一个局部组失效的块多于局部校验与全局校验能恢复的数量
+		{name: "TestLRCFourInGroup", conf: lrc, failedIdxList: []int{0, 1, 2, 6}, want: false},
+		// Cauchy 全局校验不是最大可恢复的：该模式满足 MR 条件但对应的 2×2 子式为零
+		{name: "TestLRCNotMaximallyRecoverable", conf: lrc, failedIdxList: []int{0, 1, 4, 5}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := mustNewErasureCode(t, tt.conf)
+			if got := code.IsRecoverable(tt.failedIdxList); got != tt.want {
+				t.Errorf("%s.IsRecoverable(%v) = %t, want %t", code, tt.failedIdxList, got, tt.want)
+			}
+			// 结果按失效模式缓存，与下标顺序无关；失效块多于 N-K 时直接判断，不缓存
+			reversed := make([]int, 0, len(tt.failedIdxList))
+			for i := len(tt.failedIdxList) - 1; i >= 0; i-- {
+				reversed = append(reversed, tt.failedIdxList[i])
+			}
+			if got := code.IsRecoverable(reversed); got != tt.want || len(code.(*linearCode).recoverable) > 1 {
+				t.Errorf("%s.IsRecoverable(%v) = %t with %d cached patterns, want %t from at most one cached pattern",
+					code, reversed, got, len(code.(*linearCode).recoverable), tt.want)
+			}
+		})
+	}
+}
+
+func TestLinearCode_MatchesRS(t *testing.T) {
+	linear := mustNewErasureCode(t, &ErasureCodeConf{CodeType: LINEAR, N: 9, K: 6, Generator: gf256.SystematicCauchy(9, 6)})
+	rs := mustNewErasureCode(t, &ErasureCodeConf{CodeType: RS, N: 9, K: 6})
+	for mask := 0; mask < 1<<9; mask++ {
+		var failedIdxList []int
+		for idx := 0; idx < 9; idx++ {
+			if mask&(1<<idx) != 0 {
+				failedIdxList = append(failedIdxList, idx)
+			}
+		}
+		if linear.IsRecoverable(failedIdxList) != rs.IsRecoverable(failedIdxList) {
+			t.Fatalf("IsRecoverable(%v) = %t, RS gives %t", failedIdxList, linear.IsRecoverable(failedIdxList), rs.IsRecoverable(failedIdxList))
+		}
+	}
+	if linear.FaultTolerance() != 3 || linear.String() != "LINEAR(9,6)" || linear.StorageOverhead() != 1.5 {
+		t.Errorf("%s tolerates %d with overhead %g, want LINEAR(9,6), 3, 1.5", linear, linear.FaultTolerance(), linear.StorageOverhead())
+	}
+}
+
+func TestLinearCode_RepairPlan(t *testing.T) {
+	rs := &ErasureCodeConf{CodeType: LINEAR, N: 9, K: 6, Generator: gf256.SystematicCauchy(9, 6)}
+	lrc := &ErasureCodeConf{CodeType: LINEAR, N: 10, K: 6, Generator: azureLRCGenerator()}
+	tests := []struct {
+		name        string
+		conf        *ErasureCodeConf
+		idx         int
+		alive       []int
+		wantHelpers []int
+	}{
+		{name: "TestMDSFirstK", conf: rs, idx: 0, alive: []int{8, 7, 1, 2, 3, 4, 5}, wantHelpers: []int{8, 7, 1, 2, 3, 4}},
+		{name: "TestMDSTooFewAlive", conf: rs, idx: 0, alive: []int{1, 2, 3, 4, 5}, wantHelpers: nil},
+		{name: "TestLRCLocalGroup", conf: lrc, idx: 1, alive: []int{0, 2, 6, 3, 4, 5, 7, 8, 9}, wantHelpers: []int{0, 2, 6}},
+		// 冗余的块不计入协助块
+		{name: "TestLRCSkipDependent", conf: lrc, idx: 6, alive: []int{3, 4, 5, 7, 0, 1, 2}, wantHelpers: []int{3, 4, 5, 0, 1, 2}},
+		{name: "TestLRCGroupDegraded", conf: lrc, idx: 1, alive: []int{0, 2, 3, 4, 5, 7, 8, 9}, wantHelpers: []int{0, 2, 3, 4, 5, 8}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := mustNewErasureCode(t, tt.conf)
+			for i := 0; i < 2; i++ {
+				helpers, helperRatio := code.RepairPlan(tt.idx, tt.alive)
+				if !reflect.DeepEqual(helpers, tt.wantHelpers) || helperRatio != 1 {
+					t.Errorf("%s.RepairPlan(%d, %v) = %v, %g, want %v, 1", code, tt.idx, tt.alive, helpers, helperRatio, tt.wantHelpers)
+				}
+			}
+		})
+	}
+}
+
+func TestLinearCode_RepairPlanCache(t *testing.T) {
+	code := mustNewErasureCode(t, &ErasureCodeConf{CodeType: LINEAR, N: 9, K: 6, Generator: gf256.SystematicCauchy(9, 6)})
+	// 同一组可用块以不同顺序传入时按各自的顺序选择协助块，不能互相命中缓存
+	tests := []struct {
+		alive       []int
+		wantHelpers []int
+	}{
+		{alive: []int{1, 2, 3, 4, 5, 7, 8}, wantHelpers: []int{1, 2, 3, 4, 5, 7}},
+		{alive: []int{7, 8, 1, 2, 3, 4, 5}, wantHelpers: []int{7, 8, 1, 2, 3, 4}},
+		{alive: []int{8, 7, 1, 2, 3, 4, 5}, wantHelpers: []int{8, 7, 1, 2, 3, 4}},
+		{alive: []int{1, 2, 3, 4, 5, 7, 8}, wantHelpers: []int{1, 2, 3, 4, 5, 7}},
+	}
+	for _, tt := range tests {
+		if helpers, _ := code.RepairPlan(0, tt.alive); !reflect.DeepEqual(helpers, tt.wantHelpers) {
+			t.Errorf("RepairPlan(0, %v) = %v, want %v", tt.alive, helpers, tt.wantHelpers)
+		}
+	}
+	if plans := len(code.(*linearCode).repairPlans); plans != 2 {
+		t.Errorf("cached %d repair plans, want 2", plans)
+	}
+}
+
+func TestNewLinearCode(t *testing.T) {
+	tests := []struct {
+		name       string
+		conf       *ErasureCodeConf
+		wantFields []string
+	}{
+		{name: "TestRowsNotN", conf: &ErasureCodeConf{CodeType: LINEAR, N: 9, K: 6, Generator: gf256.SystematicCauchy(8, 6)}, wantFields: []string{"ErasureCodeConf.Generator"}},
+		{name: "TestColsNotK", conf: &ErasureCodeConf{CodeType: LINEAR, N: 9, K: 5, Generator: gf256.SystematicCauchy(9, 6)}, wantFields: []string{"ErasureCodeConf.Generator"}},
+		{name: "TestRankDeficient", conf: &ErasureCodeConf{CodeType: LINEAR, N: 3, K: 2, Generator: [][]byte{{1, 1}, {2, 2}, {3, 3}}}, wantFields: []string{"ErasureCodeConf.Generator"}},
+		{name: "TestTooManyChunks", conf: &ErasureCodeConf{CodeType: LINEAR, N: 65, K: 60, Generator: gf256.SystematicCauchy(65, 60)}, wantFields: []string{"ErasureCodeConf.N"}},
+		{name: "TestMissingGenerator", conf: &ErasureCodeConf{CodeType: LINEAR, ChunkPlaceType: FLAT, N: 9, K: 6}, wantFields: []string{"ErasureCodeConf.Generator"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fields []string
+			for _, err := range tt.conf.Validate() {
+				fields = append(fields, err.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("Validate() error fields = %v, want %v", fields, tt.wantFields)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package data_center
+
+import (
+	"ECDC_SIM/internal/pkg/enum_error"
+	"fmt"
+)
+
+// lrc Azure 式局部可修复码。K 个数据块按下标连续均分到 L 个局部组，下标 K+j 为第 j 组的局部校验块，
+// 其后的 G 个为全局校验块，N = K + L + G。可恢复性按最大可恢复（maximally recoverable）码判断
+type lrc struct {
+	k, l, g int
+}
+
+func newLRC(conf *ErasureCodeConf) (ErasureCode, enum_error.ConfigErrors) {
+	var errs enum_error.ConfigErrors
+	if conf.L <= 0 {
+		errs = append(errs, enum_error.NewConfigError(enum_error.ParamsOutOfRangeError, "ErasureCodeConf.L", "must be positive for LRC, got %d", conf.L))
+	} else if conf.K%conf.L != 0 {
+		errs = append(errs, enum_error.NewConfigError(enum_error.ParamsInconsistentError, "ErasureCodeConf.L", "must divide K=%d, got %d", conf.K, conf.L))
+	}
+	if conf.G < 0 {
+		errs = append(errs, enum_error.NewConfigError(enum_error.ParamsOutOfRangeError, "ErasureCodeConf.G", "must not be negative, got %d", conf.G))
+	}
+	if conf.N != conf.K+conf.L+conf.G {
+		errs = append(errs, enum_error.NewConfigError(enum_error.ParamsInconsistentError, "ErasureCodeConf.N", "must equal K+L+G=%d for LRC, got %d", conf.K+conf.L+conf.G, conf.N))
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return &lrc{k: conf.K, l: conf.L, g: conf.G}, nil
+}
+
+func (c *lrc) String() string {
+	return fmt.Sprintf("LRC(k=%d,l=%d,g=%d)", c.k, c.l, c.g)
+}
+
+func (c *lrc) N() int {
+	return c.k + c.l + c.g
+}
+
+func (c *lrc) K() int {
+	return c.k
+}
+
+// localGroup 下标为 idx 的块所属的局部组，全局校验块返回 -1
+func (c *lrc) localGroup(idx int) int {
+	switch {
+	case idx < 0:
+		return -1
+	case idx < c.k:
+		return idx / (c.k / c.l)
+	case idx < c.k+c.l:
+		return idx - c.k
+	}
+	return -1
+}
+
+// localGroupMembers 局部组 group 的数据块与局部校验块下标
+func (c *lrc) localGroupMembers(group int) []int {
+	size := c.k / c.l
+	members := make([]int, 0, size+1)
+	for idx := group * size; idx < (group+1)*size; idx++ {
+		members = append(members, idx)
+	}
+	return append(members, c.k+group)
+}
+
+func (c *lrc) LocalGroups() [][]int {
+	groups := make([][]int, 0, c.l)
+	for group := 0; group < c.l; group++ {
+		groups = append(groups, c.localGroupMembers(group))
+	}
+	return groups
+}
+
+// IsRecoverable 每个局部组若局部校验块可用，先用它恢复一个失效数据块，剩余的失效数据块数不超过可用的全局校验块数时可以恢复
+func (c *lrc) IsRecoverable(failedIdx []int) bool {
+	failedData := make([]int, c.l)
+	localParityFailed := make([]bool, c.l)
+	aliveGlobal := c.g
+	for _, idx := range failedIdx {
+		switch {
+		case idx < c.k:
+			failedData[c.localGroup(idx)]++
+		case idx < c.k+c.l:
+			localParityFailed[idx-c.k] = true
+		default:
+			aliveGlobal--
+		}
+	}
+	remaining := 0
+	for group, failedNum := range failedData {
+		if failedNum > 0 && !localParityFailed[group] {
+			failedNum--
+		}
+		remaining += failedNum
+	}
+	return remaining <= aliveGlobal
+}
+
+// RepairPlan 局部组的其余块均可用时只读取局部组，否则与 RS 相同读取 K 个可用块
+func (c *lrc) RepairPlan(failedIdx int, alive []int) ([]int, float64) {
+	if group := c.localGroup(failedIdx); group >= 0 {
+		aliveSet := make(map[int]struct{}, len(alive))
+		for _, idx := range alive {
+			aliveSet[idx] = struct{}{}
+		}
+		helpers := make([]int, 0, c.k/c.l)
+		for _, idx := range c.localGroupMembers(group) {
+			if _, ok := aliveSet[idx]; ok && idx != failedIdx {
+				helpers = append(helpers, idx)
+			}
+		}
+		if len(helpers) == c.k/c.l {
+			return helpers, 1
+		}
+	}
+	return firstHelpers(failedIdx, alive, c.k), 1
+}
+
+// FaultTolerance 最大可恢复 LRC 可以容忍任意 G+1 个块失效
+func (c *lrc) FaultTolerance() int {
+	return c.g + 1
+}
+
+func (c *lrc) StorageOverhead() float64 {
+	return float64(c.N()) / float64(c.k)
+}
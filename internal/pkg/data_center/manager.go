@@ -4,10 +4,14 @@ import (
 	"ECDC_SIM/internal/pkg/enum_error"
 	"ECDC_SIM/internal/pkg/util"
 	"github.com/gogap/logrus"
+	"math/rand"
+	"sort"
 )
 
-var (
-	dcManager *DCManager
+const (
+	// BytesPerChunkSizeUnit DCConf.ChunkSize 以 MB 为单位
+	BytesPerChunkSizeUnit = 1 << 20
+	BytesPerTB            = 1 << 40
 )
 
 type DCState int8
@@ -30,18 +34,26 @@ type DCManager struct {
 	chunkSize       int
 	dataChunksNum   int
 	erasureCodeConf *ErasureCodeConf
+	erasureCode     ErasureCode
 	stripesLocation [][]int
+	lostStripes     map[int]struct{}         // 已记录过数据丢失的条带，不再重复计入
+	rebuiltChunks   map[int]map[int]struct{} // 按条带修复时，故障磁盘上已重建完成的条带
+	abandonedChunks map[int]map[int]struct{} // 按条带修复时，故障磁盘上因条带无法恢复而放弃重建的条带
 	missionTime     float64
+	powerDomainsNum int
+	pOutageD        *util.Weibull
+	pRestoreD       *util.Weibull
+	powerUpFailure  PowerUpFailure
 }
 
 type DCConf struct {
 	RacksNum                    int
 	StripesNum                  int
 	DisksPerNode                int
-	DiskCapacity                int
+	DiskCapacity                int // 单位 GB
 	NodesPerRack                int
 	ChunkNum                    int
-	ChunkSize                   int
+	ChunkSize                   int // 单位 MB
 	DataChunksNum               int
 	NFailD, NTFailD, NTRepairD  *util.Weibull
 	DFailD, DRepairD            *util.Weibull
@@ -50,10 +62,23 @@ type DCConf struct {
 	MaxIntraRackRepairBandwidth float64
 	MissionTime                 float64
 	UseNetwork                  bool
+	BandwidthSharing            BandwidthSharing // 同时进行的修复分配修复带宽的方式，只在 UseNetwork 时生效
+
+	PowerDomainsNum int           // 供电域数，机架按编号连续均分到各个供电域，为 0 时整个数据中心为一个供电域
+	POutageD        *util.Weibull // 供电域两次断电之间的时间分布
+	PRestoreD       *util.Weibull // 断电后恢复供电所需的时间分布
+	PowerUpFailure  PowerUpFailure
+}
+
+// PowerUpFailure 恢复供电时永久故障的节点与磁盘比例，按设备独立抽样
+type PowerUpFailure struct {
+	NodeRatio float64
+	DiskRatio float64
 }
 
-func InitDCManager(dcConf *DCConf, eCConf *ErasureCodeConf) {
-	dcManager = &DCManager{
+// NewDCManager 创建一个数据中心实例，每个模拟器持有各自的实例
+func NewDCManager(dcConf *DCConf, eCConf *ErasureCodeConf) *DCManager {
+	dcm := &DCManager{
 		state:           OK,
 		disksPerNode:    dcConf.DisksPerNode,
 		nodesPerRack:    dcConf.NodesPerRack,
@@ -62,25 +87,40 @@ func InitDCManager(dcConf *DCConf, eCConf *ErasureCodeConf) {
 		chunkSize:       dcConf.ChunkSize,
 		dataChunksNum:   dcConf.DataChunksNum,
 		erasureCodeConf: eCConf,
+		lostStripes:     make(map[int]struct{}),
+		rebuiltChunks:   make(map[int]map[int]struct{}),
+		abandonedChunks: make(map[int]map[int]struct{}),
 		missionTime:     dcConf.MissionTime,
+		powerDomainsNum: dcConf.PowerDomainsNum,
+		pOutageD:        dcConf.POutageD,
+		pRestoreD:       dcConf.PRestoreD,
+		powerUpFailure:  dcConf.PowerUpFailure,
 	}
-	dcManager.nodesManager = NewNodesManager(dcConf.RacksNum*dcConf.NodesPerRack, dcConf.NFailD, dcConf.NTFailD, dcConf.NTRepairD)
-	dcManager.disksManager = NewDisksManager(dcManager.nodesManager.nodesNum*dcConf.DisksPerNode, dcConf.DiskCapacity, dcConf.DFailD, dcConf.DRepairD)
-	dcManager.rackManager = NewRacksManager(dcConf.RacksNum, dcConf.RFailD, dcConf.RRepairD)
-	dcManager.networkManager = NewNetworkManager(dcConf.RacksNum, dcConf.UseNetwork, dcConf.MaxCrossRackRepairBandwidth, dcConf.MaxIntraRackRepairBandwidth)
-	return
-}
-
-func GetDCManager() *DCManager {
-	return dcManager
+	var errs enum_error.ConfigErrors
+	if dcm.erasureCode, errs = NewErasureCode(eCConf); len(errs) > 0 {
+		logrus.Errorf("[NewDCManager] invalid erasure code, err=%+v", errs)
+	}
+	if dcm.powerDomainsNum == 0 {
+		dcm.powerDomainsNum = 1
+	}
+	dcm.nodesManager = NewNodesManager(dcConf.RacksNum*dcConf.NodesPerRack, dcConf.NFailD, dcConf.NTFailD, dcConf.NTRepairD)
+	dcm.disksManager = NewDisksManager(dcm.nodesManager.nodesNum*dcConf.DisksPerNode, dcConf.DiskCapacity, dcConf.DFailD, dcConf.DRepairD)
+	dcm.rackManager = NewRacksManager(dcConf.RacksNum, dcConf.RFailD, dcConf.RRepairD)
+	dcm.networkManager = NewNetworkManager(dcConf.RacksNum, dcConf.UseNetwork, dcConf.BandwidthSharing, dcConf.MaxCrossRackRepairBandwidth, dcConf.MaxIntraRackRepairBandwidth)
+	return dcm
 }
 
-func (dcm *DCManager) Reset() {
+// Reset 重置设备状态，并使用随机源 r 重新生成数据放置
+func (dcm *DCManager) Reset(r *rand.Rand) {
 	dcm.disksManager.Reset(0)
 	dcm.nodesManager.Reset(0)
 	dcm.rackManager.Reset(0)
 	dcm.networkManager.Reset()
-	dcm.GenerateDataPlacement()
+	dcm.stripesLocation = nil
+	dcm.lostStripes = make(map[int]struct{})
+	dcm.rebuiltChunks = make(map[int]map[int]struct{})
+	dcm.abandonedChunks = make(map[int]map[int]struct{})
+	dcm.GenerateDataPlacement(r)
 }
 
 func (dcm *DCManager) DiskManager() *DisksManager {
@@ -103,8 +143,12 @@ func (dcm *DCManager) ErasureCodeConf() *ErasureCodeConf {
 	return dcm.erasureCodeConf
 }
 
+func (dcm *DCManager) ErasureCode() ErasureCode {
+	return dcm.erasureCode
+}
+
 func (dcm *DCManager) GetRackIdByDiskId(diskId int) int {
-	return diskId / dcm.nodesPerRack * dcm.disksPerNode
+	return diskId / (dcm.nodesPerRack * dcm.disksPerNode)
 }
 
 func (dcm *DCManager) GetDiskIdByNodeId(nodeId int, offset int) int {
@@ -146,88 +190,273 @@ func (dcm *DCManager) GetMissionTime() float64 {
 	return dcm.missionTime
 }
 
-// GenerateDataPlacement 生成数据块放置策略
-func (dcm *DCManager) GenerateDataPlacement() {
-	var err error
-	switch dcm.erasureCodeConf.CodeType {
-	case RS:
-		logrus.Info("[DCManager.GenerateDataPlacement] generate placement for code RS")
-		err = dcm.GeneratePlacementByArchType()
-		if err != nil {
-			logrus.Errorf("DCManager.GenerateDataPlacement error, codeType=RS, err=%+v", err)
+// GetChunkNum 数据中心的块总数
+func (dcm *DCManager) GetChunkNum() int {
+	return dcm.chunksNum
+}
+
+func (dcm *DCManager) GetPowerDomainsNum() int {
+	return dcm.powerDomainsNum
+}
+
+// GetPowerDomainByRackId 机架所在的供电域
+func (dcm *DCManager) GetPowerDomainByRackId(rackId int) int {
+	return rackId * dcm.powerDomainsNum / dcm.rackManager.racksNum
+}
+
+// GetRackIdsByPowerDomain 供电域内的全部机架
+func (dcm *DCManager) GetRackIdsByPowerDomain(domainId int) []int {
+	rackIdList := make([]int, 0)
+	for rackId := 0; rackId < dcm.rackManager.racksNum; rackId++ {
+		if dcm.GetPowerDomainByRackId(rackId) == domainId {
+			rackIdList = append(rackIdList, rackId)
 		}
-	case LRC:
+	}
+	return rackIdList
+}
 
-	default:
+func (dcm *DCManager) GetPowerOutageDistribution() *util.Weibull {
+	return dcm.pOutageD
+}
 
+func (dcm *DCManager) GetPowerRestoreDistribution() *util.Weibull {
+	return dcm.pRestoreD
+}
+
+func (dcm *DCManager) GetPowerUpFailure() PowerUpFailure {
+	return dcm.powerUpFailure
+}
+
+// GenerateDataPlacement 生成数据块放置策略
+func (dcm *DCManager) GenerateDataPlacement(r *rand.Rand) {
+	logrus.Infof("[DCManager.GenerateDataPlacement] generate placement for code %s", dcm.erasureCode)
+	if err := dcm.GeneratePlacementByArchType(r); err != nil {
+		logrus.Errorf("DCManager.GenerateDataPlacement error, code=%s, err=%+v", dcm.erasureCode, err)
 	}
 }
 
-func (dcm *DCManager) GeneratePlacementByArchType() error {
+func (dcm *DCManager) GeneratePlacementByArchType(r *rand.Rand) error {
+	racksNum := dcm.rackManager.racksNum
+	if dcm.erasureCode == nil {
+		logrus.Error("[DCManager.GeneratePlacementByArchType] invalid erasure code")
+		return enum_error.ParamsInvalidError
+	}
 	switch dcm.erasureCodeConf.ChunkPlaceType {
 	case FLAT:
-		if dcm.rackManager.racksNum < dcm.erasureCodeConf.N {
-			logrus.Errorf("[DCManager.GenerateRSPlacement] error params for rack init, racksNum=%d,N=%d", dcm.rackManager.racksNum, dcm.erasureCodeConf.N)
+		if minRacksNum := minFlatRacksNum(dcm.erasureCode); racksNum < minRacksNum {
+			logrus.Errorf("[DCManager.GeneratePlacementByArchType] error params for rack init, racksNum=%d,minRacksNum=%d", racksNum, minRacksNum)
 			return enum_error.ParamsInvalidError
 		}
-		for stripeId := 0; stripeId < dcm.stripesNum; stripeId++ {
-			rackIdList := util.GenerateListSample(dcm.rackManager.racksNum, dcm.erasureCodeConf.N)
-			diskIdList := make([]int, 0)
-			for _, rackId := range rackIdList {
-				diskId := dcm.GetDiskRandomlyByRack(rackId)
-				dcm.disksManager.SetDiskStripe(diskId, stripeId, rackId)
-				diskIdList = append(diskIdList, diskId)
-			}
-			dcm.stripesLocation = append(dcm.stripesLocation, diskIdList)
-		}
 	case HIERARCHICAL:
+		if dcm.erasureCodeConf.ChunksPerRack <= 0 || racksNum < dcm.erasureCodeConf.HierarchicalRacksNum() {
+			logrus.Errorf("[DCManager.GeneratePlacementByArchType] error params for rack init, racksNum=%d,chunksPerRack=%d", racksNum, dcm.erasureCodeConf.ChunksPerRack)
+			return enum_error.ParamsInvalidError
+		}
 	default:
 		logrus.Error("[DCManager.GeneratePlacementByArchType] invalid chunk place type")
+		return enum_error.ParamsInvalidError
+	}
+	// 每个条带抽样不同的机架，块下标到机架的划分见 rackSlots，同一条带的块位于不同磁盘，并尽量位于不同节点
+	slots := rackSlots(dcm.erasureCode, dcm.erasureCodeConf, racksNum)
+	for stripeId := 0; stripeId < dcm.stripesNum; stripeId++ {
+		rackIdList := util.GenerateListSample(r, racksNum, len(slots))
+		diskIdList := make([]int, dcm.erasureCode.N())
+		usedDisks, usedNodes := make(map[int]struct{}, len(diskIdList)), make(map[int]struct{}, len(diskIdList))
+		for slot, idxList := range slots {
+			for _, idx := range idxList {
+				diskId := dcm.getUnusedDiskByRack(r, rackIdList[slot], usedDisks, usedNodes)
+				dcm.disksManager.SetDiskStripe(diskId, stripeId, idx)
+				diskIdList[idx] = diskId
+			}
+		}
+		dcm.stripesLocation = append(dcm.stripesLocation, diskIdList)
 	}
 	return nil
 }
 
-func (dcm *DCManager) GetDiskRandomlyByRack(rackId int) int {
+// RackFaultTolerance 每个条带可容忍同时故障的机架数
+func (dcm *DCManager) RackFaultTolerance() int {
+	return rackFaultTolerance(dcm.erasureCode, rackSlots(dcm.erasureCode, dcm.erasureCodeConf, dcm.rackManager.racksNum))
+}
+
+func (dcm *DCManager) GetDiskRandomlyByRack(r *rand.Rand, rackId int) int {
 	minDiskNumber := rackId * dcm.nodesPerRack * dcm.disksPerNode
 	maxDiskNumber := minDiskNumber + dcm.nodesPerRack*dcm.disksPerNode - 1
 	if minDiskNumber == maxDiskNumber {
 		return minDiskNumber
 	}
-	return util.RandomInt(minDiskNumber, maxDiskNumber)
+	return util.RandomInt(r, minDiskNumber, maxDiskNumber)
 }
 
-func (dcm *DCManager) CheckDataLoss() (bool, int, int) {
+// getUnusedDiskByRack 在机架中随机选择一个同一条带未使用的磁盘并标记为已使用。选中的磁盘已使用或所在节点已放置该条带的块时
+// 顺序取机架内的下一个，优先使用没有该条带块的节点，使一个节点故障至多影响条带的一个块（如同一条带的副本位于不同节点）
+func (dcm *DCManager) getUnusedDiskByRack(r *rand.Rand, rackId int, usedDisks, usedNodes map[int]struct{}) int {
+	disksPerRack := dcm.nodesPerRack * dcm.disksPerNode
+	firstDisk := rackId * disksPerRack
+	start := dcm.GetDiskRandomlyByRack(r, rackId)
+	diskId := -1
+	for _, nodeFree := range []bool{true, false} {
+		for offset := 0; offset < disksPerRack; offset++ {
+			candidate := firstDisk + (start-firstDisk+offset)%disksPerRack
+			if _, used := usedDisks[candidate]; used {
+				continue
+			}
+			if _, used := usedNodes[dcm.GetNodeIdByDiskId(candidate)]; used && nodeFree {
+				continue
+			}
+			diskId = candidate
+			break
+		}
+		if diskId >= 0 {
+			break
+		}
+	}
+	usedDisks[diskId] = struct{}{}
+	usedNodes[dcm.GetNodeIdByDiskId(diskId)] = struct{}{}
+	return diskId
+}
+
+// DataLossInfo 数据丢失检查的结果，丢失的块按下标区分数据块（下标小于 K）与校验块
+type DataLossInfo struct {
+	FailedStripes      []int
+	LostChunkNum       int
+	LostDataChunkNum   int
+	LostParityChunkNum int
+}
+
+// CheckDataLoss 检查当前是否存在无法恢复的条带，每个条带只统计一次
+func (dcm *DCManager) CheckDataLoss() (bool, *DataLossInfo) {
 	failedDiskMap := dcm.disksManager.GetFailedDiskMap()
-	// TODO check logic here
-	stripeIdList := make([]int, 0)
+	stripeIdMap := make(map[int]struct{})
 	for _, failedDisk := range failedDiskMap {
-		stripeIdList = append(stripeIdList, dcm.disksManager.GetDiskStripes(failedDisk)...)
-	}
-	var dataLoss bool
-	var failedStripes int
-	var lostChunks int
-	switch dcm.erasureCodeConf.CodeType {
-	case RS:
-		for _, stripeId := range stripeIdList {
-			curStripeFailedDiskNum := 0
-			curStripeLostChunksNum := 0
-			for _, stripeDiskId := range dcm.stripesLocation[stripeId] {
-				if _, ok := failedDiskMap[stripeDiskId]; ok {
-					curStripeFailedDiskNum += 1
-					curStripeLostChunksNum += 1
-				}
+		for _, stripeId := range dcm.disksManager.GetDiskStripes(failedDisk) {
+			if _, lost := dcm.lostStripes[stripeId]; !lost {
+				stripeIdMap[stripeId] = struct{}{}
 			}
-			if curStripeFailedDiskNum > dcm.erasureCodeConf.N-dcm.erasureCodeConf.K {
-				dataLoss = true
-				failedStripes += 1
-				lostChunks += curStripeLostChunksNum
+		}
+	}
+	stripeIdList := make([]int, 0, len(stripeIdMap))
+	for stripeId := range stripeIdMap {
+		stripeIdList = append(stripeIdList, stripeId)
+	}
+	sort.Ints(stripeIdList)
+	lossInfo := new(DataLossInfo)
+	for _, stripeId := range stripeIdList {
+		failedIdxList := dcm.failedChunkIdx(stripeId, failedDiskMap)
+		if dcm.erasureCode.IsRecoverable(failedIdxList) {
+			continue
+		}
+		lossInfo.FailedStripes = append(lossInfo.FailedStripes, stripeId)
+		for _, idx := range failedIdxList {
+			lossInfo.LostChunkNum++
+			if idx < dcm.erasureCode.K() {
+				lossInfo.LostDataChunkNum++
+			} else {
+				lossInfo.LostParityChunkNum++
 			}
 		}
-		return dataLoss, failedStripes, lostChunks
-	case LRC:
 	}
+	return len(lossInfo.FailedStripes) > 0, lossInfo
+}
+
+// failedChunkIdx 条带中位于故障磁盘上且尚未重建的块下标
+func (dcm *DCManager) failedChunkIdx(stripeId int, failedDiskMap map[int]int) []int {
+	failedIdxList := make([]int, 0)
+	for idx, stripeDiskId := range dcm.stripesLocation[stripeId] {
+		if _, ok := failedDiskMap[stripeDiskId]; ok && !dcm.IsChunkRebuilt(stripeDiskId, stripeId) {
+			failedIdxList = append(failedIdxList, idx)
+		}
+	}
+	return failedIdxList
+}
+
+// RebuildChunk 记录故障磁盘 diskId 上条带 stripeId 的块已重建，返回该磁盘上的条带是否已全部重建或放弃重建
+func (dcm *DCManager) RebuildChunk(diskId, stripeId int) bool {
+	rebuilt, ok := dcm.rebuiltChunks[diskId]
+	if !ok {
+		rebuilt = make(map[int]struct{})
+		dcm.rebuiltChunks[diskId] = rebuilt
+	}
+	rebuilt[stripeId] = struct{}{}
+	delete(dcm.abandonedChunks[diskId], stripeId)
+	return dcm.isDiskSettled(diskId)
+}
+
+// AbandonChunk 放弃重建故障磁盘 diskId 上无法恢复的条带 stripeId 的块，该块仍视为丢失，
+// 返回该磁盘上的条带是否已全部重建或放弃重建
+func (dcm *DCManager) AbandonChunk(diskId, stripeId int) bool {
+	if !dcm.IsChunkRebuilt(diskId, stripeId) {
+		abandoned, ok := dcm.abandonedChunks[diskId]
+		if !ok {
+			abandoned = make(map[int]struct{})
+			dcm.abandonedChunks[diskId] = abandoned
+		}
+		abandoned[stripeId] = struct{}{}
+	}
+	return dcm.isDiskSettled(diskId)
+}
+
+func (dcm *DCManager) isDiskSettled(diskId int) bool {
+	return len(dcm.rebuiltChunks[diskId])+len(dcm.abandonedChunks[diskId]) >= len(dcm.disksManager.GetDiskStripes(diskId))
+}
+
+// IsChunkRebuilt 故障磁盘 diskId 上条带 stripeId 的块是否已重建
+func (dcm *DCManager) IsChunkRebuilt(diskId, stripeId int) bool {
+	_, ok := dcm.rebuiltChunks[diskId][stripeId]
+	return ok
+}
+
+// IsChunkLost 磁盘 diskId 上条带 stripeId 的块是否因磁盘故障丢失且尚未重建
+func (dcm *DCManager) IsChunkLost(diskId, stripeId int) bool {
+	return dcm.disksManager.GetDiskState(diskId) == DiskStateCrashed && !dcm.IsChunkRebuilt(diskId, stripeId)
+}
+
+// ClearRebuiltChunks 磁盘修复完成后清除其重建与放弃重建的记录
+func (dcm *DCManager) ClearRebuiltChunks(diskId int) {
+	delete(dcm.rebuiltChunks, diskId)
+	delete(dcm.abandonedChunks, diskId)
+}
+
+// MarkStripesLost 将条带标记为已丢失，之后的 CheckDataLoss 不再统计这些条带
+func (dcm *DCManager) MarkStripesLost(stripeIdList []int) {
+	for _, stripeId := range stripeIdList {
+		dcm.lostStripes[stripeId] = struct{}{}
+	}
+}
+
+// RestoreRecoverableStripes 已丢失的条带在失效块修复到可恢复范围内后视为已从外部恢复，重新参与数据丢失检查，
+// 返回恢复的条带数
+func (dcm *DCManager) RestoreRecoverableStripes() int {
+	failedDiskMap := dcm.disksManager.GetFailedDiskMap()
+	restoredNum := 0
+	for stripeId := range dcm.lostStripes {
+		if dcm.erasureCode.IsRecoverable(dcm.failedChunkIdx(stripeId, failedDiskMap)) {
+			delete(dcm.lostStripes, stripeId)
+			restoredNum++
+		}
+	}
+	return restoredNum
+}
+
+// GetLostStripesNum 当前被标记为已丢失的条带数
+func (dcm *DCManager) GetLostStripesNum() int {
+	return len(dcm.lostStripes)
+}
+
+// GetChunkBytes 单个块的字节数
+func (dcm *DCManager) GetChunkBytes() int64 {
+	return int64(dcm.chunkSize) * BytesPerChunkSizeUnit
+}
+
+// GetDataBytesPerChunk 单个数据块含有的用户数据字节数，即 B/K。MBR 的块大小 α 大于 B/K，多出的部分是冗余
+func (dcm *DCManager) GetDataBytesPerChunk() float64 {
+	code := dcm.erasureCode
+	return float64(dcm.GetChunkBytes()) * float64(code.N()) / code.StorageOverhead() / float64(code.K())
+}
 
-	return false, 0, 0
+// GetUsableCapacityTB 数据中心存放的用户数据总量（不含冗余），单位 TB
+func (dcm *DCManager) GetUsableCapacityTB() float64 {
+	return float64(dcm.stripesNum) * float64(dcm.erasureCode.K()) * dcm.GetDataBytesPerChunk() / BytesPerTB
 }
 
 func (dcm *DCManager) GetBlockedRatio(currentTime float64) float64 {
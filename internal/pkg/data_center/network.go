@@ -2,16 +2,21 @@ package data_center
 
 type NetworkManager struct {
 	useNetwork bool
+	sharing    BandwidthSharing
 
 	maxCrossRackRepairBandwidth   float64
 	maxIntraRackRepairBandwidth   float64
 	availCrossRackRepairBandwidth float64
 	availIntraRackRepairBandwidth []float64
+
+	flows    []*repairFlow // 共享带宽时正在进行的修复，按开始顺序排列
+	flowTime float64       // flows 中剩余读取量对应的时刻
 }
 
-func NewNetworkManager(numOfRacks int, useNetwork bool, maxCrossRackRepairBandwidth, maxIntraRackRepairBandwidth float64) *NetworkManager {
+func NewNetworkManager(numOfRacks int, useNetwork bool, sharing BandwidthSharing, maxCrossRackRepairBandwidth, maxIntraRackRepairBandwidth float64) *NetworkManager {
 	network := &NetworkManager{
 		useNetwork:                    useNetwork,
+		sharing:                       sharing,
 		maxCrossRackRepairBandwidth:   maxCrossRackRepairBandwidth,
 		maxIntraRackRepairBandwidth:   maxIntraRackRepairBandwidth,
 		availCrossRackRepairBandwidth: maxCrossRackRepairBandwidth,
@@ -27,6 +32,7 @@ func (n *NetworkManager) Reset() {
 	for idx := range n.availIntraRackRepairBandwidth {
 		n.availIntraRackRepairBandwidth[idx] = n.maxIntraRackRepairBandwidth
 	}
+	n.flows, n.flowTime = nil, 0
 }
 
 func (n *NetworkManager) UpdateAvailCrossRackRepairBandwidth(newBandwidth float64) {
@@ -39,6 +45,17 @@ func (n *NetworkManager) GetAvailCrossRackRepairBandwidth() float64 {
 	return n.availCrossRackRepairBandwidth
 }
 
+func (n *NetworkManager) UpdateAvailIntraRackRepairBandwidth(rackId int, newBandwidth float64) {
+	if newBandwidth <= n.maxIntraRackRepairBandwidth {
+		n.availIntraRackRepairBandwidth[rackId] = newBandwidth
+	}
+}
+
+// ReleaseIntraRackRepairBandwidth 机架内的修复完成后归还机架内带宽，同一机架同时只有一个修复占用
+func (n *NetworkManager) ReleaseIntraRackRepairBandwidth(rackId int) {
+	n.availIntraRackRepairBandwidth[rackId] = n.maxIntraRackRepairBandwidth
+}
+
 func (n *NetworkManager) GetAvailIntraRackRepairBandwidth(rackId int) float64 {
 	return n.availIntraRackRepairBandwidth[rackId]
 }
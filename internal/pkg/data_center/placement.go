@@ -0,0 +1,90 @@
+package data_center
+
+// chunkPlaceOrder 放置时依次分配机架的块下标，实现 LocalGroupCode 的纠删码同一局部组的块相邻排列，其余块在最后
+func chunkPlaceOrder(code ErasureCode) []int {
+	order := make([]int, 0, code.N())
+	grouped := make(map[int]struct{})
+	if groupCode, ok := code.(LocalGroupCode); ok {
+		for _, group := range groupCode.LocalGroups() {
+			for _, idx := range group {
+				order = append(order, idx)
+				grouped[idx] = struct{}{}
+			}
+		}
+	}
+	for idx := 0; idx < code.N(); idx++ {
+		if _, ok := grouped[idx]; !ok {
+			order = append(order, idx)
+		}
+	}
+	return order
+}
+
+// minFlatRacksNum FLAT 放置所需的最少机架数。没有局部组时各块位于不同机架；有局部组时只要求同一局部组的块位于不同机架、
+// 不属于局部组的块位于不同机架，单个机架故障时每个局部组至多失效一个块
+func minFlatRacksNum(code ErasureCode) int {
+	groupCode, ok := code.(LocalGroupCode)
+	if !ok {
+		return code.N()
+	}
+	minRacksNum, groupedNum := 0, 0
+	for _, group := range groupCode.LocalGroups() {
+		if len(group) > minRacksNum {
+			minRacksNum = len(group)
+		}
+		groupedNum += len(group)
+	}
+	if code.N()-groupedNum > minRacksNum {
+		minRacksNum = code.N() - groupedNum
+	}
+	return minRacksNum
+}
+
+// rackSlots 按放置方式将条带的块下标划分到各个机架，racksNum 为数据中心的机架数。
+// FLAT 按放置顺序循环使用 min(N, racksNum) 个机架；HIERARCHICAL 按放置顺序每 ChunksPerRack 个块放在同一机架，
+// 局部组因此尽量位于同一机架
+func rackSlots(code ErasureCode, conf *ErasureCodeConf, racksNum int) [][]int {
+	placeOrder := chunkPlaceOrder(code)
+	var slots [][]int
+	switch conf.ChunkPlaceType {
+	case FLAT:
+		slotNum := code.N()
+		if racksNum < slotNum {
+			slotNum = racksNum
+		}
+		slots = make([][]int, slotNum)
+		for pos, idx := range placeOrder {
+			slots[pos%slotNum] = append(slots[pos%slotNum], idx)
+		}
+	case HIERARCHICAL:
+		slots = make([][]int, conf.HierarchicalRacksNum())
+		for pos, idx := range placeOrder {
+			slots[pos/conf.ChunksPerRack] = append(slots[pos/conf.ChunksPerRack], idx)
+		}
+	}
+	return slots
+}
+
+// rackFaultTolerance 条带所在的机架中任意多少个同时故障仍能恢复数据
+func rackFaultTolerance(code ErasureCode, slots [][]int) int {
+	for failedNum := 1; failedNum <= len(slots); failedNum++ {
+		if !recoverableRackFailures(code, slots, failedNum, 0, nil) {
+			return failedNum - 1
+		}
+	}
+	return len(slots)
+}
+
+// recoverableRackFailures 从第 start 个机架起再选择 failedNum 个机架与 failedIdx 一起失效的所有组合是否都能恢复
+func recoverableRackFailures(code ErasureCode, slots [][]int, failedNum, start int, failedIdx []int) bool {
+	if failedNum == 0 {
+		return code.IsRecoverable(failedIdx)
+	}
+	for slot := start; slot <= len(slots)-failedNum; slot++ {
+		failed := append(append(make([]int, 0, len(failedIdx)+len(slots[slot])), failedIdx...), slots[slot]...)
+		if !recoverableRackFailures(code, slots, failedNum-1, slot+1, failed) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,81 @@
+package data_center
+
+import (
+	"ECDC_SIM/internal/pkg/enum_error"
+	"fmt"
+)
+
+// regenerating 再生码，K <= d <= N-1。每个块存储 α 字节，修复一个块时从 d 个协助块各下载 β 字节，文件大小为 B：
+//   - MSR：α = B/K，β = α/(d-K+1)，按通用构造（如 product-matrix）协助块读取整个块计算出 β 字节再传输
+//   - CLAY：Clay 码是 MSR 的一种构造，α、β 与 MSR 相同，协助块从磁盘读取的数据量也只有 β
+//   - MBR：α = 2Bd/(K(2d-K+1))，β = α/d，修复只下载一个块的数据量，存储开销大于 MSR，协助块同样读取整个块
+//
+// 任意 K 个块可以恢复全部数据。可用块不足 d 个时退化为读取 K 个完整的块
+type regenerating struct {
+	codeType ErasureCodeType
+	n, k, d  int
+}
+
+func newRegenerating(conf *ErasureCodeConf) (ErasureCode, enum_error.ConfigErrors) {
+	d := conf.D
+	if d == 0 {
+		d = conf.N - 1
+	}
+	if d < conf.K || d > conf.N-1 {
+		return nil, enum_error.ConfigErrors{enum_error.NewConfigError(enum_error.ParamsOutOfRangeError, "ErasureCodeConf.D",
+			"must be in [K=%d, N-1=%d] for %s, got %d", conf.K, conf.N-1, conf.CodeType, d)}
+	}
+	return &regenerating{codeType: conf.CodeType, n: conf.N, k: conf.K, d: d}, nil
+}
+
+func (c *regenerating) String() string {
+	return fmt.Sprintf("%s(%d,%d,d=%d)", c.codeType, c.n, c.k, c.d)
+}
+
+func (c *regenerating) N() int {
+	return c.n
+}
+
+func (c *regenerating) K() int {
+	return c.k
+}
+
+func (c *regenerating) IsRecoverable(failedIdx []int) bool {
+	return len(failedIdx) <= c.n-c.k
+}
+
+// RepairPlan 可用块不少于 d 个时读取代价最低的 d 个块，每个传输 β/α
+func (c *regenerating) RepairPlan(failedIdx int, alive []int) ([]int, float64) {
+	if helpers := firstHelpers(failedIdx, alive, c.d); helpers != nil {
+		return helpers, c.helperRatio()
+	}
+	return firstHelpers(failedIdx, alive, c.k), 1
+}
+
+// helperRatio β/α
+func (c *regenerating) helperRatio() float64 {
+	if c.codeType == MBR {
+		return 1 / float64(c.d)
+	}
+	return 1 / float64(c.d-c.k+1)
+}
+
+// HelperReadRatio CLAY 的协助块只读取要传输的数据，MSR 与 MBR 读取整个块
+func (c *regenerating) HelperReadRatio(helperRatio float64) float64 {
+	if c.codeType == CLAY {
+		return helperRatio
+	}
+	return 1
+}
+
+func (c *regenerating) FaultTolerance() int {
+	return c.n - c.k
+}
+
+// StorageOverhead nα/B，MBR 为 2nd/(K(2d-K+1))
+func (c *regenerating) StorageOverhead() float64 {
+	if c.codeType == MBR {
+		return float64(2*c.n*c.d) / float64(c.k*(2*c.d-c.k+1))
+	}
+	return float64(c.n) / float64(c.k)
+}
@@ -0,0 +1,47 @@
+package data_center
+
+import (
+	"ECDC_SIM/internal/pkg/enum_error"
+	"fmt"
+)
+
+// replication N 副本冗余，条带的 N 个块互为副本，任意一个可用副本即可恢复数据，修复时读取代价最低的一个副本
+type replication struct {
+	replicas int
+}
+
+func newReplication(conf *ErasureCodeConf) (ErasureCode, enum_error.ConfigErrors) {
+	if conf.K != 1 {
+		return nil, enum_error.ConfigErrors{enum_error.NewConfigError(enum_error.ParamsInconsistentError, "ErasureCodeConf.K", "must be 1 for REPLICATION, got %d", conf.K)}
+	}
+	return &replication{replicas: conf.N}, nil
+}
+
+func (c *replication) String() string {
+	return fmt.Sprintf("REPLICATION(%d)", c.replicas)
+}
+
+func (c *replication) N() int {
+	return c.replicas
+}
+
+func (c *replication) K() int {
+	return 1
+}
+
+func (c *replication) IsRecoverable(failedIdx []int) bool {
+	return len(failedIdx) < c.replicas
+}
+
+// RepairPlan 读取代价最低的一个可用副本
+func (c *replication) RepairPlan(failedIdx int, alive []int) ([]int, float64) {
+	return firstHelpers(failedIdx, alive, 1), 1
+}
+
+func (c *replication) FaultTolerance() int {
+	return c.replicas - 1
+}
+
+func (c *replication) StorageOverhead() float64 {
+	return float64(c.replicas)
+}
@@ -0,0 +1,44 @@
+package data_center
+
+import (
+	"ECDC_SIM/internal/pkg/enum_error"
+	"fmt"
+)
+
+// reedSolomon RS(N,K) 码，任意 K 个块可以恢复全部数据
+type reedSolomon struct {
+	n, k int
+}
+
+func newReedSolomon(conf *ErasureCodeConf) (ErasureCode, enum_error.ConfigErrors) {
+	return &reedSolomon{n: conf.N, k: conf.K}, nil
+}
+
+func (c *reedSolomon) String() string {
+	return fmt.Sprintf("RS(%d,%d)", c.n, c.k)
+}
+
+func (c *reedSolomon) N() int {
+	return c.n
+}
+
+func (c *reedSolomon) K() int {
+	return c.k
+}
+
+func (c *reedSolomon) IsRecoverable(failedIdx []int) bool {
+	return len(failedIdx) <= c.n-c.k
+}
+
+// RepairPlan 读取代价最低的 K 个可用块
+func (c *reedSolomon) RepairPlan(failedIdx int, alive []int) ([]int, float64) {
+	return firstHelpers(failedIdx, alive, c.k), 1
+}
+
+func (c *reedSolomon) FaultTolerance() int {
+	return c.n - c.k
+}
+
+func (c *reedSolomon) StorageOverhead() float64 {
+	return float64(c.n) / float64(c.k)
+}
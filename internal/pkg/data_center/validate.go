@@ -0,0 +1,157 @@
+package data_center
+
+import (
+	"ECDC_SIM/internal/pkg/enum_error"
+	"ECDC_SIM/internal/pkg/util"
+)
+
+// Validate 检查纠删码配置
+func (c *ErasureCodeConf) Validate() enum_error.ConfigErrors {
+	var errs enum_error.ConfigErrors
+	if _, ok := chunkPlaceTypeNames[c.ChunkPlaceType]; !ok {
+		errs = append(errs, enum_error.NewConfigError(enum_error.ParamsOutOfRangeError, "ErasureCodeConf.ChunkPlaceType", "unknown chunk place type %d", c.ChunkPlaceType))
+	}
+	if c.K <= 0 {
+		errs = append(errs, enum_error.NewConfigError(enum_error.ParamsOutOfRangeError, "ErasureCodeConf.K", "must be positive, got %d", c.K))
+	}
+	if c.K >= c.N {
+		errs = append(errs, enum_error.NewConfigError(enum_error.ParamsInconsistentError, "ErasureCodeConf.K", "must be less than N=%d, got %d", c.N, c.K))
+	}
+	if c.ChunkPlaceType == HIERARCHICAL && (c.ChunksPerRack <= 0 || c.ChunksPerRack > c.N) {
+		errs = append(errs, enum_error.NewConfigError(enum_error.ParamsOutOfRangeError, "ErasureCodeConf.ChunksPerRack",
+			"must be in [1, N=%d] for HIERARCHICAL placement, got %d", c.N, c.ChunksPerRack))
+	}
+	if _, codeErrs := NewErasureCode(c); len(codeErrs) > 0 {
+		errs = append(errs, codeErrs...)
+	}
+	return errs
+}
+
+// Validate 检查数据中心配置，ecConf 非 nil 时同时检查与纠删码相关的约束
+func (c *DCConf) Validate(ecConf *ErasureCodeConf) enum_error.ConfigErrors {
+	var errs enum_error.ConfigErrors
+	for _, field := range []struct {
+		name  string
+		value int
+	}{
+		{"DCConf.RacksNum", c.RacksNum},
+		{"DCConf.NodesPerRack", c.NodesPerRack},
+		{"DCConf.DisksPerNode", c.DisksPerNode},
+		{"DCConf.DiskCapacity", c.DiskCapacity},
+		{"DCConf.StripesNum", c.StripesNum},
+		{"DCConf.ChunkNum", c.ChunkNum},
+		{"DCConf.ChunkSize", c.ChunkSize},
+	} {
+		if field.value <= 0 {
+			errs = append(errs, enum_error.NewConfigError(enum_error.ParamsOutOfRangeError, field.name, "must be positive, got %d", field.value))
+		}
+	}
+	if c.MissionTime <= 0 {
+		errs = append(errs, enum_error.NewConfigError(enum_error.ParamsOutOfRangeError, "DCConf.MissionTime", "must be positive, got %g", c.MissionTime))
+	}
+	for _, field := range []struct {
+		name     string
+		weibull  *util.Weibull
+		required bool
+	}{
+		{"DCConf.NFailD", c.NFailD, true},
+		{"DCConf.DFailD", c.DFailD, true},
+		{"DCConf.DRepairD", c.DRepairD, !c.UseNetwork}, // 不模拟网络时修复时间按该分布抽样
+		{"DCConf.NTFailD", c.NTFailD, false},
+		{"DCConf.NTRepairD", c.NTRepairD, false},
+		{"DCConf.RFailD", c.RFailD, false},
+		{"DCConf.RRepairD", c.RRepairD, false},
+		{"DCConf.POutageD", c.POutageD, false},
+		{"DCConf.PRestoreD", c.PRestoreD, false},
+	} {
+		errs = append(errs, validateWeibull(field.name, field.weibull, field.required)...)
+	}
+	if c.UseNetwork {
+		if c.MaxCrossRackRepairBandwidth <= 0 {
+			errs = append(errs, enum_error.NewConfigError(enum_error.ParamsOutOfRangeError, "DCConf.MaxCrossRackRepairBandwidth", "must be positive when UseNetwork is set, got %g", c.MaxCrossRackRepairBandwidth))
+		}
+		if c.MaxIntraRackRepairBandwidth <= 0 {
+			errs = append(errs, enum_error.NewConfigError(enum_error.ParamsOutOfRangeError, "DCConf.MaxIntraRackRepairBandwidth", "must be positive when UseNetwork is set, got %g", c.MaxIntraRackRepairBandwidth))
+		}
+	}
+	if _, ok := bandwidthSharingNames[c.BandwidthSharing]; !ok {
+		errs = append(errs, enum_error.NewConfigError(enum_error.ParamsOutOfRangeError, "DCConf.BandwidthSharing", "unknown bandwidth sharing %d", c.BandwidthSharing))
+	} else if c.BandwidthSharing != BandwidthExclusive && !c.UseNetwork {
+		errs = append(errs, enum_error.NewConfigError(enum_error.ParamsInconsistentError, "DCConf.BandwidthSharing", "%s requires UseNetwork", c.BandwidthSharing))
+	}
+	if c.PowerDomainsNum < 0 || (c.RacksNum > 0 && c.PowerDomainsNum > c.RacksNum) {
+		errs = append(errs, enum_error.NewConfigError(enum_error.ParamsOutOfRangeError, "DCConf.PowerDomainsNum", "must be in [0, RacksNum=%d], got %d", c.RacksNum, c.PowerDomainsNum))
+	}
+	for _, field := range []struct {
+		name  string
+		value float64
+	}{
+		{"DCConf.PowerUpFailure.NodeRatio", c.PowerUpFailure.NodeRatio},
+		{"DCConf.PowerUpFailure.DiskRatio", c.PowerUpFailure.DiskRatio},
+	} {
+		if field.value < 0 || field.value > 1 {
+			errs = append(errs, enum_error.NewConfigError(enum_error.ParamsOutOfRangeError, field.name, "must be in [0, 1], got %g", field.value))
+		}
+	}
+	disksNum := c.RacksNum * c.NodesPerRack * c.DisksPerNode
+	if disksNum > 0 && c.DiskCapacity > 0 && c.ChunkSize > 0 &&
+		float64(c.ChunkNum)*float64(c.ChunkSize) > float64(disksNum)*float64(c.DiskCapacity)*1024 {
+		errs = append(errs, enum_error.NewConfigError(enum_error.ParamsCapacityExceedError, "DCConf.ChunkNum",
+			"%d chunks of %dMB exceed the capacity of %d disks of %dGB", c.ChunkNum, c.ChunkSize, disksNum, c.DiskCapacity))
+	}
+	if ecConf == nil {
+		return errs
+	}
+	if c.ChunkNum != c.StripesNum*ecConf.N {
+		errs = append(errs, enum_error.NewConfigError(enum_error.ParamsInconsistentError, "DCConf.ChunkNum",
+			"must equal StripesNum*N=%d, got %d", c.StripesNum*ecConf.N, c.ChunkNum))
+	}
+	// 纠删码配置本身的错误由 ErasureCodeConf.Validate 给出
+	code, codeErrs := NewErasureCode(ecConf)
+	if len(codeErrs) > 0 {
+		return errs
+	}
+	switch ecConf.ChunkPlaceType {
+	case FLAT:
+		if minRacksNum := minFlatRacksNum(code); c.RacksNum < minRacksNum {
+			errs = append(errs, enum_error.NewConfigError(enum_error.ParamsInconsistentError, "DCConf.RacksNum",
+				"FLAT placement of %s needs at least %d racks, got %d", code, minRacksNum, c.RacksNum))
+		} else if chunksPerRack := (ecConf.N + c.RacksNum - 1) / c.RacksNum; c.NodesPerRack*c.DisksPerNode < chunksPerRack {
+			errs = append(errs, enum_error.NewConfigError(enum_error.ParamsInconsistentError, "DCConf.DisksPerNode",
+				"FLAT placement puts up to %d chunks of a stripe in a rack, got %d disks per rack", chunksPerRack, c.NodesPerRack*c.DisksPerNode))
+		}
+	case HIERARCHICAL:
+		if ecConf.ChunksPerRack <= 0 {
+			return errs
+		}
+		if racksNum := ecConf.HierarchicalRacksNum(); c.RacksNum < racksNum {
+			errs = append(errs, enum_error.NewConfigError(enum_error.ParamsInconsistentError, "DCConf.RacksNum",
+				"HIERARCHICAL placement of %d chunks, %d per rack, needs at least %d racks, got %d", ecConf.N, ecConf.ChunksPerRack, racksNum, c.RacksNum))
+		}
+		if c.NodesPerRack*c.DisksPerNode < ecConf.ChunksPerRack {
+			errs = append(errs, enum_error.NewConfigError(enum_error.ParamsInconsistentError, "DCConf.DisksPerNode",
+				"HIERARCHICAL placement puts %d chunks of a stripe in a rack, got %d disks per rack", ecConf.ChunksPerRack, c.NodesPerRack*c.DisksPerNode))
+		}
+	}
+	return errs
+}
+
+func validateWeibull(field string, weibull *util.Weibull, required bool) enum_error.ConfigErrors {
+	if weibull == nil {
+		if required {
+			return enum_error.ConfigErrors{enum_error.NewConfigError(enum_error.ParamsMissingError, field, "distribution is required")}
+		}
+		return nil
+	}
+	var errs enum_error.ConfigErrors
+	if weibull.Shape() <= 0 {
+		errs = append(errs, enum_error.NewConfigError(enum_error.ParamsOutOfRangeError, field, "shape must be positive, got %g", weibull.Shape()))
+	}
+	if weibull.Scale() <= 0 {
+		errs = append(errs, enum_error.NewConfigError(enum_error.ParamsOutOfRangeError, field, "scale must be positive, got %g", weibull.Scale()))
+	}
+	if weibull.Location() < 0 {
+		errs = append(errs, enum_error.NewConfigError(enum_error.ParamsOutOfRangeError, field, "location must not be negative, got %g", weibull.Location()))
+	}
+	return errs
+}
@@ -1,7 +1,69 @@
 package enum_error
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
 
 var (
 	ParamsInvalidError = errors.New("invalid params")
+
+	// 以下错误均包装 ParamsInvalidError，errors.Is(err, ParamsInvalidError) 对所有配置错误成立
+	ParamsOutOfRangeError     = fmt.Errorf("%w: value out of range", ParamsInvalidError)
+	ParamsMissingError        = fmt.Errorf("%w: required value missing", ParamsInvalidError)
+	ParamsInconsistentError   = fmt.Errorf("%w: values inconsistent", ParamsInvalidError)
+	ParamsCapacityExceedError = fmt.Errorf("%w: capacity exceeded", ParamsInvalidError)
 )
+
+// ConfigError 某个配置项违反的约束，Kind 为上面定义的错误类别之一
+type ConfigError struct {
+	Field      string
+	Constraint string
+	Kind       error
+}
+
+func NewConfigError(kind error, field, constraintFormat string, args ...interface{}) *ConfigError {
+	return &ConfigError{
+		Field:      field,
+		Constraint: fmt.Sprintf(constraintFormat, args...),
+		Kind:       kind,
+	}
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Constraint)
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Kind
+}
+
+// ConfigErrors 一组配置错误，为空表示配置有效
+type ConfigErrors []*ConfigError
+
+func (errs ConfigErrors) Error() string {
+	messages := make([]string, 0, len(errs))
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("%d invalid settings: %s", len(errs), strings.Join(messages, "; "))
+}
+
+// Err 没有错误时返回 nil，避免把空的 ConfigErrors 当作非 nil 的 error 返回
+func (errs ConfigErrors) Err() error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// Is 使 errors.Is(errs, kind) 在任一错误属于 kind 时成立
+func (errs ConfigErrors) Is(target error) bool {
+	for _, err := range errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,23 +2,28 @@ package event_trigger
 
 import (
 	"ECDC_SIM/internal/pkg/data_center"
-	"ECDC_SIM/internal/pkg/util"
 	"container/heap"
 	"github.com/gogap/logrus"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
 )
 
 var (
 	EventHandlerFuncMap = map[EventType]EventHandlerFunc{
 		EventDiskFail:            DiskFailHandler,
 		EventDiskRepair:          DiskRepairHandler,
+		EventStripeRepair:        StripeRepairHandler,
+		EventRepairBatch:         RepairBatchHandler,
 		EventNodeFail:            NodeFailHandler,
 		EventNodeTransientFail:   NodeTransientFailHandler,
 		EventNodeTransientRepair: NodeTransientRepairHandler,
 		EventRackFail:            RackFailHandler,
 		EventRackRepair:          RackRepairHandler,
+		EventPowerOutage:         PowerOutageHandler,
+		EventPowerRestore:        PowerRestoreHandler,
 	}
-	loggerPath  = `D:\Code\Projects\Go\ECDC_SIM\output\event_log\`
-	eventLogger = util.GetLogger(loggerPath, "event")
 )
 
 type EventType int8
@@ -30,10 +35,15 @@ const (
 
 	EventDiskFail
 	EventDiskRepair
+	EventStripeRepair
+	EventRepairBatch
 
 	EventRackFail
 	EventRackRepair
 
+	EventPowerOutage
+	EventPowerRestore
+
 	EventMissionEnd
 )
 
@@ -43,6 +53,8 @@ const (
 	Rack DeviceType = iota
 	Node
 	Disk
+	PowerDomain
+	Stripe
 )
 
 type Event struct {
@@ -51,6 +63,7 @@ type Event struct {
 	deviceIdList []int
 	deviceType   DeviceType
 	bandwidth    float64
+	canceled     bool // 共享带宽时修复完成时刻重新推算后作废的修复完成事件
 }
 
 type EventExecResult struct {
@@ -74,10 +87,20 @@ func (e *Event) EventType() string {
 		return "DiskFail"
 	case EventDiskRepair:
 		return "DiskRepair"
+	case EventStripeRepair:
+		return "StripeRepair"
+	case EventRepairBatch:
+		return "RepairBatch"
 	case EventRackFail:
 		return "RackFail"
 	case EventRackRepair:
 		return "RackRepair"
+	case EventPowerOutage:
+		return "PowerOutage"
+	case EventPowerRestore:
+		return "PowerRestore"
+	case EventMissionEnd:
+		return "MissionEnd"
 	}
 	return ""
 }
@@ -93,13 +116,30 @@ func NewEvent(eventTime float64, eventType EventType, deviceType DeviceType, ban
 }
 
 type RunningConfig struct {
+	Seed                   int64 // 随机种子，相同种子下第 i 次迭代的事件序列总是相同
 	UseTrace               bool
-	UsePowerOutage         bool
+	UsePowerOutage         bool // 模拟供电域断电，断电使域内设备全部离线，并代替独立的机架故障
 	EnableTransientFailure bool
+	ContinueAfterLoss      bool // 发生数据丢失后记录丢失事件并继续模拟到 MissionTime
+	RestoreLostStripes     bool // ContinueAfterLoss 模式下，丢失的条带在失效块修复后视为已恢复，否则一直标记为丢失
+	StripeRepair           bool // 按条带修复：降级的条带按还能容忍的失效块数排队修复，而不是整块磁盘一次修复
+	StripeRepairSlots      int  // 同时进行的条带修复数上限，大于 1 时需要共享带宽（DCConf.BandwidthSharing 不为 EXCLUSIVE）
+	RepairPolicy           RepairPolicy
+	RepairThreshold        int     // LAZY 策略下条带开始修复时丢失的块数
+	RepairBatchInterval    float64 // BATCHED 策略的批次间隔（小时）
+
+	ConfidenceLevel     float64       // 汇总统计使用的置信水平，为 0 时使用 0.95
+	TargetRelativeError float64       // PDL 相对误差低于该值时提前结束多次迭代，为 0 时不启用
+	TimeBudget          time.Duration // 多次迭代的运行时间预算，用完后停止并返回已完成部分的结果，为 0 时不限制
+
+	Trace *Trace // UseTrace 模式下回放的故障记录，设备不再按分布抽样下一次故障
 }
 
 type EventManager struct {
 	RunningConfig
+	dcManager  *data_center.DCManager
+	rng        *rand.Rand
+	powerUpRng *rand.Rand // 恢复供电时抽样永久故障的节点与磁盘
 	eventQueue *EventHeap
 	waitQueue  *EventHeap
 
@@ -107,19 +147,55 @@ type EventManager struct {
 	repairStripesSingleChunkNum int
 	delayedStripesNum           int
 	delayedRepairDict           map[int][]int
+	localRepairStripesNum       int             // 读取少于 K 个块完成修复的条带数
+	repairReadChunkNum          float64         // 修复读取的数据量，以块为单位
+	repairDiskReadChunkNum      float64         // 修复时协助块从磁盘读取的数据量，以块为单位
+	repairReadSavedChunkNum     float64         // 一并重建条带的多个丢失块比逐个修复少读取的数据量，以块为单位
+	diskRepairStart             map[int]float64 // 正在修复的磁盘开始修复的时间
+	diskRepairTimeSum           float64         // 已完成修复的磁盘从开始修复到完成的用时之和（小时）
+	diskRepairNum               int
+	repairEvents                map[int]*Event // 共享带宽时各修复尚未作废的完成事件，键与 NetworkManager 中的修复编号相同
+
+	stripeRepairQueue     *stripeRepairHeap
+	stripeRepairTasks     map[int]*stripeRepairTask // 在修复队列中的条带
+	stripeRepairSeq       int
+	stripeRepairing       map[int]*stripeRepair // 正在进行的条带修复
+	stripeDegradedStart   map[int]float64       // 有块丢失的条带开始降级的时间
+	stripeDegradedTimeSum float64               // 已恢复完好的条带从降级到恢复的用时之和（小时）
+	stripeDegradedNum     int
+	batchedStripes        []int // BATCHED 策略下等待下一个批次的条带
+
+	traceDiskRepairs map[int]int  // UseTrace 模式下各磁盘尚未处理的修复记录数
+	traceRepairing   map[int]bool // 等待故障记录中的修复的磁盘
+
+	powerOutageNum int
+	powerOff       []*powerOutage // 按供电域编号记录正在进行的断电，未断电时为 nil
+	powerOffTime   float64        // 已恢复供电的断电造成的磁盘离线时间之和
+
+	recorder    EventRecorder
+	stateBefore []int8
+	stateAfter  []int8
+	replay      []*EventRecord
+	replayNext  int
 }
 
-func NewEventManager(configs *RunningConfig) *EventManager {
-	return &EventManager{
-		RunningConfig: RunningConfig{
-			UseTrace:               configs.UseTrace,
-			UsePowerOutage:         configs.UsePowerOutage,
-			EnableTransientFailure: configs.EnableTransientFailure,
-		},
+// NewEventManager 创建绑定到 dcManager 的事件管理器，各事件处理函数只操作该数据中心实例
+func NewEventManager(configs *RunningConfig, dcManager *data_center.DCManager) *EventManager {
+	em := &EventManager{
+		RunningConfig:     *configs,
+		dcManager:         dcManager,
 		eventQueue:        NewEventHeap(make([]*Event, 0)),
 		waitQueue:         NewEventHeap(make([]*Event, 0)),
 		delayedRepairDict: make(map[int][]int),
+		repairEvents:      make(map[int]*Event),
+		powerOff:          make([]*powerOutage, dcManager.GetPowerDomainsNum()),
 	}
+	em.resetStripeRepair()
+	return em
+}
+
+func (em *EventManager) DCManager() *data_center.DCManager {
+	return em.dcManager
 }
 
 func (em *EventManager) GetDelayedRepairDictLength() int {
@@ -130,148 +206,258 @@ func (em *EventManager) GetDelayedRepairDictLength() int {
 	return count
 }
 
-// ResetEventManager 根据各个设备的失败概率分布，生成可能发生的故障事件
-func (em *EventManager) ResetEventManager() {
+// ResetEventManager 使用随机源 r 重置事件队列，本次迭代后续的抽样均使用 r。
+// UseTrace 模式下以故障记录作为故障事件，否则根据各个设备的失败概率分布生成可能发生的故障事件。
+// 设置了回放记录时事件全部来自记录，事件队列不再使用
+func (em *EventManager) ResetEventManager(r *rand.Rand) {
+	em.rng, em.powerUpRng = r, r
+	em.replayNext = 0
+	var eventQueue []*Event
+	if em.replay != nil {
+		eventQueue = make([]*Event, 0)
+	} else if em.UseTrace {
+		// 记录回放完后队列会变空，加入任务结束事件保证迭代能运行到 MissionTime
+		eventQueue = append(em.Trace.events(em.dcManager.GetMissionTime()), em.missionEndEvent())
+	} else {
+		eventQueue = em.drawFailureEvents()
+	}
+	em.eventQueue = NewEventHeap(eventQueue)
+	em.waitQueue = NewEventHeap(make([]*Event, 0))
+	em.repairStripesNum, em.repairStripesSingleChunkNum, em.delayedStripesNum = 0, 0, 0
+	em.localRepairStripesNum, em.repairReadChunkNum, em.repairReadSavedChunkNum, em.repairDiskReadChunkNum = 0, 0, 0, 0
+	em.diskRepairStart, em.diskRepairTimeSum, em.diskRepairNum = make(map[int]float64), 0, 0
+	em.delayedRepairDict, em.repairEvents = make(map[int][]int), make(map[int]*Event)
+	em.resetStripeRepair()
+	em.traceDiskRepairs, em.traceRepairing = make(map[int]int), make(map[int]bool)
+	if em.UseTrace {
+		for diskId, times := range em.Trace.diskRepairTimes(em.dcManager.GetMissionTime()) {
+			em.traceDiskRepairs[diskId] = len(times)
+		}
+	}
+	em.powerOutageNum, em.powerOff, em.powerOffTime = 0, make([]*powerOutage, em.dcManager.GetPowerDomainsNum()), 0
+}
+
+// missionEndEvent 紧接 MissionTime 之后的任务结束事件
+func (em *EventManager) missionEndEvent() *Event {
+	return NewEvent(math.Nextafter(em.dcManager.GetMissionTime(), math.Inf(1)), EventMissionEnd, Disk, 0, nil)
+}
+
+// drawFailureEvents 根据各个设备的失败概率分布抽样生成故障事件
+func (em *EventManager) drawFailureEvents() []*Event {
 	eventQueue := make([]*Event, 0)
-	dcManager := data_center.GetDCManager()
+	dcManager := em.dcManager
 	diskM, nodeM, rackM := dcManager.DiskManager(), dcManager.NodeManager(), dcManager.RackManager()
 	for idx := 0; idx < diskM.GetDiskNum(); idx++ {
-		diskFailTime := diskM.GetDiskFailDistribution(idx).Draw()
+		diskFailTime := diskM.GetDiskFailDistribution(idx).Draw(em.rng)
 		if diskFailTime <= dcManager.GetMissionTime() {
-			logrus.Infof("[EventManager.ResetEventManager] generate disk fail eventTime=%+v", diskFailTime)
+			logrus.Infof("[EventManager.drawFailureEvents] generate disk fail eventTime=%+v", diskFailTime)
 			eventQueue = append(eventQueue, NewEvent(diskFailTime, EventDiskFail, Disk, 0, []int{idx}))
 		}
 	}
 
 	for idx := 0; idx < nodeM.GetNodeNum(); idx++ {
-		nodeFailTime := nodeM.GetNodeFailDistribution(idx).Draw()
-		logrus.Infof("[EventManager.ResetEventManager] generate node fail eventTime=%+v", nodeFailTime)
+		nodeFailTime := nodeM.GetNodeFailDistribution(idx).Draw(em.rng)
+		logrus.Infof("[EventManager.drawFailureEvents] generate node fail eventTime=%+v", nodeFailTime)
 		eventQueue = append(eventQueue, NewEvent(nodeFailTime, EventNodeFail, Node, 0, []int{idx}))
 		if em.EnableTransientFailure {
-			eventQueue = append(eventQueue, NewEvent(nodeM.GetTransitFailDistribution(idx).Draw(), EventNodeTransientFail, Node, 0, []int{idx}))
+			eventQueue = append(eventQueue, NewEvent(nodeM.GetTransitFailDistribution(idx).Draw(em.rng), EventNodeTransientFail, Node, 0, []int{idx}))
 		}
 	}
 
 	if !em.UsePowerOutage && em.EnableTransientFailure {
 		for idx := 0; idx < rackM.GetRackNum(); idx++ {
-			rackFailTime := rackM.GetRackFailDistribution(idx).Draw()
+			rackFailTime := rackM.GetRackFailDistribution(idx).Draw(em.rng)
 			eventQueue = append(eventQueue, NewEvent(rackFailTime, EventRackFail, Rack, 0, []int{idx}))
 		}
 	}
 
-	// TODO correlated failures caused by power outage
+	if em.UsePowerOutage {
+		for idx := 0; idx < dcManager.GetPowerDomainsNum(); idx++ {
+			outageTime := dcManager.GetPowerOutageDistribution().Draw(em.rng)
+			eventQueue = append(eventQueue, NewEvent(outageTime, EventPowerOutage, PowerDomain, 0, []int{idx}))
+		}
+	}
 
-	em.eventQueue = NewEventHeap(eventQueue)
+	return eventQueue
 }
 
-type EventHandlerFunc func(em *EventManager, event *Event, dList []int, bList []float64) (*Event, error)
+// EventHandlerFunc 事件处理函数，dcm 为事件所作用的数据中心实例
+type EventHandlerFunc func(em *EventManager, dcm *data_center.DCManager, event *Event, dList []int, bList []float64) (*Event, error)
 
-func DiskFailHandler(em *EventManager, event *Event, dList []int, bList []float64) (*Event, error) {
+func DiskFailHandler(em *EventManager, dcm *data_center.DCManager, event *Event, dList []int, bList []float64) (*Event, error) {
 	if event.deviceType != Disk {
 		logrus.Error("[DiskFailHandler] deviceType wrong")
 	}
 	failTime := event.eventTime
-	diskM := data_center.GetDCManager().DiskManager()
 	for _, diskId := range dList {
-		if diskM.GetDiskState(diskId) != data_center.DiskStateCrashed {
-			if _, ok := em.delayedRepairDict[diskId]; ok {
-				delete(em.delayedRepairDict, diskId)
-			}
-			diskM.FailDisk(diskId, failTime)
+		em.failDisk(dcm, diskId, failTime)
+	}
+	return NewEvent(failTime, EventDiskFail, Disk, 0, dList), nil
+}
+
+// failDisk 磁盘永久故障并安排修复，已故障的磁盘不做处理。按条带修复时磁盘上的条带加入修复队列，
+// 没有条带的磁盘立即修复
+func (em *EventManager) failDisk(dcm *data_center.DCManager, diskId int, failTime float64) {
+	diskM := dcm.DiskManager()
+	if diskM.GetDiskState(diskId) != data_center.DiskStateCrashed {
+		if _, ok := em.delayedRepairDict[diskId]; ok {
+			delete(em.delayedRepairDict, diskId)
+		}
+		diskM.FailDisk(diskId, failTime)
+		em.degradeStripes(diskId, failTime)
+		if em.traceDiskRepairs[diskId] > 0 {
+			// 按故障记录中的下一次修复完成，不占用修复带宽
+			em.diskRepairStart[diskId] = failTime
+			em.traceRepairing[diskId] = true
+			return
+		}
+		if !em.StripeRepair {
 			em.SetDiskRepair(diskId, failTime)
+			return
+		}
+		em.diskRepairStart[diskId] = failTime
+		if len(diskM.GetDiskStripes(diskId)) == 0 {
+			em.repairDisk(dcm, diskId, failTime)
+			return
 		}
+		em.enqueueStripeRepairs(diskId, failTime)
 	}
-	return NewEvent(failTime, EventDiskFail, Disk, 0, dList), nil
 }
 
-func DiskRepairHandler(em *EventManager, event *Event, dList []int, bList []float64) (*Event, error) {
+func DiskRepairHandler(em *EventManager, dcm *data_center.DCManager, event *Event, dList []int, bList []float64) (*Event, error) {
 	repairTime := event.eventTime
-	dcManager := data_center.GetDCManager()
-	diskM, nodeM, network := dcManager.DiskManager(), dcManager.NodeManager(), dcManager.Network()
+	network := dcm.Network()
+	computedList := dList
+	if em.UseTrace {
+		computedList = em.handleTraceRepairs(dcm, dList, repairTime)
+	}
+	for _, diskId := range computedList {
+		em.repairDisk(dcm, diskId, repairTime)
+	}
+	if network.UseNetwork() && network.Sharing() != data_center.BandwidthExclusive {
+		for _, diskId := range computedList {
+			delete(em.repairEvents, diskId)
+			network.FinishRepair(diskId, repairTime)
+		}
+		em.rescheduleRepairs()
+	} else if network.UseNetwork() {
+		for _, bandwidth := range bList {
+			network.UpdateAvailCrossRackRepairBandwidth(network.GetAvailCrossRackRepairBandwidth() + bandwidth)
+		}
+		for _, diskId := range computedList {
+			network.ReleaseIntraRackRepairBandwidth(dcm.GetRackIdByDiskId(diskId))
+		}
+	}
+	return NewEvent(repairTime, EventDiskRepair, Disk, 0, dList), nil
+}
+
+// handleTraceRepairs 处理 dList 中来自故障记录的修复，返回其余由修复带宽计算的磁盘。
+// 等待记录修复的磁盘修复完成；未故障的磁盘的记录不起作用。按带宽修复的磁盘故障时已没有尚未处理的记录，
+// 因此已故障且不等待记录的磁盘的修复事件均由模拟器计算
+func (em *EventManager) handleTraceRepairs(dcm *data_center.DCManager, dList []int, repairTime float64) []int {
+	diskM := dcm.DiskManager()
+	computedList := make([]int, 0, len(dList))
 	for _, diskId := range dList {
-		if diskM.GetDiskState(diskId) == data_center.DiskStateCrashed {
-			diskM.RepairDisk(diskId, repairTime)
+		if em.traceRepairing[diskId] {
+			delete(em.traceRepairing, diskId)
+			em.traceDiskRepairs[diskId]--
+			em.repairDisk(dcm, diskId, repairTime)
+		} else if diskM.GetDiskState(diskId) != data_center.DiskStateCrashed {
+			em.traceDiskRepairs[diskId]--
+		} else {
+			computedList = append(computedList, diskId)
+		}
+	}
+	return computedList
+}
+
+// repairDisk 磁盘修复完成，节点上的磁盘全部正常时节点也修复完成
+func (em *EventManager) repairDisk(dcm *data_center.DCManager, diskId int, repairTime float64) {
+	diskM, nodeM := dcm.DiskManager(), dcm.NodeManager()
+	if start, ok := em.diskRepairStart[diskId]; ok {
+		em.diskRepairTimeSum += repairTime - start
+		em.diskRepairNum++
+		delete(em.diskRepairStart, diskId)
+	}
+	if diskM.GetDiskState(diskId) == data_center.DiskStateCrashed {
+		diskM.RepairDisk(diskId, repairTime)
+		for _, stripeId := range diskM.GetDiskStripes(diskId) {
+			em.healStripe(stripeId, repairTime)
+		}
+		if !em.UseTrace {
 			em.SetDiskFail(diskId, repairTime)
 		}
-		nodeId := dcManager.GetNodeIdByDiskId(diskId)
-		if nodeM.GetNodeState(nodeId) == data_center.NodeStateCrashed {
-			allDiskOK := true
-			for offset := 0; offset < dcManager.GetDisksPerNode(); offset++ {
-				if diskM.GetDiskState(dcManager.GetDiskIdByNodeId(nodeId, offset)) != data_center.DiskStateNormal {
-					allDiskOK = false
-				}
-			}
-			if allDiskOK {
-				nodeM.RepairNode(nodeId)
-				if !em.UseTrace {
-					em.SetNodeFail(nodeId, repairTime)
-				}
+	}
+	nodeId := dcm.GetNodeIdByDiskId(diskId)
+	if nodeM.GetNodeState(nodeId) == data_center.NodeStateCrashed {
+		allDiskOK := true
+		for offset := 0; offset < dcm.GetDisksPerNode(); offset++ {
+			if diskM.GetDiskState(dcm.GetDiskIdByNodeId(nodeId, offset)) != data_center.DiskStateNormal {
+				allDiskOK = false
 			}
 		}
-	}
-	if network.UseNetwork() {
-		for _, bandwidth := range bList {
-			network.UpdateAvailCrossRackRepairBandwidth(network.GetAvailCrossRackRepairBandwidth() + bandwidth)
+		if allDiskOK {
+			nodeM.RepairNode(nodeId)
+			if !em.UseTrace {
+				em.SetNodeFail(nodeId, repairTime)
+			}
 		}
 	}
-	return NewEvent(repairTime, EventDiskRepair, Disk, 0, dList), nil
 }
 
-func NodeFailHandler(em *EventManager, event *Event, dList []int, bList []float64) (*Event, error) {
+func NodeFailHandler(em *EventManager, dcm *data_center.DCManager, event *Event, dList []int, bList []float64) (*Event, error) {
 	failTime := event.eventTime
 	failedDiskList := make([]int, 0)
-	dcManager := data_center.GetDCManager()
-	diskM, nodeM := dcManager.DiskManager(), dcManager.NodeManager()
 	for _, nodeId := range dList {
-		if nodeM.GetNodeState(nodeId) != data_center.NodeStateCrashed {
-			nodeM.FailNode(nodeId, failTime)
-		}
-		for offset := 0; offset < dcManager.GetDisksPerNode(); offset++ {
-			diskId := dcManager.GetDiskIdByNodeId(nodeId, offset)
-			failedDiskList = append(failedDiskList, diskId)
-			if diskM.GetDiskState(diskId) != data_center.DiskStateCrashed {
-				// TODO here some questions
-				if _, ok := em.delayedRepairDict[diskId]; ok {
-					delete(em.delayedRepairDict, diskId)
-				}
-				diskM.FailDisk(diskId, failTime)
-				em.SetDiskRepair(diskId, failTime)
-			}
-		}
+		failedDiskList = append(failedDiskList, em.failNode(dcm, nodeId, failTime)...)
 	}
 	return NewEvent(failTime, EventNodeFail, Disk, 0, failedDiskList), nil
 }
 
-func NodeTransientFailHandler(em *EventManager, event *Event, dList []int, bList []float64) (*Event, error) {
+// failNode 节点永久故障，节点上的磁盘全部故障并安排修复，返回节点上的磁盘
+func (em *EventManager) failNode(dcm *data_center.DCManager, nodeId int, failTime float64) []int {
+	nodeM := dcm.NodeManager()
+	if nodeM.GetNodeState(nodeId) != data_center.NodeStateCrashed {
+		nodeM.FailNode(nodeId, failTime)
+	}
+	diskList := make([]int, 0, dcm.GetDisksPerNode())
+	for offset := 0; offset < dcm.GetDisksPerNode(); offset++ {
+		diskId := dcm.GetDiskIdByNodeId(nodeId, offset)
+		diskList = append(diskList, diskId)
+		// TODO here some questions
+		em.failDisk(dcm, diskId, failTime)
+	}
+	return diskList
+}
+
+func NodeTransientFailHandler(em *EventManager, dcm *data_center.DCManager, event *Event, dList []int, bList []float64) (*Event, error) {
 	failTime := event.eventTime
-	dcManager := data_center.GetDCManager()
-	diskM, nodeM := dcManager.DiskManager(), dcManager.NodeManager()
+	diskM, nodeM := dcm.DiskManager(), dcm.NodeManager()
 	for _, nodeId := range dList {
 		if nodeM.GetNodeState(nodeId) == data_center.NodeStateNormal {
 			nodeM.OfflineNode(nodeId)
-			for offset := 0; offset < dcManager.GetDisksPerNode(); offset++ {
-				diskId := dcManager.GetDiskIdByNodeId(nodeId, offset)
+			for offset := 0; offset < dcm.GetDisksPerNode(); offset++ {
+				diskId := dcm.GetDiskIdByNodeId(nodeId, offset)
 				if diskM.GetDiskState(diskId) == data_center.DiskStateNormal {
 					diskM.OfflineDisk(diskId, failTime)
 				}
 			}
 		}
-		if em.UseTrace {
+		if !em.UseTrace {
 			em.SetNodeTransientRepair(nodeId, failTime)
 		}
 	}
 	return NewEvent(failTime, EventNodeTransientFail, Node, 0, nil), nil
 }
 
-func NodeTransientRepairHandler(em *EventManager, event *Event, dList []int, bList []float64) (*Event, error) {
-	dcManager := data_center.GetDCManager()
-	diskM, nodeM := dcManager.DiskManager(), dcManager.NodeManager()
+func NodeTransientRepairHandler(em *EventManager, dcm *data_center.DCManager, event *Event, dList []int, bList []float64) (*Event, error) {
+	diskM, nodeM := dcm.DiskManager(), dcm.NodeManager()
 	repairTime := event.eventTime
 	for _, nodeId := range dList {
 		if nodeM.GetNodeState(nodeId) == data_center.NodeStateUnavailable {
 			nodeM.OnlineNode(nodeId)
-			for offset := 0; offset < dcManager.GetDisksPerNode(); offset++ {
-				diskId := dcManager.GetDiskIdByNodeId(nodeId, offset)
+			for offset := 0; offset < dcm.GetDisksPerNode(); offset++ {
+				diskId := dcm.GetDiskIdByNodeId(nodeId, offset)
 				if diskM.GetDiskState(diskId) == data_center.DiskStateUnavailable {
 					diskM.OnlineDisk(diskId, repairTime)
 				}
@@ -284,46 +470,53 @@ func NodeTransientRepairHandler(em *EventManager, event *Event, dList []int, bLi
 	return NewEvent(repairTime, EventNodeTransientRepair, Node, 0, nil), nil
 }
 
-func RackFailHandler(em *EventManager, event *Event, dList []int, bList []float64) (*Event, error) {
+func RackFailHandler(em *EventManager, dcm *data_center.DCManager, event *Event, dList []int, bList []float64) (*Event, error) {
 	failTime := event.eventTime
-	dcManager := data_center.GetDCManager()
-	diskM, nodeM, rackM := dcManager.DiskManager(), dcManager.NodeManager(), dcManager.RackManager()
 	for _, rackId := range dList {
-		if rackM.GetRackState(rackId) == data_center.RackStateNormal {
-			rackM.FailRack(rackId)
-			for offset := 0; offset < dcManager.GetNodesPerRack(); offset++ {
-				nodeId := dcManager.GetNodeIdByRackId(rackId, offset)
-				if nodeM.GetNodeState(nodeId) == data_center.NodeStateNormal {
-					nodeM.OfflineNode(nodeId)
-					for diskOffset := 0; diskOffset < dcManager.GetDisksPerNode(); diskOffset++ {
-						diskId := dcManager.GetDiskIdByNodeId(nodeId, diskOffset)
-						if diskM.GetDiskState(diskId) == data_center.DiskStateNormal {
-							diskM.OfflineDisk(diskId, failTime)
-						}
-					}
-				}
-			}
-		}
-		if !em.UsePowerOutage {
+		em.offlineRack(dcm, rackId, failTime)
+		if !em.UsePowerOutage && !em.UseTrace {
 			em.SetRackRepair(rackId, failTime)
 		}
 	}
 	return NewEvent(failTime, EventRackFail, Rack, 0, nil), nil
 }
 
-func RackRepairHandler(em *EventManager, event *Event, dList []int, bList []float64) (*Event, error) {
+// offlineRack 机架及其正常的节点与磁盘离线，返回离线的磁盘数
+func (em *EventManager) offlineRack(dcm *data_center.DCManager, rackId int, failTime float64) int {
+	diskM, nodeM, rackM := dcm.DiskManager(), dcm.NodeManager(), dcm.RackManager()
+	if rackM.GetRackState(rackId) != data_center.RackStateNormal {
+		return 0
+	}
+	offlineDiskNum := 0
+	rackM.FailRack(rackId)
+	for offset := 0; offset < dcm.GetNodesPerRack(); offset++ {
+		nodeId := dcm.GetNodeIdByRackId(rackId, offset)
+		if nodeM.GetNodeState(nodeId) == data_center.NodeStateNormal {
+			nodeM.OfflineNode(nodeId)
+			for diskOffset := 0; diskOffset < dcm.GetDisksPerNode(); diskOffset++ {
+				diskId := dcm.GetDiskIdByNodeId(nodeId, diskOffset)
+				if diskM.GetDiskState(diskId) == data_center.DiskStateNormal {
+					diskM.OfflineDisk(diskId, failTime)
+					offlineDiskNum++
+				}
+			}
+		}
+	}
+	return offlineDiskNum
+}
+
+func RackRepairHandler(em *EventManager, dcm *data_center.DCManager, event *Event, dList []int, bList []float64) (*Event, error) {
 	repairTime := event.eventTime
-	dcManager := data_center.GetDCManager()
-	diskM, nodeM, rackM := dcManager.DiskManager(), dcManager.NodeManager(), dcManager.RackManager()
+	diskM, nodeM, rackM := dcm.DiskManager(), dcm.NodeManager(), dcm.RackManager()
 	for _, rackId := range dList {
 		if rackM.GetRackState(rackId) == data_center.RackStateUnavailable {
 			rackM.RepairRack(rackId)
-			for offset := 0; offset < dcManager.GetNodesPerRack(); offset++ {
-				nodeId := dcManager.GetNodeIdByRackId(rackId, offset)
+			for offset := 0; offset < dcm.GetNodesPerRack(); offset++ {
+				nodeId := dcm.GetNodeIdByRackId(rackId, offset)
 				if nodeM.GetNodeState(nodeId) == data_center.NodeStateUnavailable {
 					nodeM.OnlineNode(nodeId)
-					for diskOffset := 0; diskOffset < dcManager.GetDisksPerNode(); diskOffset++ {
-						diskId := dcManager.GetDiskIdByNodeId(nodeId, diskOffset)
+					for diskOffset := 0; diskOffset < dcm.GetDisksPerNode(); diskOffset++ {
+						diskId := dcm.GetDiskIdByNodeId(nodeId, diskOffset)
 						if diskM.GetDiskState(diskId) == data_center.DiskStateUnavailable {
 							diskM.OnlineDisk(diskId, repairTime)
 						}
@@ -331,31 +524,57 @@ func RackRepairHandler(em *EventManager, event *Event, dList []int, bList []floa
 				}
 			}
 		}
-		if !em.UsePowerOutage {
+		if !em.UsePowerOutage && !em.UseTrace {
 			em.SetRackFail(rackId, repairTime)
 		}
 	}
-	return NewEvent(repairTime, EventRackFail, Rack, 0, nil), nil
+	return NewEvent(repairTime, EventRackRepair, Rack, 0, nil), nil
 }
 
 // HandleNextEvent 根据事件队列进行相应的事件操作
 func (em *EventManager) HandleNextEvent(currentTime float64) *EventExecResult {
 	var err error
-	dcManager := data_center.GetDCManager()
+	dcManager := em.dcManager
 	em.checkDelayedRepairDict()
 	em.checkWaitQueue(currentTime)
-	event := em.eventQueue.Get()
-	deviceList, repairBandwidthList := em.popSameEvent(event)
+	em.checkStripeRepairQueue(currentTime)
+	var event *Event
+	var deviceList []int
+	var repairBandwidthList []float64
+	if em.replay != nil {
+		event, deviceList, repairBandwidthList = em.nextReplayEvent()
+	} else {
+		event = em.eventQueue.Get()
+		for event.canceled {
+			event = em.eventQueue.Get()
+		}
+		deviceList, repairBandwidthList = em.popSameEvent(event)
+	}
 	if event.eventTime > dcManager.GetMissionTime() {
-		eventLogger.Infof("[EventManager.HandleNextEvent] next event timeout, time=%+v", event.eventTime)
+		logrus.Infof("[EventManager.HandleNextEvent] next event timeout, time=%+v", event.eventTime)
+		if em.recorder != nil {
+			// 迭代结束的时刻决定 BlockedRatio 等按时间平均的指标，一并记录以便回放时得到相同的结果
+			em.recorder(&EventRecord{Time: event.eventTime, Event: "MissionEnd", Devices: []int{}, Changes: []StateChange{}})
+		}
 		return &EventExecResult{EventTime: event.eventTime, EventType: EventMissionEnd}
 	}
 	if handleFunc, ok := EventHandlerFuncMap[event.eventType]; ok {
-		eventLogger.Infof("[EventManager.HandleNextEvent] receive event, time=%+v, type=%s, deviceList=%+v", event.eventTime, event.EventType(), deviceList)
-		event, err = handleFunc(em, event, deviceList, repairBandwidthList)
+		logrus.Infof("[EventManager.HandleNextEvent] receive event, time=%+v, type=%s, deviceList=%+v", event.eventTime, event.EventType(), deviceList)
+		var record *EventRecord
+		if em.recorder != nil {
+			record = &EventRecord{Time: event.eventTime, Event: event.EventType(), Devices: append([]int(nil), deviceList...),
+				Bandwidth: append([]float64(nil), repairBandwidthList...)}
+			em.stateBefore = em.deviceStates(em.stateBefore)
+		}
+		event, err = handleFunc(em, em.dcManager, event, deviceList, repairBandwidthList)
 		if err != nil {
 			logrus.Error("[EventManager.GetNextEvent] EventHandlerFuncMap error")
 		}
+		if record != nil {
+			em.stateAfter = em.deviceStates(em.stateAfter)
+			record.Changes = em.stateChanges(em.stateBefore, em.stateAfter)
+			em.recorder(record)
+		}
 		return &EventExecResult{EventTime: event.eventTime, EventType: event.eventType}
 	} else {
 		logrus.Error("[EventManager.GetNextEvent] HandlerFunc missing")
@@ -369,23 +588,11 @@ func (em *EventManager) checkDelayedRepairDict() {
 		return
 	}
 	diskToRemove := make([]int, 0)
-	dcManager := data_center.GetDCManager()
-	diskManager := dcManager.DiskManager()
+	code := em.dcManager.ErasureCode()
 	for diskIdKey, stripeIdList := range em.delayedRepairDict {
 		newDictValue := make([]int, 0)
 		for _, stripeId := range stripeIdList {
-			var repairDelay bool
-			var numUnavailingChunk int
-			for _, diskId := range dcManager.GetStripesLocation(stripeId) {
-				if diskManager.GetDiskState(diskId) != data_center.DiskStateNormal {
-					numUnavailingChunk++
-				}
-				if numUnavailingChunk > dcManager.ErasureCodeConf().N-dcManager.ErasureCodeConf().K {
-					repairDelay = true
-					break
-				}
-			}
-			if repairDelay {
+			if !code.IsRecoverable(em.unavailableChunkIdx(stripeId)) {
 				newDictValue = append(newDictValue, stripeId)
 			}
 		}
@@ -400,25 +607,38 @@ func (em *EventManager) checkDelayedRepairDict() {
 	}
 }
 
+// unavailableChunkIdx 条带中位于不可用磁盘上的块下标
+func (em *EventManager) unavailableChunkIdx(stripeId int) []int {
+	diskM := em.dcManager.DiskManager()
+	unavailableIdxList := make([]int, 0)
+	for idx, diskId := range em.dcManager.GetStripesLocation(stripeId) {
+		if diskM.GetDiskState(diskId) != data_center.DiskStateNormal {
+			unavailableIdxList = append(unavailableIdxList, idx)
+		}
+	}
+	return unavailableIdxList
+}
+
 func (em *EventManager) checkWaitQueue(currentTime float64) {
 	if len(*em.waitQueue) == 0 {
 		return
 	}
-	dcManager := data_center.GetDCManager()
+	dcManager := em.dcManager
 	networkM := dcManager.Network()
 	rackManager := dcManager.RackManager()
 	diskId := (*em.waitQueue)[0].deviceIdList[0]
 	rackId := dcManager.GetRackIdByDiskId(diskId)
-	if networkM.UseNetwork() && networkM.GetAvailCrossRackRepairBandwidth() != 0 &&
-		networkM.GetAvailIntraRackRepairBandwidth(rackId) != 0 &&
-		rackManager.GetRackState(rackId) == data_center.RackStateNormal {
+	// 不模拟网络时只等待机架恢复
+	bandwidthReady := !networkM.UseNetwork() ||
+		(networkM.GetAvailCrossRackRepairBandwidth() != 0 && networkM.GetAvailIntraRackRepairBandwidth(rackId) != 0)
+	if bandwidthReady && rackManager.GetRackState(rackId) == data_center.RackStateNormal {
 		heap.Pop(em.waitQueue)
 		em.SetDiskRepair(diskId, currentTime)
 	}
 }
 
 func (em *EventManager) popSameEvent(event *Event) ([]int, []float64) {
-	dcManager := data_center.GetDCManager()
+	dcManager := em.dcManager
 	networkM := dcManager.Network()
 	deviceIdList := make([]int, 0)
 	deviceIdList = append(deviceIdList, event.deviceIdList...)
@@ -429,6 +649,9 @@ func (em *EventManager) popSameEvent(event *Event) ([]int, []float64) {
 	for len(*em.eventQueue) > 0 && (*em.eventQueue)[0].eventTime == event.eventTime &&
 		(*em.eventQueue)[0].eventType == event.eventType {
 		event = em.eventQueue.Get()
+		if event.canceled {
+			continue
+		}
 		deviceIdList = append(deviceIdList, event.deviceIdList...)
 		if networkM.UseNetwork() && event.eventType == EventDiskRepair {
 			repairBandwidthList = append(repairBandwidthList, event.bandwidth)
@@ -438,105 +661,212 @@ func (em *EventManager) popSameEvent(event *Event) ([]int, []float64) {
 }
 
 func (em *EventManager) SetDiskRepair(diskId int, currentTime float64) {
-	dcManager := data_center.GetDCManager()
+	dcManager := em.dcManager
 	networkM, rackM, diskM := dcManager.Network(), dcManager.RackManager(), dcManager.DiskManager()
 	rackId := dcManager.GetRackIdByDiskId(diskId)
-	if networkM.GetAvailCrossRackRepairBandwidth() == 0 || rackM.GetRackState(rackId) != data_center.RackStateNormal {
+	if (networkM.UseNetwork() && (networkM.GetAvailCrossRackRepairBandwidth() == 0 || networkM.GetAvailIntraRackRepairBandwidth(rackId) == 0)) ||
+		rackM.GetRackState(rackId) != data_center.RackStateNormal {
 		heap.Push(em.waitQueue, NewEvent(currentTime, EventDiskFail, Disk, 0, []int{diskId}))
 		return
 	}
-	crossRackDownload := 0
-	// TODO check logic here
+	// 修复读取的块中与被修复磁盘位于同一机架的占用机架内带宽，其余占用跨机架带宽
+	crossRackDownload, intraRackDownload := 0.0, 0.0
+	code := dcManager.ErasureCode()
 	stripeIdList := diskM.GetDiskStripes(diskId)
 	em.repairStripesNum += len(stripeIdList)
 	var stripesToDelay []int
+	maxFailedChunks := 1
 	// 针对这一个块上的所有条带，均需要进行修复
 	for _, stripeId := range stripeIdList {
-		numOfFailedChunks, numOfAliveChunkInSameRack, numOfUnavailingChunk := 0, 0, 0
-		for _, diskNum := range dcManager.GetStripesLocation(stripeId) {
-			if diskM.GetDiskState(diskNum) != data_center.DiskStateNormal {
-				numOfUnavailingChunk++
+		stripeLocation := dcManager.GetStripesLocation(stripeId)
+		numOfFailedChunks, numOfAliveChunkInSameRack, repairIdx := 0, 0, -1
+		// 可用块按读取代价排列，同一机架的在前
+		sameRackAlive, otherAlive := make([]int, 0), make([]int, 0)
+		for idx, diskNum := range stripeLocation {
+			if diskNum == diskId {
+				repairIdx = idx
 			}
-			switch dcManager.ErasureCodeConf().CodeType {
-			case data_center.RS:
-				if diskM.GetDiskState(diskNum) == data_center.DiskStateCrashed {
-					numOfFailedChunks++
-				} else if dcManager.GetRackIdByDiskId(diskNum) == rackId {
-					numOfAliveChunkInSameRack++
-				}
-			case data_center.LRC:
-				// TODO
+			state := diskM.GetDiskState(diskNum)
+			if state == data_center.DiskStateCrashed {
+				numOfFailedChunks++
+				continue
+			}
+			sameRack := dcManager.GetRackIdByDiskId(diskNum) == rackId
+			if sameRack {
+				numOfAliveChunkInSameRack++
+			}
+			if state != data_center.DiskStateNormal || diskNum == diskId {
+				continue
+			}
+			if sameRack {
+				sameRackAlive = append(sameRackAlive, idx)
+			} else {
+				otherAlive = append(otherAlive, idx)
 			}
 		}
 		if numOfFailedChunks == 1 {
 			em.repairStripesSingleChunkNum++
 		}
+		if numOfFailedChunks > maxFailedChunks {
+			maxFailedChunks = numOfFailedChunks
+		}
 		// 无法完成纠删码要求的修复
-		if numOfUnavailingChunk > (dcManager.ErasureCodeConf().N - dcManager.ErasureCodeConf().K) {
+		if !code.IsRecoverable(em.unavailableChunkIdx(stripeId)) {
 			stripesToDelay = append(stripesToDelay, stripeId)
 		}
-		switch dcManager.ErasureCodeConf().CodeType {
-		case data_center.RS:
-			if numOfAliveChunkInSameRack < dcManager.ErasureCodeConf().K {
-				crossRackDownload += dcManager.ErasureCodeConf().K - numOfAliveChunkInSameRack
+		helpers, helperRatio := code.RepairPlan(repairIdx, append(sameRackAlive, otherAlive...))
+		if helpers == nil {
+			// 可用块不足，按读取 K 个块估计修复流量，条带等待延迟修复
+			intra := numOfAliveChunkInSameRack
+			if intra > code.K() {
+				intra = code.K()
+			}
+			em.repairReadChunkNum += float64(code.K())
+			em.repairDiskReadChunkNum += float64(code.K())
+			intraRackDownload += float64(intra)
+			crossRackDownload += float64(code.K() - intra)
+			continue
+		}
+		if len(helpers) < code.K() {
+			em.localRepairStripesNum++
+		}
+		em.repairReadChunkNum += float64(len(helpers)) * helperRatio
+		em.repairDiskReadChunkNum += float64(len(helpers)) * data_center.HelperReadRatio(code, helperRatio)
+		for _, idx := range helpers {
+			if dcManager.GetRackIdByDiskId(stripeLocation[idx]) == rackId {
+				intraRackDownload += helperRatio
+			} else {
+				crossRackDownload += helperRatio
 			}
-		case data_center.LRC:
 		}
 	}
-	repairBandwidth := networkM.GetAvailCrossRackRepairBandwidth()
-	networkM.UpdateAvailCrossRackRepairBandwidth(0)
-	repairTime := float64(crossRackDownload*dcManager.GetChunkSize()) / repairBandwidth
-	repairTime /= float64(3600)
-	logrus.Infof("[EventManager.SetDiskRepair] repair time: %+v", repairTime)
 	if len(stripesToDelay) > 0 {
 		em.delayedStripesNum += len(stripesToDelay)
 		em.delayedRepairDict[diskId] = stripesToDelay
 	}
+	em.diskRepairStart[diskId] = currentTime
+	if networkM.UseNetwork() && networkM.Sharing() != data_center.BandwidthExclusive {
+		// 与其他修复共享带宽，权重为磁盘上最危急的条带失效的块数
+		chunkSize := float64(dcManager.GetChunkSize())
+		networkM.StartRepair(diskId, rackId, crossRackDownload*chunkSize, intraRackDownload*chunkSize, float64(maxFailedChunks), currentTime)
+		logrus.Infof("[EventManager.SetDiskRepair] share bandwidth, disk=%d, weight=%d", diskId, maxFailedChunks)
+		em.rescheduleRepairs()
+		return
+	}
+	var repairBandwidth, repairTime float64
+	if networkM.UseNetwork() {
+		repairBandwidth = networkM.GetAvailCrossRackRepairBandwidth()
+		intraRackBandwidth := networkM.GetAvailIntraRackRepairBandwidth(rackId)
+		networkM.UpdateAvailCrossRackRepairBandwidth(0)
+		networkM.UpdateAvailIntraRackRepairBandwidth(rackId, 0)
+		// 跨机架与机架内的读取同时进行，修复时间取两者中较长的
+		repairTime = crossRackDownload * float64(dcManager.GetChunkSize()) / repairBandwidth
+		if intraRackDownload > 0 {
+			repairTime = math.Max(repairTime, intraRackDownload*float64(dcManager.GetChunkSize())/intraRackBandwidth)
+		}
+		repairTime /= float64(3600)
+	} else {
+		// 不模拟网络时修复时间服从磁盘修复分布
+		repairTime = diskM.GetDiskRepairDistribution(diskId).Draw(em.rng)
+	}
+	logrus.Infof("[EventManager.SetDiskRepair] repair time: %+v", repairTime)
 
 	// TODO repair bandwidth
 	heap.Push(em.eventQueue, NewEvent(repairTime+currentTime, EventDiskRepair, Disk, repairBandwidth, []int{diskId}))
 }
 
+// rescheduleRepairs 共享带宽时有修复开始或结束后，按新的带宽分配重新推算各修复完成的时刻，
+// 完成时刻改变的修复作废原来的完成事件。磁盘修复以磁盘编号、条带修复以 stripeFlowId 为修复编号
+func (em *EventManager) rescheduleRepairs() {
+	times := em.dcManager.Network().RepairCompletionTimes()
+	flowIdList := make([]int, 0, len(times))
+	for flowId := range times {
+		flowIdList = append(flowIdList, flowId)
+	}
+	sort.Ints(flowIdList)
+	for _, flowId := range flowIdList {
+		oldEvent := em.repairEvents[flowId]
+		if oldEvent != nil {
+			if oldEvent.eventTime == times[flowId] {
+				continue
+			}
+			oldEvent.canceled = true
+		}
+		event := NewEvent(times[flowId], EventDiskRepair, Disk, 0, []int{flowId})
+		if flowId < 0 {
+			event = NewEvent(times[flowId], EventStripeRepair, Stripe, 0, []int{stripeIdOfFlow(flowId)})
+		}
+		em.repairEvents[flowId] = event
+		heap.Push(em.eventQueue, event)
+	}
+}
+
 func (em *EventManager) SetDiskFail(diskId int, currentTime float64) {
-	dcManager := data_center.GetDCManager()
+	dcManager := em.dcManager
 	diskM := dcManager.DiskManager()
-	heap.Push(em.eventQueue, NewEvent(diskM.GetDiskFailDistribution(diskId).Draw()+currentTime,
+	heap.Push(em.eventQueue, NewEvent(diskM.GetDiskFailDistribution(diskId).Draw(em.rng)+currentTime,
 		EventDiskFail, Disk, 0, []int{diskId}))
 }
 
 func (em *EventManager) SetNodeTransientRepair(nodeId int, currentTime float64) {
-	dcManager := data_center.GetDCManager()
+	dcManager := em.dcManager
 	nodeM := dcManager.NodeManager()
-	heap.Push(em.eventQueue, NewEvent(nodeM.GetTransitRepairDistribution(nodeId).Draw()+currentTime,
+	heap.Push(em.eventQueue, NewEvent(nodeM.GetTransitRepairDistribution(nodeId).Draw(em.rng)+currentTime,
 		EventNodeTransientRepair, Node, 0, []int{nodeId}))
 }
 
 func (em *EventManager) SetNodeFail(nodeId int, currentTime float64) {
-	dcManager := data_center.GetDCManager()
+	dcManager := em.dcManager
 	nodeM := dcManager.NodeManager()
-	heap.Push(em.eventQueue, NewEvent(nodeM.GetNodeFailDistribution(nodeId).Draw()+currentTime,
+	heap.Push(em.eventQueue, NewEvent(nodeM.GetNodeFailDistribution(nodeId).Draw(em.rng)+currentTime,
 		EventNodeFail, Node, 0, []int{nodeId}))
 }
 
 func (em *EventManager) SetNodeTransientFail(nodeId int, currentTime float64) {
-	dcManager := data_center.GetDCManager()
+	dcManager := em.dcManager
 	nodeM := dcManager.NodeManager()
-	heap.Push(em.eventQueue, NewEvent(nodeM.GetTransitFailDistribution(nodeId).Draw()+currentTime,
-		EventNodeFail, Node, 0, []int{nodeId}))
+	heap.Push(em.eventQueue, NewEvent(nodeM.GetTransitFailDistribution(nodeId).Draw(em.rng)+currentTime,
+		EventNodeTransientFail, Node, 0, []int{nodeId}))
 }
 
 func (em *EventManager) SetRackRepair(rackId int, currentTime float64) {
-	dcManager := data_center.GetDCManager()
+	dcManager := em.dcManager
 	rackM := dcManager.RackManager()
-	heap.Push(em.eventQueue, NewEvent(rackM.GetRackRepairDistribution(rackId).Draw()+currentTime,
+	heap.Push(em.eventQueue, NewEvent(rackM.GetRackRepairDistribution(rackId).Draw(em.rng)+currentTime,
 		EventRackRepair, Rack, 0, []int{rackId}))
 }
 
 func (em *EventManager) SetRackFail(rackId int, currentTime float64) {
-	dcManager := data_center.GetDCManager()
+	dcManager := em.dcManager
 	rackM := dcManager.RackManager()
-	heap.Push(em.eventQueue, NewEvent(rackM.GetRackFailDistribution(rackId).Draw()+currentTime,
-		EventRackRepair, Rack, 0, []int{rackId}))
+	heap.Push(em.eventQueue, NewEvent(rackM.GetRackFailDistribution(rackId).Draw(em.rng)+currentTime,
+		EventRackFail, Rack, 0, []int{rackId}))
+}
+
+// GetMeanDiskRepairTime 已完成修复的磁盘从开始修复到完成的平均用时（小时），没有完成的修复时为 0
+func (em *EventManager) GetMeanDiskRepairTime() float64 {
+	if em.diskRepairNum == 0 {
+		return 0
+	}
+	return em.diskRepairTimeSum / float64(em.diskRepairNum)
+}
+
+// GetLocalRepairRatio 读取少于 K 个块完成修复（如 LRC 局部修复）的条带占修复条带的比例
+func (em *EventManager) GetLocalRepairRatio() float64 {
+	if em.repairStripesNum != 0 {
+		return float64(em.localRepairStripesNum) / float64(em.repairStripesNum)
+	}
+	return 0
+}
+
+// GetRepairReadChunkNum 修复读取的数据量，以块为单位
+func (em *EventManager) GetRepairReadChunkNum() float64 {
+	return em.repairReadChunkNum
+}
+
+// GetRepairDiskReadChunkNum 修复时协助块从磁盘读取的数据量，以块为单位
+func (em *EventManager) GetRepairDiskReadChunkNum() float64 {
+	return em.repairDiskReadChunkNum
 }
 
 func (em *EventManager) GetSingleChunkRepairRatio() float64 {
@@ -0,0 +1,86 @@
+package event_trigger
+
+import (
+	"ECDC_SIM/internal/pkg/data_center"
+	"ECDC_SIM/internal/pkg/util"
+	"github.com/gogap/logrus"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+// newTestEventManager 4 个机架、每机架 2 个节点的数据中心，事件队列为空
+func newTestEventManager(t *testing.T, rConf *RunningConfig) (*EventManager, *data_center.DCManager) {
+	t.Helper()
+	logrus.SetOutput(io.Discard)
+	dcConf := &data_center.DCConf{
+		RacksNum:     4,
+		StripesNum:   8,
+		DisksPerNode: 1,
+		DiskCapacity: 1024,
+		NodesPerRack: 2,
+		ChunkNum:     8 * 3,
+		ChunkSize:    256,
+		NFailD:       util.NewWeibull(1, 9125, 0),
+		NTFailD:      util.NewWeibull(1, 2890.8, 0),
+		NTRepairD:    util.NewWeibull(1, 0.25, 0),
+		DFailD:       util.NewWeibull(1.12, 8760, 0),
+		RFailD:       util.NewWeibull(1, 87600, 0),
+		RRepairD:     util.NewWeibull(1, 24, 10),
+		MissionTime:  87600,
+	}
+	ecConf := &data_center.ErasureCodeConf{CodeType: data_center.RS, ChunkPlaceType: data_center.FLAT, N: 3, K: 2}
+	dcm := data_center.NewDCManager(dcConf, ecConf)
+	r := rand.New(rand.NewSource(1))
+	dcm.Reset(r)
+	em := NewEventManager(rConf, dcm)
+	em.ResetEventManager(r)
+	em.eventQueue = NewEventHeap(make([]*Event, 0))
+	return em, dcm
+}
+
+func TestEventHandlers(t *testing.T) {
+	tests := []struct {
+		name       string
+		useTrace   bool
+		event      *Event
+		handler    EventHandlerFunc
+		wantResult EventType
+		wantQueue  []EventType // 处理后加入事件队列的事件
+	}{
+		// 瞬时故障后安排瞬时修复，瞬时修复后安排下一次瞬时故障
+		{name: "TestNodeTransientFail", event: NewEvent(10, EventNodeTransientFail, Node, 0, []int{0}), handler: NodeTransientFailHandler,
+			wantResult: EventNodeTransientFail, wantQueue: []EventType{EventNodeTransientRepair}},
+		{name: "TestNodeTransientRepair", event: NewEvent(10, EventNodeTransientRepair, Node, 0, []int{0}), handler: NodeTransientRepairHandler,
+			wantResult: EventNodeTransientRepair, wantQueue: []EventType{EventNodeTransientFail}},
+		// 回放故障记录时修复由记录给出
+		{name: "TestNodeTransientFailTrace", useTrace: true, event: NewEvent(10, EventNodeTransientFail, Node, 0, []int{0}),
+			handler: NodeTransientFailHandler, wantResult: EventNodeTransientFail},
+		{name: "TestRackFail", event: NewEvent(10, EventRackFail, Rack, 0, []int{1}), handler: RackFailHandler,
+			wantResult: EventRackFail, wantQueue: []EventType{EventRackRepair}},
+		{name: "TestRackRepair", event: NewEvent(10, EventRackRepair, Rack, 0, []int{1}), handler: RackRepairHandler,
+			wantResult: EventRackRepair, wantQueue: []EventType{EventRackFail}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			em, dcm := newTestEventManager(t, &RunningConfig{UseTrace: tt.useTrace, Trace: &Trace{}})
+			result, err := tt.handler(em, dcm, tt.event, tt.event.deviceIdList, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.eventType != tt.wantResult {
+				t.Errorf("handler result = %s, want %s", result.EventType(), NewEvent(0, tt.wantResult, 0, 0, nil).EventType())
+			}
+			if em.eventQueue.Len() != len(tt.wantQueue) {
+				t.Fatalf("handler queued %d events, want %d", em.eventQueue.Len(), len(tt.wantQueue))
+			}
+			for _, want := range tt.wantQueue {
+				next := em.eventQueue.Get()
+				if next.eventType != want || next.eventTime <= tt.event.eventTime {
+					t.Errorf("queued %s at %g, want %s after %g", next.EventType(), next.eventTime,
+						NewEvent(0, want, 0, 0, nil).EventType(), tt.event.eventTime)
+				}
+			}
+		})
+	}
+}
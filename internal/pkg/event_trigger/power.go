@@ -0,0 +1,116 @@
+package event_trigger
+
+import (
+	"ECDC_SIM/internal/pkg/data_center"
+	"container/heap"
+	"math/rand"
+)
+
+// powerOutage 一次正在进行的供电域断电
+type powerOutage struct {
+	start          float64
+	offlineDiskNum int // 因本次断电离线的磁盘数，已因其它原因离线或故障的磁盘不计入
+}
+
+func (em *EventManager) isValidPowerDomain(domainId int) bool {
+	return domainId >= 0 && domainId < len(em.powerOff)
+}
+
+// PowerOutageHandler 供电域断电，域内全部机架、节点与磁盘同时离线，已断电的供电域不做处理
+func PowerOutageHandler(em *EventManager, dcm *data_center.DCManager, event *Event, dList []int, bList []float64) (*Event, error) {
+	failTime := event.eventTime
+	for _, domainId := range dList {
+		if !em.isValidPowerDomain(domainId) || em.powerOff[domainId] != nil {
+			continue
+		}
+		outage := &powerOutage{start: failTime}
+		for _, rackId := range dcm.GetRackIdsByPowerDomain(domainId) {
+			outage.offlineDiskNum += em.offlineRack(dcm, rackId, failTime)
+		}
+		em.powerOff[domainId] = outage
+		em.powerOutageNum++
+		if !em.UseTrace {
+			em.SetPowerRestore(domainId, failTime)
+		}
+	}
+	return NewEvent(failTime, EventPowerOutage, PowerDomain, 0, dList), nil
+}
+
+// PowerRestoreHandler 供电域恢复供电，离线的设备重新上线，其中按 PowerUpFailure 的比例抽样的节点与磁盘永久故障
+func PowerRestoreHandler(em *EventManager, dcm *data_center.DCManager, event *Event, dList []int, bList []float64) (*Event, error) {
+	repairTime := event.eventTime
+	diskM, nodeM, rackM := dcm.DiskManager(), dcm.NodeManager(), dcm.RackManager()
+	powerUpFailure := dcm.GetPowerUpFailure()
+	for _, domainId := range dList {
+		if !em.isValidPowerDomain(domainId) || em.powerOff[domainId] == nil {
+			continue
+		}
+		outage := em.powerOff[domainId]
+		em.powerOff[domainId] = nil
+		em.powerOffTime += float64(outage.offlineDiskNum) * (repairTime - outage.start)
+		for _, rackId := range dcm.GetRackIdsByPowerDomain(domainId) {
+			if rackM.GetRackState(rackId) != data_center.RackStateUnavailable {
+				continue
+			}
+			rackM.RepairRack(rackId)
+			for offset := 0; offset < dcm.GetNodesPerRack(); offset++ {
+				nodeId := dcm.GetNodeIdByRackId(rackId, offset)
+				if nodeM.GetNodeState(nodeId) != data_center.NodeStateUnavailable {
+					continue
+				}
+				if em.powerUpRng.Float64() < powerUpFailure.NodeRatio {
+					em.failNode(dcm, nodeId, repairTime)
+					continue
+				}
+				nodeM.OnlineNode(nodeId)
+				for diskOffset := 0; diskOffset < dcm.GetDisksPerNode(); diskOffset++ {
+					diskId := dcm.GetDiskIdByNodeId(nodeId, diskOffset)
+					if diskM.GetDiskState(diskId) != data_center.DiskStateUnavailable {
+						continue
+					}
+					if em.powerUpRng.Float64() < powerUpFailure.DiskRatio {
+						em.failDisk(dcm, diskId, repairTime)
+					} else {
+						diskM.OnlineDisk(diskId, repairTime)
+					}
+				}
+			}
+		}
+		if !em.UseTrace {
+			em.SetPowerOutage(domainId, repairTime)
+		}
+	}
+	return NewEvent(repairTime, EventPowerRestore, PowerDomain, 0, dList), nil
+}
+
+// SetPowerUpRand 设置本次迭代恢复供电时抽样永久故障设备的随机源，需在 ResetEventManager 之后调用，未设置时使用 ResetEventManager 的随机源。
+// 回放时不再抽样故障事件，主随机源的位置与记录时不同，使用独立的随机源才能抽到与记录时相同的故障设备
+func (em *EventManager) SetPowerUpRand(r *rand.Rand) {
+	em.powerUpRng = r
+}
+
+func (em *EventManager) SetPowerOutage(domainId int, currentTime float64) {
+	heap.Push(em.eventQueue, NewEvent(em.dcManager.GetPowerOutageDistribution().Draw(em.rng)+currentTime,
+		EventPowerOutage, PowerDomain, 0, []int{domainId}))
+}
+
+func (em *EventManager) SetPowerRestore(domainId int, currentTime float64) {
+	heap.Push(em.eventQueue, NewEvent(em.dcManager.GetPowerRestoreDistribution().Draw(em.rng)+currentTime,
+		EventPowerRestore, PowerDomain, 0, []int{domainId}))
+}
+
+// GetPowerOutageNum 本次迭代发生的断电次数
+func (em *EventManager) GetPowerOutageNum() int {
+	return em.powerOutageNum
+}
+
+// GetPowerBlockedRatio 断电造成的磁盘离线时间占比，计算方式与 DCManager.GetBlockedRatio 相同，仍在断电的供电域计算到 currentTime
+func (em *EventManager) GetPowerBlockedRatio(currentTime float64) float64 {
+	offlineTime := em.powerOffTime
+	for _, outage := range em.powerOff {
+		if outage != nil {
+			offlineTime += float64(outage.offlineDiskNum) * (currentTime - outage.start)
+		}
+	}
+	return offlineTime / (float64(em.dcManager.GetChunkNum()) * currentTime)
+}
@@ -0,0 +1,132 @@
+package event_trigger
+
+import "fmt"
+
+var deviceTypeNames = map[DeviceType]string{
+	Rack:        "Rack",
+	Node:        "Node",
+	Disk:        "Disk",
+	PowerDomain: "PowerDomain",
+	Stripe:      "Stripe",
+}
+
+func (t DeviceType) String() string {
+	if name, ok := deviceTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("DeviceType(%d)", int8(t))
+}
+
+// deviceStateNames 磁盘、节点与机架的状态取值相同，按取值给出名称
+var deviceStateNames = []string{"Normal", "Unavailable", "Crashed", "Undefined"}
+
+func deviceStateName(state int8) string {
+	if state >= 0 && int(state) < len(deviceStateNames) {
+		return deviceStateNames[state]
+	}
+	return fmt.Sprintf("State(%d)", state)
+}
+
+// StateChange 事件处理前后状态发生变化的一个设备
+type StateChange struct {
+	Device string `json:"device"`
+	Id     int    `json:"id"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+}
+
+// EventRecord 一个已处理事件的结构化记录。Devices 与 Bandwidth 为合并同时刻同类事件后交给处理函数的设备与修复带宽，
+// Changes 为处理后状态发生变化的磁盘、节点与机架
+type EventRecord struct {
+	Time      float64       `json:"time"`
+	Event     string        `json:"event"`
+	Devices   []int         `json:"devices"`
+	Bandwidth []float64     `json:"bandwidth,omitempty"`
+	Changes   []StateChange `json:"changes"`
+}
+
+// EventRecorder 接收每个已处理事件的记录
+type EventRecorder func(record *EventRecord)
+
+// recordEventTypes 记录中可出现的事件名
+var recordEventTypes = map[string]struct {
+	eventType  EventType
+	deviceType DeviceType
+}{
+	"DiskRepair":   {EventDiskRepair, Disk},
+	"StripeRepair": {EventStripeRepair, Stripe},
+	"RepairBatch":  {EventRepairBatch, Stripe},
+	"MissionEnd":   {EventMissionEnd, Disk},
+}
+
+func init() {
+	for name, eventType := range traceEventTypes {
+		recordEventTypes[name] = eventType
+	}
+}
+
+// SetEventRecorder 设置事件记录回调，为 nil 时不记录。记录需要在每个事件前后比较全部设备的状态，只在排查问题时开启
+func (em *EventManager) SetEventRecorder(recorder EventRecorder) {
+	em.recorder = recorder
+}
+
+// SetReplay 设置下一次 ResetEventManager 后回放的事件记录，为 nil 时恢复正常模拟。
+// 回放时按记录的顺序、时刻、设备与带宽依次调用事件处理函数，处理函数新安排的事件被忽略，
+// 因此在相同配置、种子与迭代序号下可以重现记录时的运行过程
+func (em *EventManager) SetReplay(records []*EventRecord) error {
+	for idx, record := range records {
+		if _, ok := recordEventTypes[record.Event]; !ok {
+			return fmt.Errorf("event record %d: unknown event %q", idx+1, record.Event)
+		}
+	}
+	em.replay, em.replayNext = records, 0
+	return nil
+}
+
+// nextReplayEvent 取出下一条回放记录，记录用完后返回任务结束事件
+func (em *EventManager) nextReplayEvent() (*Event, []int, []float64) {
+	if em.replayNext >= len(em.replay) {
+		return em.missionEndEvent(), nil, nil
+	}
+	record := em.replay[em.replayNext]
+	em.replayNext++
+	eventType := recordEventTypes[record.Event]
+	deviceList := append([]int(nil), record.Devices...)
+	bandwidthList := append(make([]float64, 0, len(record.Bandwidth)), record.Bandwidth...)
+	return NewEvent(record.Time, eventType.eventType, eventType.deviceType, 0, deviceList), deviceList, bandwidthList
+}
+
+// deviceStates 依次返回全部磁盘、节点与机架的状态，复用 buf 的空间
+func (em *EventManager) deviceStates(buf []int8) []int8 {
+	diskM, nodeM, rackM := em.dcManager.DiskManager(), em.dcManager.NodeManager(), em.dcManager.RackManager()
+	buf = buf[:0]
+	for idx := 0; idx < diskM.GetDiskNum(); idx++ {
+		buf = append(buf, int8(diskM.GetDiskState(idx)))
+	}
+	for idx := 0; idx < nodeM.GetNodeNum(); idx++ {
+		buf = append(buf, int8(nodeM.GetNodeState(idx)))
+	}
+	for idx := 0; idx < rackM.GetRackNum(); idx++ {
+		buf = append(buf, int8(rackM.GetRackState(idx)))
+	}
+	return buf
+}
+
+// stateChanges 比较处理前后的设备状态
+func (em *EventManager) stateChanges(before, after []int8) []StateChange {
+	changes := make([]StateChange, 0)
+	diskNum, nodeNum := em.dcManager.DiskManager().GetDiskNum(), em.dcManager.NodeManager().GetNodeNum()
+	for idx := range after {
+		if before[idx] == after[idx] {
+			continue
+		}
+		change := StateChange{Device: Disk.String(), Id: idx, From: deviceStateName(before[idx]), To: deviceStateName(after[idx])}
+		if idx >= diskNum+nodeNum {
+			change.Device, change.Id = Rack.String(), idx-diskNum-nodeNum
+		} else if idx >= diskNum {
+			change.Device, change.Id = Node.String(), idx-diskNum
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
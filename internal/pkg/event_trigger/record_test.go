@@ -0,0 +1,45 @@
+package event_trigger
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEventManager_SetReplay(t *testing.T) {
+	tests := []struct {
+		name       string
+		record     *EventRecord
+		eventType  EventType
+		deviceType string
+	}{
+		{name: "TestDiskFail", record: &EventRecord{Time: 1, Event: "DiskFail", Devices: []int{3}}, eventType: EventDiskFail, deviceType: "Disk"},
+		{name: "TestDiskRepair", record: &EventRecord{Time: 2, Event: "DiskRepair", Devices: []int{3}, Bandwidth: []float64{125}},
+			eventType: EventDiskRepair, deviceType: "Disk"},
+		{name: "TestRackFail", record: &EventRecord{Time: 3, Event: "RackFail", Devices: []int{1}}, eventType: EventRackFail, deviceType: "Rack"},
+		{name: "TestPowerOutage", record: &EventRecord{Time: 4, Event: "PowerOutage", Devices: []int{0, 2}}, eventType: EventPowerOutage, deviceType: "PowerDomain"},
+		{name: "TestPowerRestore", record: &EventRecord{Time: 5, Event: "PowerRestore", Devices: []int{0}}, eventType: EventPowerRestore, deviceType: "PowerDomain"},
+		{name: "TestStripeRepair", record: &EventRecord{Time: 6, Event: "StripeRepair", Devices: []int{7}}, eventType: EventStripeRepair, deviceType: "Stripe"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			em := &EventManager{}
+			if err := em.SetReplay([]*EventRecord{tt.record}); err != nil {
+				t.Fatal(err)
+			}
+			event, devices, bandwidth := em.nextReplayEvent()
+			if event.eventType != tt.eventType || event.deviceType.String() != tt.deviceType || event.eventTime != tt.record.Time {
+				t.Errorf("nextReplayEvent() = %s %s at %g, want %s %s at %g", event.EventType(), event.deviceType, event.eventTime,
+					tt.record.Event, tt.deviceType, tt.record.Time)
+			}
+			if !reflect.DeepEqual(devices, tt.record.Devices) || len(bandwidth) != len(tt.record.Bandwidth) {
+				t.Errorf("nextReplayEvent() devices = %v, bandwidth = %v, want %v, %v", devices, bandwidth, tt.record.Devices, tt.record.Bandwidth)
+			}
+		})
+	}
+	// 每种可回放的事件的设备类型都有名称
+	for name, eventType := range recordEventTypes {
+		if _, ok := deviceTypeNames[eventType.deviceType]; !ok {
+			t.Errorf("event %s has unnamed device type %s", name, eventType.deviceType)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package event_trigger
+
+import (
+	"ECDC_SIM/internal/pkg/data_center"
+	"container/heap"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// RepairPolicy 按条带修复时决定降级条带何时加入修复队列
+type RepairPolicy int8
+
+const (
+	RepairEager   RepairPolicy = iota // 块丢失后立即加入修复队列
+	RepairLazy                        // 条带丢失的块数达到 RepairThreshold 时才加入修复队列
+	RepairBatched                     // 条带等到下一个 RepairBatchInterval 整数倍的时刻再一并加入修复队列
+)
+
+var repairPolicyNames = map[RepairPolicy]string{
+	RepairEager:   "EAGER",
+	RepairLazy:    "LAZY",
+	RepairBatched: "BATCHED",
+}
+
+func (p RepairPolicy) String() string {
+	if name, ok := repairPolicyNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("RepairPolicy(%d)", int8(p))
+}
+
+// ParseRepairPolicy 根据名称（不区分大小写）解析修复策略
+func ParseRepairPolicy(name string) (RepairPolicy, error) {
+	for policy, policyName := range repairPolicyNames {
+		if strings.EqualFold(policyName, name) {
+			return policy, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown repair policy %q", name)
+}
+
+// admitStripeRepair 按修复策略处理有块丢失的条带：已在修复队列中的条带按新的失效块数调整优先级，
+// LAZY 策略下丢失块数未达到阈值的条带暂不修复，BATCHED 策略下条带等到下一个批次时刻再加入队列
+func (em *EventManager) admitStripeRepair(stripeId int, currentTime float64) {
+	if _, ok := em.stripeRepairTasks[stripeId]; ok {
+		em.enqueueStripeRepair(stripeId)
+		return
+	}
+	switch em.RepairPolicy {
+	case RepairLazy:
+		if len(em.lostChunkIdx(stripeId)) < em.RepairThreshold {
+			return
+		}
+	case RepairBatched:
+		em.batchedStripes = append(em.batchedStripes, stripeId)
+		if len(em.batchedStripes) == 1 {
+			batchTime := (math.Floor(currentTime/em.RepairBatchInterval) + 1) * em.RepairBatchInterval
+			heap.Push(em.eventQueue, NewEvent(batchTime, EventRepairBatch, Stripe, 0, nil))
+		}
+		return
+	}
+	em.enqueueStripeRepair(stripeId)
+}
+
+// RepairBatchHandler 到达批次时刻，上一批次以来降级的条带加入修复队列，正在修复的条带在修复完成后重新检查
+func RepairBatchHandler(em *EventManager, dcm *data_center.DCManager, event *Event, dList []int, bList []float64) (*Event, error) {
+	stripeIdList := em.batchedStripes
+	em.batchedStripes = nil
+	for _, stripeId := range stripeIdList {
+		if _, ok := em.stripeRepairing[stripeId]; ok {
+			continue
+		}
+		if len(em.lostChunkIdx(stripeId)) > 0 {
+			em.enqueueStripeRepair(stripeId)
+		}
+	}
+	return NewEvent(event.eventTime, EventRepairBatch, Stripe, 0, stripeIdList), nil
+}
+
+// GetRepairReadSavedChunkNum 一并重建条带的多个丢失块比逐个修复少读取的数据量，以块为单位
+func (em *EventManager) GetRepairReadSavedChunkNum() float64 {
+	return em.repairReadSavedChunkNum
+}
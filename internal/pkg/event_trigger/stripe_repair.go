@@ -0,0 +1,319 @@
+package event_trigger
+
+import (
+	"ECDC_SIM/internal/pkg/data_center"
+	"container/heap"
+	"github.com/gogap/logrus"
+	"math"
+)
+
+// stripeRepairTask 按条带修复时一个降级条带的修复任务
+type stripeRepairTask struct {
+	stripeId  int
+	tolerance int  // 条带还能容忍的块失效数，越小越优先修复
+	seq       int  // 入队顺序，容忍数相同时先入队的先修复
+	stalled   bool // 是否因可用块不足等待过
+	index     int  // 在修复队列中的位置
+}
+
+// stripeRepairHeap 降级条带的修复队列，最危急的条带在堆顶
+type stripeRepairHeap []*stripeRepairTask
+
+func (h *stripeRepairHeap) Len() int {
+	return len(*h)
+}
+
+func (h *stripeRepairHeap) Less(i, j int) bool {
+	if (*h)[i].tolerance != (*h)[j].tolerance {
+		return (*h)[i].tolerance < (*h)[j].tolerance
+	}
+	return (*h)[i].seq < (*h)[j].seq
+}
+
+func (h *stripeRepairHeap) Swap(i, j int) {
+	(*h)[i], (*h)[j] = (*h)[j], (*h)[i]
+	(*h)[i].index, (*h)[j].index = i, j
+}
+
+func (h *stripeRepairHeap) Pop() (v interface{}) {
+	*h, v = (*h)[:len(*h)-1], (*h)[len(*h)-1]
+	return
+}
+
+func (h *stripeRepairHeap) Push(v interface{}) {
+	task := v.(*stripeRepairTask)
+	task.index = len(*h)
+	*h = append(*h, task)
+}
+
+// stripeRepair 正在进行的条带修复
+type stripeRepair struct {
+	stripeId int
+	disks    []int // 重建的块所在的故障磁盘
+}
+
+// resetStripeRepair 清空条带修复队列与条带降级时间的统计
+func (em *EventManager) resetStripeRepair() {
+	em.stripeRepairQueue = new(stripeRepairHeap)
+	em.stripeRepairTasks = make(map[int]*stripeRepairTask)
+	em.stripeRepairSeq, em.stripeRepairing, em.batchedStripes = 0, make(map[int]*stripeRepair), nil
+	em.stripeDegradedStart, em.stripeDegradedTimeSum, em.stripeDegradedNum = make(map[int]float64), 0, 0
+}
+
+// lostChunkIdx 条带中因磁盘故障丢失且尚未重建的块下标
+func (em *EventManager) lostChunkIdx(stripeId int) []int {
+	lostIdxList := make([]int, 0)
+	for idx, diskId := range em.dcManager.GetStripesLocation(stripeId) {
+		if em.dcManager.IsChunkLost(diskId, stripeId) {
+			lostIdxList = append(lostIdxList, idx)
+		}
+	}
+	return lostIdxList
+}
+
+// degradeStripes 磁盘 diskId 故障后，记录其上原本完好的条带开始降级的时间
+func (em *EventManager) degradeStripes(diskId int, failTime float64) {
+	for _, stripeId := range em.dcManager.DiskManager().GetDiskStripes(diskId) {
+		if _, ok := em.stripeDegradedStart[stripeId]; !ok {
+			em.stripeDegradedStart[stripeId] = failTime
+		}
+	}
+}
+
+// healStripe 条带不再有丢失的块时，累计其从降级到恢复完好的用时
+func (em *EventManager) healStripe(stripeId int, repairTime float64) {
+	start, ok := em.stripeDegradedStart[stripeId]
+	if !ok || len(em.lostChunkIdx(stripeId)) > 0 {
+		return
+	}
+	em.stripeDegradedTimeSum += repairTime - start
+	em.stripeDegradedNum++
+	delete(em.stripeDegradedStart, stripeId)
+}
+
+// enqueueStripeRepairs 磁盘 diskId 故障后按修复策略将其上的条带加入修复队列，正在修复的条带在修复完成后重新检查
+func (em *EventManager) enqueueStripeRepairs(diskId int, failTime float64) {
+	for _, stripeId := range em.dcManager.DiskManager().GetDiskStripes(diskId) {
+		if _, ok := em.stripeRepairing[stripeId]; ok {
+			continue
+		}
+		em.admitStripeRepair(stripeId, failTime)
+	}
+}
+
+func (em *EventManager) enqueueStripeRepair(stripeId int) {
+	tolerance := em.dcManager.ErasureCode().FaultTolerance() - len(em.lostChunkIdx(stripeId))
+	if task, ok := em.stripeRepairTasks[stripeId]; ok {
+		task.tolerance = tolerance
+		heap.Fix(em.stripeRepairQueue, task.index)
+		return
+	}
+	em.stripeRepairSeq++
+	task := &stripeRepairTask{stripeId: stripeId, tolerance: tolerance, seq: em.stripeRepairSeq}
+	heap.Push(em.stripeRepairQueue, task)
+	em.stripeRepairTasks[stripeId] = task
+}
+
+// stripeFlowId 条带修复在 NetworkManager 中的修复编号，取负数以区别于以磁盘编号为修复编号的磁盘修复
+func stripeFlowId(stripeId int) int {
+	return -stripeId - 1
+}
+
+func stripeIdOfFlow(flowId int) int {
+	return -flowId - 1
+}
+
+// stripeRepairSlots 同时进行的条带修复数上限，独占带宽时逐个修复
+func (em *EventManager) stripeRepairSlots() int {
+	if em.StripeRepairSlots <= 1 || em.dcManager.Network().Sharing() == data_center.BandwidthExclusive {
+		return 1
+	}
+	return em.StripeRepairSlots
+}
+
+// checkStripeRepairQueue 正在进行的条带修复数未达到上限时，按顺序开始修复队列中最危急且可用块足以修复的条带。
+// 丢失的块可以恢复、但部分块所在的磁盘暂时离线而无法修复的条带留在队列中等待，丢失的块已无法恢复的条带移出队列
+func (em *EventManager) checkStripeRepairQueue(currentTime float64) {
+	if !em.StripeRepair || len(em.stripeRepairing) >= em.stripeRepairSlots() || len(*em.stripeRepairQueue) == 0 {
+		return
+	}
+	code := em.dcManager.ErasureCode()
+	var stalled []*stripeRepairTask
+	for len(*em.stripeRepairQueue) > 0 && len(em.stripeRepairing) < em.stripeRepairSlots() {
+		task := heap.Pop(em.stripeRepairQueue).(*stripeRepairTask)
+		lostIdxList := em.lostChunkIdx(task.stripeId)
+		if len(lostIdxList) == 0 {
+			delete(em.stripeRepairTasks, task.stripeId)
+			continue
+		}
+		if !code.IsRecoverable(lostIdxList) {
+			delete(em.stripeRepairTasks, task.stripeId)
+			em.abandonStripe(task.stripeId, lostIdxList, currentTime)
+			continue
+		}
+		if !code.IsRecoverable(em.stripeUnavailableChunkIdx(task.stripeId)) {
+			if !task.stalled {
+				task.stalled = true
+				em.delayedStripesNum++
+			}
+			stalled = append(stalled, task)
+			continue
+		}
+		delete(em.stripeRepairTasks, task.stripeId)
+		em.setStripeRepair(task.stripeId, lostIdxList, currentTime)
+	}
+	for _, task := range stalled {
+		heap.Push(em.stripeRepairQueue, task)
+	}
+}
+
+// abandonStripe 放弃重建无法恢复的条带 stripeId 丢失的块，所在磁盘的其余条带都已重建时磁盘修复完成
+func (em *EventManager) abandonStripe(stripeId int, lostIdxList []int, currentTime float64) {
+	logrus.Infof("[EventManager.abandonStripe] stripe=%d is unrecoverable, lost chunks=%v", stripeId, lostIdxList)
+	stripeLocation := em.dcManager.GetStripesLocation(stripeId)
+	for _, idx := range lostIdxList {
+		if diskId := stripeLocation[idx]; em.dcManager.AbandonChunk(diskId, stripeId) {
+			em.repairDisk(em.dcManager, diskId, currentTime)
+			em.dcManager.ClearRebuiltChunks(diskId)
+		}
+	}
+}
+
+// stripeUnavailableChunkIdx 条带中当前无法读取的块下标，已重建的块可以读取
+func (em *EventManager) stripeUnavailableChunkIdx(stripeId int) []int {
+	diskM := em.dcManager.DiskManager()
+	unavailableIdxList := make([]int, 0)
+	for idx, diskId := range em.dcManager.GetStripesLocation(stripeId) {
+		if diskM.GetDiskState(diskId) != data_center.DiskStateNormal && !em.dcManager.IsChunkRebuilt(diskId, stripeId) {
+			unavailableIdxList = append(unavailableIdxList, idx)
+		}
+	}
+	return unavailableIdxList
+}
+
+// setStripeRepair 开始重建条带 stripeId 丢失的块。只丢失一个块时按纠删码的修复计划读取协助块，
+// 否则读取 K 个块解码后一并重建。逐个修复时修复使用全部跨机架带宽与第一个丢失块所在机架的机架内带宽，
+// 多个条带同时修复时与其他修复共享带宽，权重为条带丢失的块数
+func (em *EventManager) setStripeRepair(stripeId int, lostIdxList []int, currentTime float64) {
+	dcManager := em.dcManager
+	networkM, diskM := dcManager.Network(), dcManager.DiskManager()
+	code := dcManager.ErasureCode()
+	stripeLocation := dcManager.GetStripesLocation(stripeId)
+	rackId := dcManager.GetRackIdByDiskId(stripeLocation[lostIdxList[0]])
+	// 可用块按读取代价排列，同一机架的在前
+	sameRackAlive, otherAlive := make([]int, 0), make([]int, 0)
+	for idx, diskId := range stripeLocation {
+		if diskM.GetDiskState(diskId) != data_center.DiskStateNormal && !dcManager.IsChunkRebuilt(diskId, stripeId) {
+			continue
+		}
+		if dcManager.GetRackIdByDiskId(diskId) == rackId {
+			sameRackAlive = append(sameRackAlive, idx)
+		} else {
+			otherAlive = append(otherAlive, idx)
+		}
+	}
+	alive := append(sameRackAlive, otherAlive...)
+	em.repairStripesNum++
+	var helpers []int
+	helperRatio := 1.0
+	if len(lostIdxList) == 1 {
+		em.repairStripesSingleChunkNum++
+		helpers, helperRatio = code.RepairPlan(lostIdxList[0], alive)
+	} else {
+		// 逐个修复各丢失块的读取量与一并重建读取 K 个块之差
+		separateRead := 0.0
+		for _, idx := range lostIdxList {
+			if plan, ratio := code.RepairPlan(idx, alive); plan != nil {
+				separateRead += float64(len(plan)) * ratio
+			} else {
+				separateRead += float64(code.K())
+			}
+		}
+		em.repairReadSavedChunkNum += separateRead - float64(code.K())
+	}
+	crossRackDownload, intraRackDownload := 0.0, 0.0
+	if helpers == nil {
+		// 多个块一同重建或可用块不足时按读取 K 个块计算修复流量
+		intra := len(sameRackAlive)
+		if intra > code.K() {
+			intra = code.K()
+		}
+		em.repairReadChunkNum += float64(code.K())
+		em.repairDiskReadChunkNum += float64(code.K())
+		intraRackDownload, crossRackDownload = float64(intra), float64(code.K()-intra)
+	} else {
+		if len(helpers) < code.K() {
+			em.localRepairStripesNum++
+		}
+		em.repairReadChunkNum += float64(len(helpers)) * helperRatio
+		em.repairDiskReadChunkNum += float64(len(helpers)) * data_center.HelperReadRatio(code, helperRatio)
+		for _, idx := range helpers {
+			if dcManager.GetRackIdByDiskId(stripeLocation[idx]) == rackId {
+				intraRackDownload += helperRatio
+			} else {
+				crossRackDownload += helperRatio
+			}
+		}
+	}
+	repair := &stripeRepair{stripeId: stripeId, disks: make([]int, 0, len(lostIdxList))}
+	for _, idx := range lostIdxList {
+		repair.disks = append(repair.disks, stripeLocation[idx])
+	}
+	em.stripeRepairing[stripeId] = repair
+	if em.stripeRepairSlots() > 1 {
+		chunkSize := float64(dcManager.GetChunkSize())
+		networkM.StartRepair(stripeFlowId(stripeId), rackId, crossRackDownload*chunkSize, intraRackDownload*chunkSize, float64(len(lostIdxList)), currentTime)
+		logrus.Infof("[EventManager.setStripeRepair] share bandwidth, stripe=%d", stripeId)
+		em.rescheduleRepairs()
+		return
+	}
+	repairTime := crossRackDownload * float64(dcManager.GetChunkSize()) / networkM.GetAvailCrossRackRepairBandwidth()
+	if intraRackDownload > 0 {
+		repairTime = math.Max(repairTime, intraRackDownload*float64(dcManager.GetChunkSize())/networkM.GetAvailIntraRackRepairBandwidth(rackId))
+	}
+	repairTime /= float64(3600)
+	logrus.Infof("[EventManager.setStripeRepair] stripe=%d, repair time: %+v", stripeId, repairTime)
+	heap.Push(em.eventQueue, NewEvent(currentTime+repairTime, EventStripeRepair, Stripe, 0, []int{stripeId}))
+}
+
+// StripeRepairHandler 条带的块重建完成。磁盘上的条带全部重建后磁盘修复完成，
+// 修复期间条带又有块丢失时按修复策略重新加入修复队列
+func StripeRepairHandler(em *EventManager, dcm *data_center.DCManager, event *Event, dList []int, bList []float64) (*Event, error) {
+	repairTime := event.eventTime
+	network, shared := dcm.Network(), em.stripeRepairSlots() > 1
+	for _, stripeId := range dList {
+		repair, ok := em.stripeRepairing[stripeId]
+		if !ok {
+			logrus.Errorf("[StripeRepairHandler] stripe repair not in progress, stripe=%d", stripeId)
+			continue
+		}
+		delete(em.stripeRepairing, stripeId)
+		if shared {
+			delete(em.repairEvents, stripeFlowId(stripeId))
+			network.FinishRepair(stripeFlowId(stripeId), repairTime)
+		}
+		for _, diskId := range repair.disks {
+			if dcm.RebuildChunk(diskId, stripeId) {
+				em.repairDisk(dcm, diskId, repairTime)
+				dcm.ClearRebuiltChunks(diskId)
+			}
+		}
+		if len(em.lostChunkIdx(stripeId)) > 0 {
+			em.admitStripeRepair(stripeId, repairTime)
+		} else {
+			em.healStripe(stripeId, repairTime)
+		}
+	}
+	if shared {
+		em.rescheduleRepairs()
+	}
+	return NewEvent(repairTime, EventStripeRepair, Stripe, 0, dList), nil
+}
+
+// GetMeanStripeDegradedTime 已恢复完好的条带从首个块丢失到全部块修复的平均用时（小时），即条带的平均脆弱窗口
+func (em *EventManager) GetMeanStripeDegradedTime() float64 {
+	if em.stripeDegradedNum == 0 {
+		return 0
+	}
+	return em.stripeDegradedTimeSum / float64(em.stripeDegradedNum)
+}
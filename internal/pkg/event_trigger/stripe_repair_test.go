@@ -0,0 +1,86 @@
+package event_trigger
+
+import (
+	"ECDC_SIM/internal/pkg/data_center"
+	"container/heap"
+	"reflect"
+	"testing"
+)
+
+func TestStripeRepairHeap(t *testing.T) {
+	tests := []struct {
+		name  string
+		tasks []*stripeRepairTask
+		fix   map[int]int // 入队后调整容忍数的条带
+		want  []int
+	}{
+		{name: "TestToleranceFirst", tasks: []*stripeRepairTask{
+			{stripeId: 1, tolerance: 2, seq: 1},
+			{stripeId: 2, tolerance: 0, seq: 2},
+			{stripeId: 3, tolerance: 1, seq: 3},
+		}, want: []int{2, 3, 1}},
+		{name: "TestSeqOnTie", tasks: []*stripeRepairTask{
+			{stripeId: 5, tolerance: 1, seq: 2},
+			{stripeId: 4, tolerance: 1, seq: 1},
+			{stripeId: 6, tolerance: 1, seq: 3},
+		}, want: []int{4, 5, 6}},
+		{name: "TestFix", tasks: []*stripeRepairTask{
+			{stripeId: 1, tolerance: 2, seq: 1},
+			{stripeId: 2, tolerance: 1, seq: 2},
+			{stripeId: 3, tolerance: 2, seq: 3},
+		}, fix: map[int]int{3: 0}, want: []int{3, 2, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := new(stripeRepairHeap)
+			for _, task := range tt.tasks {
+				heap.Push(h, task)
+			}
+			for _, task := range tt.tasks {
+				if tolerance, ok := tt.fix[task.stripeId]; ok {
+					task.tolerance = tolerance
+					heap.Fix(h, task.index)
+				}
+			}
+			got := make([]int, 0, len(tt.tasks))
+			for h.Len() > 0 {
+				got = append(got, heap.Pop(h).(*stripeRepairTask).stripeId)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pop order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckStripeRepairQueue_Unrecoverable(t *testing.T) {
+	em, dcm := newTestEventManager(t, &RunningConfig{StripeRepair: true})
+	// RS(3,2) 只能容忍 1 个块丢失，条带 0 的两个块所在磁盘同时故障后无法恢复
+	location := dcm.GetStripesLocation(0)
+	em.failDisk(dcm, location[0], 10)
+	em.failDisk(dcm, location[1], 10)
+	if dataLoss, lossInfo := dcm.CheckDataLoss(); !dataLoss || lossInfo.FailedStripes[0] != 0 {
+		t.Fatalf("CheckDataLoss() = %v, %+v, want stripe 0 lost", dataLoss, lossInfo)
+	}
+	em.checkStripeRepairQueue(10)
+	if _, ok := em.stripeRepairing[0]; ok {
+		t.Fatal("unrecoverable stripe 0 is being repaired")
+	}
+	if _, ok := em.stripeRepairTasks[0]; ok {
+		t.Fatal("unrecoverable stripe 0 is still in the repair queue")
+	}
+	// 其余条带修复后，放弃重建的块不再阻止磁盘修复完成
+	for round := 0; len(em.stripeRepairing) > 0 && round < 100; round++ {
+		for stripeId := range em.stripeRepairing {
+			if _, err := StripeRepairHandler(em, dcm, NewEvent(20, EventStripeRepair, Stripe, 0, []int{stripeId}), []int{stripeId}, nil); err != nil {
+				t.Fatal(err)
+			}
+		}
+		em.checkStripeRepairQueue(20)
+	}
+	for _, diskId := range location[:2] {
+		if state := dcm.DiskManager().GetDiskState(diskId); state != data_center.DiskStateNormal {
+			t.Errorf("disk %d state = %v after the recoverable stripes are rebuilt, want normal", diskId, state)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package event_trigger
+
+import (
+	"ECDC_SIM/internal/pkg/data_center"
+	"ECDC_SIM/internal/pkg/enum_error"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// traceEventTypes 故障记录中可使用的事件名，与 Event.EventType 一致。
+// 记录中有 DiskRepair 的磁盘故障后按记录的时刻修复完成，其余磁盘由模拟器根据纠删码与放置策略计算修复过程
+var traceEventTypes = map[string]struct {
+	eventType  EventType
+	deviceType DeviceType
+}{
+	"DiskFail":            {EventDiskFail, Disk},
+	"DiskRepair":          {EventDiskRepair, Disk},
+	"NodeFail":            {EventNodeFail, Node},
+	"NodeTransientFail":   {EventNodeTransientFail, Node},
+	"NodeTransientRepair": {EventNodeTransientRepair, Node},
+	"RackFail":            {EventRackFail, Rack},
+	"RackRepair":          {EventRackRepair, Rack},
+	"PowerOutage":         {EventPowerOutage, PowerDomain},
+	"PowerRestore":        {EventPowerRestore, PowerDomain},
+}
+
+// TraceRecord 一条带时间戳的故障或恢复记录，Time 单位为小时，Device 为磁盘、节点、机架或供电域编号
+type TraceRecord struct {
+	Time   float64 `json:"time"`
+	Event  string  `json:"event"`
+	Device int     `json:"device"`
+}
+
+// Trace 按时间排序的故障记录，UseTrace 模式下每次迭代以其代替按分布抽样的故障事件
+type Trace struct {
+	Records []TraceRecord
+}
+
+// LoadTrace 读取故障记录文件，按扩展名识别 CSV（.csv）或 JSON（.json）
+func LoadTrace(path string) (*Trace, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var format string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		format = "csv"
+	case ".json":
+		format = "json"
+	default:
+		return nil, fmt.Errorf("trace %s: unknown file extension, want .csv or .json", path)
+	}
+	trace, err := ParseTrace(bytes.NewReader(data), format)
+	if err != nil {
+		return nil, fmt.Errorf("trace %s: %w", path, err)
+	}
+	return trace, nil
+}
+
+// ParseTrace 解析故障记录。CSV 的首行为表头，需包含 time、event、device 三列；JSON 为记录对象的数组
+func ParseTrace(r io.Reader, format string) (*Trace, error) {
+	var records []TraceRecord
+	var err error
+	switch format {
+	case "csv":
+		records, err = parseTraceCSV(r)
+	case "json":
+		decoder := json.NewDecoder(r)
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&records)
+	default:
+		err = fmt.Errorf("unknown trace format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for idx, record := range records {
+		if _, ok := traceEventTypes[record.Event]; !ok {
+			return nil, traceRecordError(enum_error.ParamsOutOfRangeError, "record %d: unknown event %q", idx+1, record.Event)
+		}
+		if record.Time < 0 || record.Device < 0 {
+			return nil, traceRecordError(enum_error.ParamsOutOfRangeError, "record %d: negative time or device", idx+1)
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Time < records[j].Time
+	})
+	return &Trace{Records: records}, nil
+}
+
+// traceRecordError 记录内容有误时的错误，与 Validate 的错误同为 RunningConfig.Trace 的 ConfigError
+func traceRecordError(kind error, constraintFormat string, args ...interface{}) error {
+	return enum_error.NewConfigError(kind, "RunningConfig.Trace", constraintFormat, args...)
+}
+
+func parseTraceCSV(r io.Reader) ([]TraceRecord, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, traceRecordError(enum_error.ParamsMissingError, "missing header")
+	}
+	columns := map[string]int{"time": -1, "event": -1, "device": -1}
+	for idx, name := range rows[0] {
+		if _, ok := columns[strings.ToLower(name)]; ok {
+			columns[strings.ToLower(name)] = idx
+		}
+	}
+	for name, idx := range columns {
+		if idx < 0 {
+			return nil, traceRecordError(enum_error.ParamsMissingError, "missing column %q", name)
+		}
+	}
+	records := make([]TraceRecord, 0, len(rows)-1)
+	for line, row := range rows[1:] {
+		record := TraceRecord{Event: row[columns["event"]]}
+		if record.Time, err = strconv.ParseFloat(row[columns["time"]], 64); err != nil {
+			return nil, traceRecordError(enum_error.ParamsInvalidError, "line %d: %v", line+2, err)
+		}
+		if record.Device, err = strconv.Atoi(row[columns["device"]]); err != nil {
+			return nil, traceRecordError(enum_error.ParamsInvalidError, "line %d: %v", line+2, err)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// Validate 检查记录中的设备编号是否在数据中心的规模之内
+func (t *Trace) Validate(dcConf *data_center.DCConf) enum_error.ConfigErrors {
+	var errs enum_error.ConfigErrors
+	powerDomainsNum := dcConf.PowerDomainsNum
+	if powerDomainsNum == 0 {
+		powerDomainsNum = 1
+	}
+	limits := map[DeviceType]int{
+		PowerDomain: powerDomainsNum,
+		Rack:        dcConf.RacksNum,
+		Node:        dcConf.RacksNum * dcConf.NodesPerRack,
+		Disk:        dcConf.RacksNum * dcConf.NodesPerRack * dcConf.DisksPerNode,
+	}
+	for idx, record := range t.Records {
+		if limit := limits[traceEventTypes[record.Event].deviceType]; record.Device >= limit {
+			errs = append(errs, enum_error.NewConfigError(enum_error.ParamsOutOfRangeError, "RunningConfig.Trace",
+				"record %d: %s device %d out of range [0, %d)", idx+1, record.Event, record.Device, limit))
+		}
+	}
+	return errs
+}
+
+// hasDiskRepair 记录中是否有磁盘修复
+func (t *Trace) hasDiskRepair() bool {
+	for _, record := range t.Records {
+		if record.Event == "DiskRepair" {
+			return true
+		}
+	}
+	return false
+}
+
+// diskRepairTimes 任务期内各磁盘按时间排序的修复时刻
+func (t *Trace) diskRepairTimes(missionTime float64) map[int][]float64 {
+	times := make(map[int][]float64)
+	for _, record := range t.Records {
+		if record.Time > missionTime {
+			break
+		}
+		if record.Event == "DiskRepair" {
+			times[record.Device] = append(times[record.Device], record.Time)
+		}
+	}
+	return times
+}
+
+// events 生成任务期内的记录对应的事件
+func (t *Trace) events(missionTime float64) []*Event {
+	events := make([]*Event, 0, len(t.Records))
+	for _, record := range t.Records {
+		if record.Time > missionTime {
+			break
+		}
+		eventType := traceEventTypes[record.Event]
+		events = append(events, NewEvent(record.Time, eventType.eventType, eventType.deviceType, 0, []int{record.Device}))
+	}
+	return events
+}
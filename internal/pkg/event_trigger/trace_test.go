@@ -0,0 +1,122 @@
+package event_trigger
+
+import (
+	"ECDC_SIM/internal/pkg/data_center"
+	"ECDC_SIM/internal/pkg/enum_error"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTrace(t *testing.T) {
+	want := []TraceRecord{
+		{Time: 1.5, Event: "NodeTransientFail", Device: 2},
+		{Time: 10, Event: "DiskFail", Device: 3},
+		{Time: 10, Event: "RackFail", Device: 0},
+	}
+	tests := []struct {
+		name    string
+		data    string
+		format  string
+		want    []TraceRecord
+		wantErr bool
+	}{
+		{name: "TestCSV", format: "csv", data: "time,event,device\n10,DiskFail,3\n1.5,NodeTransientFail,2\n10,RackFail,0\n", want: want},
+		{name: "TestCSVColumnOrder", format: "csv", data: "device, Event, TIME, note\n3, DiskFail, 10, a\n2, NodeTransientFail, 1.5, b\n0, RackFail, 10, c\n", want: want},
+		{name: "TestJSON", format: "json", data: `[{"time": 10, "event": "DiskFail", "device": 3}, {"time": 1.5, "event": "NodeTransientFail", "device": 2},
+			{"time": 10, "event": "RackFail", "device": 0}]`, want: want},
+		{name: "TestEmptyJSON", format: "json", data: `[]`, want: []TraceRecord{}},
+		{name: "TestDiskRepair", format: "csv", data: "time,event,device\n12,DiskRepair,3\n", want: []TraceRecord{{Time: 12, Event: "DiskRepair", Device: 3}}},
+		{name: "TestMissingColumn", format: "csv", data: "time,event\n1,DiskFail\n", wantErr: true},
+		{name: "TestBadTime", format: "csv", data: "time,event,device\nsoon,DiskFail,1\n", wantErr: true},
+		{name: "TestUnknownEvent", format: "csv", data: "time,event,device\n1,DiskRebuild,1\n", wantErr: true},
+		{name: "TestNegativeTime", format: "json", data: `[{"time": -1, "event": "DiskFail", "device": 1}]`, wantErr: true},
+		{name: "TestUnknownField", format: "json", data: `[{"time": 1, "event": "DiskFail", "disk": 1}]`, wantErr: true},
+		{name: "TestUnknownFormat", format: "xml", data: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTrace(strings.NewReader(tt.data), tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTrace() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got.Records, tt.want) {
+				t.Errorf("ParseTrace() = %+v, want %+v", got.Records, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTrace_Errors(t *testing.T) {
+	// 记录有误时均返回 RunningConfig.Trace 的 ConfigError
+	tests := []struct {
+		name     string
+		data     string
+		wantKind error
+	}{
+		{name: "TestMissingColumn", data: "time,event\n1,DiskFail\n", wantKind: enum_error.ParamsMissingError},
+		{name: "TestBadTime", data: "time,event,device\nsoon,DiskFail,1\n", wantKind: enum_error.ParamsInvalidError},
+		{name: "TestBadDevice", data: "time,event,device\n1,DiskFail,disk1\n", wantKind: enum_error.ParamsInvalidError},
+		{name: "TestUnknownEvent", data: "time,event,device\n1,DiskRebuild,1\n", wantKind: enum_error.ParamsOutOfRangeError},
+		{name: "TestNegativeTime", data: "time,event,device\n-1,DiskFail,1\n", wantKind: enum_error.ParamsOutOfRangeError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseTrace(strings.NewReader(tt.data), "csv")
+			var configErr *enum_error.ConfigError
+			if !errors.As(err, &configErr) || configErr.Field != "RunningConfig.Trace" || !errors.Is(err, tt.wantKind) {
+				t.Errorf("ParseTrace() error = %v, want a RunningConfig.Trace ConfigError of kind %v", err, tt.wantKind)
+			}
+		})
+	}
+}
+
+func TestLoadTrace(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "trace.csv")
+	if err := os.WriteFile(path, []byte("time,event,device\n1,DiskFail,1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := LoadTrace(path); err != nil || len(got.Records) != 1 {
+		t.Errorf("LoadTrace() = %+v, %v", got, err)
+	}
+	if _, err := LoadTrace(filepath.Join(dir, "trace.txt")); err == nil {
+		t.Error("LoadTrace() with unknown extension want error")
+	}
+}
+
+func TestTrace_Validate(t *testing.T) {
+	dcConf := &data_center.DCConf{RacksNum: 2, NodesPerRack: 3, DisksPerNode: 2}
+	trace := &Trace{Records: []TraceRecord{
+		{Time: 1, Event: "DiskFail", Device: 11},
+		{Time: 1, Event: "DiskFail", Device: 12},
+		{Time: 2, Event: "NodeFail", Device: 6},
+		{Time: 3, Event: "RackRepair", Device: 1},
+		{Time: 4, Event: "PowerOutage", Device: 0},
+		{Time: 5, Event: "PowerRestore", Device: 1},
+	}}
+	errs := trace.Validate(dcConf)
+	if len(errs) != 3 || !errors.Is(errs.Err(), enum_error.ParamsOutOfRangeError) {
+		t.Errorf("Validate() = %v, want disk 12, node 6 and power domain 1 out of range", errs)
+	}
+	dcConf.PowerDomainsNum = 2
+	if errs = trace.Validate(dcConf); len(errs) != 2 {
+		t.Errorf("Validate() with 2 power domains = %v, want disk 12 and node 6 out of range", errs)
+	}
+}
+
+func TestTrace_events(t *testing.T) {
+	trace := &Trace{Records: []TraceRecord{
+		{Time: 1, Event: "NodeFail", Device: 1},
+		{Time: 2, Event: "RackFail", Device: 0},
+		{Time: 5, Event: "DiskFail", Device: 3},
+	}}
+	got := trace.events(2)
+	if len(got) != 2 || got[0].eventType != EventNodeFail || got[0].deviceType != Node || got[1].eventType != EventRackFail ||
+		!reflect.DeepEqual(got[1].deviceIdList, []int{0}) {
+		t.Errorf("events() = %+v", got)
+	}
+}
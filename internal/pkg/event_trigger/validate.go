@@ -0,0 +1,89 @@
+package event_trigger
+
+import (
+	"ECDC_SIM/internal/pkg/data_center"
+	"ECDC_SIM/internal/pkg/enum_error"
+)
+
+// Validate 检查运行配置，dcConf 非 nil 时同时检查所开启的故障模型需要的分布是否齐全，ecConf 非 nil 时同时检查与纠删码相关的约束
+func (c *RunningConfig) Validate(dcConf *data_center.DCConf, ecConf *data_center.ErasureCodeConf) enum_error.ConfigErrors {
+	var errs enum_error.ConfigErrors
+	if c.ConfidenceLevel < 0 || c.ConfidenceLevel >= 1 {
+		errs = append(errs, enum_error.NewConfigError(enum_error.ParamsOutOfRangeError, "RunningConfig.ConfidenceLevel", "must be in [0, 1), got %g", c.ConfidenceLevel))
+	}
+	if c.TargetRelativeError < 0 {
+		errs = append(errs, enum_error.NewConfigError(enum_error.ParamsOutOfRangeError, "RunningConfig.TargetRelativeError", "must not be negative, got %g", c.TargetRelativeError))
+	}
+	if c.TimeBudget < 0 {
+		errs = append(errs, enum_error.NewConfigError(enum_error.ParamsOutOfRangeError, "RunningConfig.TimeBudget", "must not be negative, got %v", c.TimeBudget))
+	}
+	if c.RestoreLostStripes && !c.ContinueAfterLoss {
+		errs = append(errs, enum_error.NewConfigError(enum_error.ParamsInconsistentError, "RunningConfig.RestoreLostStripes", "requires ContinueAfterLoss"))
+	}
+	// 条带修复的用时由修复流量与带宽计算
+	if c.StripeRepair && dcConf != nil && !dcConf.UseNetwork {
+		errs = append(errs, enum_error.NewConfigError(enum_error.ParamsInconsistentError, "RunningConfig.StripeRepair", "requires DCConf.UseNetwork"))
+	}
+	// 同时进行的条带修复按流体模型共享带宽
+	if c.StripeRepairSlots < 0 {
+		errs = append(errs, enum_error.NewConfigError(enum_error.ParamsOutOfRangeError, "RunningConfig.StripeRepairSlots", "must not be negative, got %d", c.StripeRepairSlots))
+	} else if c.StripeRepairSlots > 1 && dcConf != nil && dcConf.BandwidthSharing == data_center.BandwidthExclusive {
+		errs = append(errs, enum_error.NewConfigError(enum_error.ParamsInconsistentError, "RunningConfig.StripeRepairSlots", "more than one slot requires DCConf.BandwidthSharing MAXMIN or WEIGHTED"))
+	} else if c.StripeRepair && c.StripeRepairSlots <= 1 && dcConf != nil && dcConf.BandwidthSharing != data_center.BandwidthExclusive {
+		// 逐个修复条带时只有一个修复使用带宽，共享方式不起作用
+		errs = append(errs, enum_error.NewConfigError(enum_error.ParamsInconsistentError, "DCConf.BandwidthSharing", "%s with StripeRepair requires StripeRepairSlots above 1", dcConf.BandwidthSharing))
+	}
+	if _, ok := repairPolicyNames[c.RepairPolicy]; !ok {
+		errs = append(errs, enum_error.NewConfigError(enum_error.ParamsOutOfRangeError, "RunningConfig.RepairPolicy", "unknown repair policy %d", c.RepairPolicy))
+	} else if c.RepairPolicy != RepairEager && !c.StripeRepair {
+		errs = append(errs, enum_error.NewConfigError(enum_error.ParamsInconsistentError, "RunningConfig.RepairPolicy", "%s requires StripeRepair", c.RepairPolicy))
+	}
+	if c.RepairPolicy == RepairLazy && c.RepairThreshold < 1 {
+		errs = append(errs, enum_error.NewConfigError(enum_error.ParamsOutOfRangeError, "RunningConfig.RepairThreshold", "must be positive for LAZY repair, got %d", c.RepairThreshold))
+	} else if c.RepairPolicy == RepairLazy && ecConf != nil {
+		// 阈值超过容错数时条带在开始修复前就已丢失
+		if code, codeErrs := data_center.NewErasureCode(ecConf); len(codeErrs) == 0 && c.RepairThreshold > code.FaultTolerance() {
+			errs = append(errs, enum_error.NewConfigError(enum_error.ParamsInconsistentError, "RunningConfig.RepairThreshold",
+				"must not exceed the fault tolerance %d of %s for LAZY repair, got %d", code.FaultTolerance(), code, c.RepairThreshold))
+		}
+	}
+	if c.RepairPolicy == RepairBatched && c.RepairBatchInterval <= 0 {
+		errs = append(errs, enum_error.NewConfigError(enum_error.ParamsOutOfRangeError, "RunningConfig.RepairBatchInterval", "must be positive for BATCHED repair, got %g", c.RepairBatchInterval))
+	}
+	if c.UseTrace {
+		if c.Trace == nil {
+			errs = append(errs, enum_error.NewConfigError(enum_error.ParamsMissingError, "RunningConfig.Trace", "trace is required when UseTrace is set"))
+		} else if dcConf != nil {
+			errs = append(errs, c.Trace.Validate(dcConf)...)
+		}
+		// 按条带修复时磁盘由各条带的修复完成，不能按记录的时刻修复
+		if c.Trace != nil && c.StripeRepair && c.Trace.hasDiskRepair() {
+			errs = append(errs, enum_error.NewConfigError(enum_error.ParamsInconsistentError, "RunningConfig.Trace", "DiskRepair records cannot be used with StripeRepair"))
+		}
+	}
+	// 回放故障记录时不再按分布抽样，无需检查断电与瞬时故障的分布
+	if dcConf != nil && c.UsePowerOutage && !c.UseTrace {
+		if dcConf.POutageD == nil {
+			errs = append(errs, enum_error.NewConfigError(enum_error.ParamsMissingError, "DCConf.POutageD", "distribution is required when UsePowerOutage is set"))
+		}
+		if dcConf.PRestoreD == nil {
+			errs = append(errs, enum_error.NewConfigError(enum_error.ParamsMissingError, "DCConf.PRestoreD", "distribution is required when UsePowerOutage is set"))
+		}
+	}
+	if dcConf == nil || !c.EnableTransientFailure || c.UseTrace {
+		return errs
+	}
+	if dcConf.NTFailD == nil {
+		errs = append(errs, enum_error.NewConfigError(enum_error.ParamsMissingError, "DCConf.NTFailD", "distribution is required when EnableTransientFailure is set"))
+	}
+	if dcConf.NTRepairD == nil {
+		errs = append(errs, enum_error.NewConfigError(enum_error.ParamsMissingError, "DCConf.NTRepairD", "distribution is required when EnableTransientFailure is set"))
+	}
+	if !c.UsePowerOutage && dcConf.RFailD == nil {
+		errs = append(errs, enum_error.NewConfigError(enum_error.ParamsMissingError, "DCConf.RFailD", "distribution is required when EnableTransientFailure is set"))
+	}
+	if !c.UsePowerOutage && dcConf.RRepairD == nil {
+		errs = append(errs, enum_error.NewConfigError(enum_error.ParamsMissingError, "DCConf.RRepairD", "distribution is required when EnableTransientFailure is set"))
+	}
+	return errs
+}
@@ -0,0 +1,54 @@
+// Package gf256 有限域 GF(2^8) 上的运算与矩阵，本原多项式为 x^8+x^4+x^3+x^2+1（0x11d），生成元为 2
+package gf256
+
+const primitivePoly = 0x11d
+
+var (
+	expTable [510]byte
+	logTable [256]int
+)
+
+func init() {
+	x := 1
+	for i := 0; i < 255; i++ {
+		expTable[i], expTable[i+255] = byte(x), byte(x)
+		logTable[x] = i
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= primitivePoly
+		}
+	}
+}
+
+// Add 加法与减法相同，均为按位异或
+func Add(a, b byte) byte {
+	return a ^ b
+}
+
+func Mul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return expTable[logTable[a]+logTable[b]]
+}
+
+// Inv 非零元素的乘法逆元，a 为 0 时 panic
+func Inv(a byte) byte {
+	if a == 0 {
+		panic("gf256: inverse of zero")
+	}
+	return expTable[255-logTable[a]]
+}
+
+// Div a/b，b 为 0 时 panic
+func Div(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return Mul(a, Inv(b))
+}
+
+// Exp 生成元的 n 次幂
+func Exp(n int) byte {
+	return expTable[((n%255)+255)%255]
+}
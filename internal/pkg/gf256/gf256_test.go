@@ -0,0 +1,73 @@
+package gf256
+
+import "testing"
+
+func TestMulInv(t *testing.T) {
+	for a := 1; a < 256; a++ {
+		if got := Mul(byte(a), Inv(byte(a))); got != 1 {
+			t.Fatalf("Mul(%d, Inv(%d)) = %d, want 1", a, a, got)
+		}
+		for b := 1; b < 256; b++ {
+			if got := Div(Mul(byte(a), byte(b)), byte(b)); got != byte(a) {
+				t.Fatalf("Div(Mul(%d, %d), %d) = %d, want %d", a, b, b, got, a)
+			}
+		}
+	}
+	tests := []struct {
+		name string
+		a, b byte
+		want byte
+	}{
+		{name: "TestZero", a: 0, b: 7, want: 0},
+		{name: "TestOne", a: 1, b: 0x53, want: 0x53},
+		{name: "TestReduce", a: 0x80, b: 2, want: 0x1d},
+		{name: "TestGenerator", a: Exp(254), b: 2, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Mul(tt.a, tt.b); got != tt.want {
+				t.Errorf("Mul(%#x, %#x) = %#x, want %#x", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatrix_Rank(t *testing.T) {
+	tests := []struct {
+		name string
+		m    Matrix
+		want int
+	}{
+		{name: "TestIdentity", m: Identity(4), want: 4},
+		{name: "TestEmpty", m: Matrix{}, want: 0},
+		{name: "TestXorDependent", m: Matrix{{1, 0, 0}, {0, 1, 0}, {1, 1, 0}}, want: 2},
+		{name: "TestScaledRow", m: Matrix{{2, 4}, {Mul(3, 2), Mul(3, 4)}}, want: 1},
+		{name: "TestMoreRowsThanCols", m: Matrix{{1, 2}, {3, 4}, {5, 6}}, want: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.m.Rank(); got != tt.want {
+				t.Errorf("Rank(%v) = %d, want %d", tt.m, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSystematicCauchy(t *testing.T) {
+	// 任意 k 行线性无关
+	n, k := 9, 6
+	m := SystematicCauchy(n, k)
+	var choose func(start int, rows []int)
+	choose = func(start int, rows []int) {
+		if len(rows) == k {
+			if rank := m.SubRows(rows).Rank(); rank != k {
+				t.Fatalf("rows %v have rank %d, want %d", rows, rank, k)
+			}
+			return
+		}
+		for row := start; row < n; row++ {
+			choose(row+1, append(rows, row))
+		}
+	}
+	choose(0, nil)
+}
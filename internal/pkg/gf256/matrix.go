@@ -0,0 +1,97 @@
+package gf256
+
+// Matrix GF(2^8) 上的矩阵，按行存储
+type Matrix [][]byte
+
+func NewMatrix(rows, cols int) Matrix {
+	m := make(Matrix, rows)
+	for i := range m {
+		m[i] = make([]byte, cols)
+	}
+	return m
+}
+
+func Identity(n int) Matrix {
+	m := NewMatrix(n, n)
+	for i := range m {
+		m[i][i] = 1
+	}
+	return m
+}
+
+// Cauchy rows×cols 的 Cauchy 矩阵，第 (i, j) 个元素为 1/(x_i+y_j)，x_i = cols+i，y_j = j。
+// 其任意方阵子矩阵均可逆，要求 rows+cols 不超过 256
+func Cauchy(rows, cols int) Matrix {
+	m := NewMatrix(rows, cols)
+	for i := range m {
+		for j := range m[i] {
+			m[i][j] = Inv(byte(cols+i) ^ byte(j))
+		}
+	}
+	return m
+}
+
+// SystematicCauchy n×k 的系统 Cauchy 生成矩阵：前 k 行为单位阵，其后 n-k 行为 Cauchy 矩阵，任意 k 行线性无关（MDS）
+func SystematicCauchy(n, k int) Matrix {
+	return append(Identity(k), Cauchy(n-k, k)...)
+}
+
+func (m Matrix) Rows() int {
+	return len(m)
+}
+
+func (m Matrix) Cols() int {
+	if len(m) == 0 {
+		return 0
+	}
+	return len(m[0])
+}
+
+func (m Matrix) Clone() Matrix {
+	clone := make(Matrix, len(m))
+	for i, row := range m {
+		clone[i] = append([]byte(nil), row...)
+	}
+	return clone
+}
+
+// SubRows 由下标为 rows 的行组成的矩阵，行与 m 共享存储
+func (m Matrix) SubRows(rows []int) Matrix {
+	sub := make(Matrix, 0, len(rows))
+	for _, row := range rows {
+		sub = append(sub, m[row])
+	}
+	return sub
+}
+
+// Rank 高斯消元求矩阵的秩，不修改 m
+func (m Matrix) Rank() int {
+	a := m.Clone()
+	rank := 0
+	for col := 0; col < a.Cols() && rank < len(a); col++ {
+		pivot := -1
+		for row := rank; row < len(a); row++ {
+			if a[row][col] != 0 {
+				pivot = row
+				break
+			}
+		}
+		if pivot < 0 {
+			continue
+		}
+		a[rank], a[pivot] = a[pivot], a[rank]
+		inv := Inv(a[rank][col])
+		for j := col; j < len(a[rank]); j++ {
+			a[rank][j] = Mul(a[rank][j], inv)
+		}
+		for row := rank + 1; row < len(a); row++ {
+			if factor := a[row][col]; factor != 0 {
+				for j := col; j < len(a[row]); j++ {
+					a[row][j] ^= Mul(factor, a[rank][j])
+				}
+			}
+		}
+		rank++
+	}
+	return rank
+}
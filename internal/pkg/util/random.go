@@ -1,31 +1,48 @@
 package util
 
 import (
+	"hash/fnv"
 	"math/rand"
-	"time"
 )
 
-func init() {
-	rand.Seed(time.Now().UnixNano())
+// NewIterationRand 根据种子与迭代序号生成该次迭代专用的随机源，相同的 seed 与 iteration 总是得到相同的随机序列
+func NewIterationRand(seed int64, iteration int) *rand.Rand {
+	return rand.New(rand.NewSource(int64(splitMix64(uint64(seed) ^ splitMix64(uint64(iteration))))))
 }
 
-func RandomInt(begin, end int) int {
-	return begin + rand.Intn(end-begin+1)
+// NewStreamRand 第 iteration 次迭代中名为 stream 的独立随机源，抽样序列只由 seed、iteration 与 stream 决定，
+// 不受该次迭代其它随机源使用情况的影响
+func NewStreamRand(seed int64, iteration int, stream string) *rand.Rand {
+	h := fnv.New64a()
+	h.Write([]byte(stream))
+	return rand.New(rand.NewSource(int64(splitMix64(uint64(seed) ^ splitMix64(uint64(iteration)) ^ splitMix64(h.Sum64())))))
 }
 
-func GenerateListSample(numRange int, num int) []int {
+// splitMix64 将相邻的输入打散为互不相关的输出，避免相邻迭代的随机序列相关
+func splitMix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+func RandomInt(r *rand.Rand, begin, end int) int {
+	return begin + r.Intn(end-begin+1)
+}
+
+func GenerateListSample(r *rand.Rand, numRange int, num int) []int {
 	var originList []int
 	for i := 0; i < numRange; i++ {
 		originList = append(originList, i)
 	}
-	return Sample(originList, num)
+	return Sample(r, originList, num)
 }
 
 // Sample 从列表中随机取出num个元素
-func Sample(sample []int, num int) []int {
+func Sample(r *rand.Rand, sample []int, num int) []int {
 	var result []int
 	for num != 0 {
-		idx := rand.Intn(len(sample))
+		idx := r.Intn(len(sample))
 		result = append(result, sample[idx])
 		num--
 		sample = append(sample[:idx], sample[idx+1:]...)
@@ -1,6 +1,8 @@
 package util
 
 import (
+	"math/rand"
+	"reflect"
 	"testing"
 )
 
@@ -28,9 +30,64 @@ func TestSample(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			for _, item := range Sample(tt.args.sample, tt.args.num) {
+			for _, item := range Sample(rand.New(rand.NewSource(1)), tt.args.sample, tt.args.num) {
 				t.Log(item)
 			}
 		})
 	}
 }
+
+func TestNewIterationRand(t *testing.T) {
+	type args struct {
+		seed      int64
+		iteration int
+	}
+	tests := []struct {
+		name string
+		a, b args
+		same bool
+	}{
+		{name: "testSameSeedSameIteration", a: args{seed: 7, iteration: 3}, b: args{seed: 7, iteration: 3}, same: true},
+		{name: "testSameSeedOtherIteration", a: args{seed: 7, iteration: 3}, b: args{seed: 7, iteration: 4}, same: false},
+		{name: "testOtherSeedSameIteration", a: args{seed: 7, iteration: 3}, b: args{seed: 8, iteration: 3}, same: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := GenerateListSample(NewIterationRand(tt.a.seed, tt.a.iteration), 100, 10)
+			want := GenerateListSample(NewIterationRand(tt.b.seed, tt.b.iteration), 100, 10)
+			if reflect.DeepEqual(got, want) != tt.same {
+				t.Errorf("GenerateListSample() = %v, %v, same want %v", got, want, tt.same)
+			}
+		})
+	}
+}
+
+func TestNewStreamRand(t *testing.T) {
+	type args struct {
+		seed      int64
+		iteration int
+		stream    string
+	}
+	tests := []struct {
+		name string
+		a, b args
+		same bool
+	}{
+		{name: "testSameStream", a: args{seed: 7, iteration: 3, stream: "power-up"}, b: args{seed: 7, iteration: 3, stream: "power-up"}, same: true},
+		{name: "testOtherStream", a: args{seed: 7, iteration: 3, stream: "power-up"}, b: args{seed: 7, iteration: 3, stream: "other"}, same: false},
+		{name: "testOtherIteration", a: args{seed: 7, iteration: 3, stream: "power-up"}, b: args{seed: 7, iteration: 4, stream: "power-up"}, same: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := GenerateListSample(NewStreamRand(tt.a.seed, tt.a.iteration, tt.a.stream), 100, 10)
+			want := GenerateListSample(NewStreamRand(tt.b.seed, tt.b.iteration, tt.b.stream), 100, 10)
+			if reflect.DeepEqual(got, want) != tt.same {
+				t.Errorf("GenerateListSample() = %v, %v, same want %v", got, want, tt.same)
+			}
+		})
+	}
+	iterationDraw := NewIterationRand(7, 3).Int63()
+	if NewStreamRand(7, 3, "power-up").Int63() == iterationDraw {
+		t.Errorf("NewStreamRand() draws the same sequence as NewIterationRand()")
+	}
+}
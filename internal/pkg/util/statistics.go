@@ -0,0 +1,42 @@
+package util
+
+import "math"
+
+// NormalQuantile 返回双侧置信水平 level 对应的标准正态分位数，例如 0.95 -> 1.96
+func NormalQuantile(level float64) float64 {
+	if level <= 0 || level >= 1 {
+		return math.NaN()
+	}
+	return math.Sqrt2 * math.Erfinv(level)
+}
+
+// MeanInterval 由样本和与平方和计算均值及其正态近似置信区间的半宽
+func MeanInterval(sum, sqSum float64, n int, level float64) (mean, halfWidth float64) {
+	if n == 0 {
+		return 0, 0
+	}
+	mean = sum / float64(n)
+	if n == 1 {
+		return mean, math.Inf(1)
+	}
+	variance := (sqSum - float64(n)*mean*mean) / float64(n-1)
+	if variance < 0 {
+		variance = 0
+	}
+	return mean, NormalQuantile(level) * math.Sqrt(variance/float64(n))
+}
+
+// WilsonInterval 计算 n 次试验中 successes 次成功的比例的 Wilson 置信区间，
+// 比例接近 0 时（如数据丢失概率）比正态近似更可靠
+func WilsonInterval(successes, n int, level float64) (lower, upper float64) {
+	if n == 0 {
+		return 0, 1
+	}
+	z := NormalQuantile(level)
+	p := float64(successes) / float64(n)
+	nf := float64(n)
+	denominator := 1 + z*z/nf
+	center := (p + z*z/(2*nf)) / denominator
+	margin := z * math.Sqrt(p*(1-p)/nf+z*z/(4*nf*nf)) / denominator
+	return math.Max(0, center-margin), math.Min(1, center+margin)
+}
@@ -0,0 +1,55 @@
+package util
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNormalQuantile(t *testing.T) {
+	tests := []struct {
+		name  string
+		level float64
+		want  float64
+	}{
+		{name: "testLevel90", level: 0.90, want: 1.6449},
+		{name: "testLevel95", level: 0.95, want: 1.9600},
+		{name: "testLevel99", level: 0.99, want: 2.5758},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalQuantile(tt.level); math.Abs(got-tt.want) > 1e-4 {
+				t.Errorf("NormalQuantile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWilsonInterval(t *testing.T) {
+	tests := []struct {
+		name      string
+		successes int
+		n         int
+		wantLower float64
+		wantUpper float64
+	}{
+		{name: "testNoSuccess", successes: 0, n: 100, wantLower: 0, wantUpper: 0.0370},
+		{name: "testHalf", successes: 50, n: 100, wantLower: 0.4038, wantUpper: 0.5962},
+		{name: "testNoTrial", successes: 0, n: 0, wantLower: 0, wantUpper: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lower, upper := WilsonInterval(tt.successes, tt.n, 0.95)
+			if math.Abs(lower-tt.wantLower) > 1e-4 || math.Abs(upper-tt.wantUpper) > 1e-4 {
+				t.Errorf("WilsonInterval() = [%v, %v], want [%v, %v]", lower, upper, tt.wantLower, tt.wantUpper)
+			}
+		})
+	}
+}
+
+func TestMeanInterval(t *testing.T) {
+	// 样本 1,2,3,4,5: 均值 3, 标准差 sqrt(2.5)
+	mean, halfWidth := MeanInterval(15, 55, 5, 0.95)
+	if mean != 3 || math.Abs(halfWidth-1.96*math.Sqrt(2.5/5)) > 1e-3 {
+		t.Errorf("MeanInterval() = %v ± %v", mean, halfWidth)
+	}
+}
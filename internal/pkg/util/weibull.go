@@ -1,15 +1,11 @@
 package util
 
 import (
+	"fmt"
 	"math"
 	"math/rand"
-	"time"
 )
 
-func init() {
-	rand.Seed(time.Now().UnixNano())
-}
-
 type Weibull struct {
 	shape    float64
 	scale    float64
@@ -24,6 +20,22 @@ func NewWeibull(shape, scale, location float64) *Weibull {
 	}
 }
 
+func (w *Weibull) Shape() float64 {
+	return w.shape
+}
+
+func (w *Weibull) Scale() float64 {
+	return w.scale
+}
+
+func (w *Weibull) Location() float64 {
+	return w.location
+}
+
+func (w *Weibull) String() string {
+	return fmt.Sprintf("Weibull(shape=%g, scale=%g, location=%g)", w.shape, w.scale, w.location)
+}
+
 func (w *Weibull) pdf(x float64) float64 {
 	if x < 0 || x < w.location {
 		return 0
@@ -51,7 +63,8 @@ func (w *Weibull) HazardRate(x float64) float64 {
 	return math.Abs(w.pdf(x) / (1 - w.cdf(x)))
 }
 
-func (w *Weibull) Draw() float64 {
-	u := 1 - rand.Float64()
+// Draw 使用随机源 r 抽样
+func (w *Weibull) Draw(r *rand.Rand) float64 {
+	u := 1 - r.Float64()
 	return w.scale*math.Pow(-math.Log(u), 1/w.shape) + w.location
 }
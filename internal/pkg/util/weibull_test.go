@@ -1,6 +1,7 @@
 package util
 
 import (
+	"math/rand"
 	"testing"
 	"time"
 )
@@ -30,7 +31,7 @@ func TestWeibull_Draw(t *testing.T) {
 				scale:    tt.fields.scale,
 				location: tt.fields.location,
 			}
-			t.Log(w.Draw())
+			t.Log(w.Draw(rand.New(rand.NewSource(time.Now().UnixNano()))))
 		})
 	}
 }
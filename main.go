@@ -1,5 +1,10 @@
 package main
 
-func main() {
+import (
+	"ECDC_SIM/internal/pkg/cli"
+	"os"
+)
 
+func main() {
+	os.Exit(cli.Main(os.Args[1:], os.Stdout, os.Stderr))
 }
@@ -0,0 +1,284 @@
+package simulator
+
+import (
+	"ECDC_SIM/internal/pkg/util"
+	"encoding/json"
+	"math"
+)
+
+const (
+	// DefaultConfidenceLevel 未指定置信水平时使用的默认值
+	DefaultConfidenceLevel = 0.95
+	// minDataLossIterationsToStop 停止规则生效前至少需要观察到的数据丢失次数，避免在极少样本上误判收敛
+	minDataLossIterationsToStop = 10
+)
+
+// Moments 单个指标在多次迭代上的和与平方和
+type Moments struct {
+	Sum   float64
+	SqSum float64
+}
+
+func (m *Moments) Add(x float64) {
+	m.Sum += x
+	m.SqSum += x * x
+}
+
+func (m *Moments) Merge(other Moments) {
+	m.Sum += other.Sum
+	m.SqSum += other.SqSum
+}
+
+// Estimate 计算 n 次迭代上的均值及置信区间
+func (m Moments) Estimate(n int, confidenceLevel float64) Estimate {
+	mean, halfWidth := util.MeanInterval(m.Sum, m.SqSum, n, confidenceLevel)
+	return Estimate{Mean: mean, Lower: mean - halfWidth, Upper: mean + halfWidth, HalfWidth: halfWidth}
+}
+
+// Aggregate 多次迭代结果的汇总，保存各指标的矩，用于计算均值及置信区间
+type Aggregate struct {
+	Iterations             int
+	DataLossIterations     int
+	LossEventNum           Moments
+	FailedStripesNum       Moments
+	LostChunkNum           Moments
+	DataBytesLost          Moments
+	ParityBytesLost        Moments
+	NOMDL                  Moments
+	ParityNOMDL            Moments
+	BlockedRatio           Moments
+	SingleChunkRepairRatio Moments
+	LocalRepairRatio       Moments
+	RepairTrafficBytes     Moments
+	RepairDiskReadBytes    Moments
+	RepairSavedBytes       Moments
+	MeanRepairHours        Moments
+	MeanDegradedHours      Moments
+	// PowerDataLossIterations 发生由断电引起的数据丢失的迭代数，已包含在 DataLossIterations 中
+	PowerDataLossIterations int
+	PowerOutageNum          Moments
+	PowerBlockedRatio       Moments
+}
+
+// Estimate 指标的点估计及置信区间
+type Estimate struct {
+	Mean      float64
+	Lower     float64
+	Upper     float64
+	HalfWidth float64
+}
+
+// jsonFloat 非有限值（如样本不足时的置信区间）在 JSON 中输出为 null
+type jsonFloat float64
+
+func (f jsonFloat) MarshalJSON() ([]byte, error) {
+	if math.IsInf(float64(f), 0) || math.IsNaN(float64(f)) {
+		return []byte("null"), nil
+	}
+	return json.Marshal(float64(f))
+}
+
+func (e Estimate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Mean      jsonFloat
+		Lower     jsonFloat
+		Upper     jsonFloat
+		HalfWidth jsonFloat
+	}{jsonFloat(e.Mean), jsonFloat(e.Lower), jsonFloat(e.Upper), jsonFloat(e.HalfWidth)})
+}
+
+// DurabilityReport 汇总结果的统计报告
+type DurabilityReport struct {
+	Iterations             int
+	DataLossIterations     int
+	ConfidenceLevel        float64
+	PDL                    Estimate
+	PDLRelativeError       float64 // PDL 置信区间半宽与 PDL 之比，PDL 为 0 时为 +Inf
+	LossEventNum           Estimate
+	FailedStripesNum       Estimate
+	LostChunkNum           Estimate
+	DataBytesLost          Estimate
+	ParityBytesLost        Estimate
+	NOMDL                  Estimate
+	ParityNOMDL            Estimate
+	BlockedRatio           Estimate
+	SingleChunkRepairRatio Estimate
+	LocalRepairRatio       Estimate
+	RepairTrafficBytes     Estimate
+	RepairDiskReadBytes    Estimate
+	RepairSavedBytes       Estimate
+	MeanRepairHours        Estimate
+	MeanDegradedHours      Estimate
+	// 断电的影响单独给出：PowerPDL 为发生由断电引起的数据丢失的概率，PowerBlockedRatio 为断电造成的离线时间占比
+	PowerDataLossIterations int
+	PowerPDL                Estimate
+	PowerOutageNum          Estimate
+	PowerBlockedRatio       Estimate
+}
+
+func NewAggregate() *Aggregate {
+	return &Aggregate{}
+}
+
+// Add 合并一次迭代的结果
+func (a *Aggregate) Add(result *SimResult) {
+	if result == nil {
+		return
+	}
+	a.Iterations++
+	if result.DataLoss {
+		a.DataLossIterations++
+	}
+	a.LossEventNum.Add(float64(len(result.LossEvents)))
+	a.FailedStripesNum.Add(float64(result.FailedStripesNum))
+	a.LostChunkNum.Add(float64(result.LostChunkNum))
+	a.DataBytesLost.Add(float64(result.DataBytesLost))
+	a.ParityBytesLost.Add(float64(result.ParityBytesLost))
+	a.NOMDL.Add(result.NOMDL)
+	a.ParityNOMDL.Add(result.ParityNOMDL)
+	a.BlockedRatio.Add(result.BlockedRatio)
+	a.SingleChunkRepairRatio.Add(result.SingleChunkRepairRatio)
+	a.LocalRepairRatio.Add(result.LocalRepairRatio)
+	a.RepairTrafficBytes.Add(float64(result.RepairTrafficBytes))
+	a.RepairDiskReadBytes.Add(float64(result.RepairDiskReadBytes))
+	a.RepairSavedBytes.Add(float64(result.RepairSavedBytes))
+	a.MeanRepairHours.Add(result.MeanRepairHours)
+	a.MeanDegradedHours.Add(result.MeanDegradedHours)
+	if result.PowerDataLoss {
+		a.PowerDataLossIterations++
+	}
+	a.PowerOutageNum.Add(float64(result.PowerOutageNum))
+	a.PowerBlockedRatio.Add(result.PowerBlockedRatio)
+}
+
+// Merge 合并另一份汇总结果
+func (a *Aggregate) Merge(other *Aggregate) {
+	if other == nil {
+		return
+	}
+	a.Iterations += other.Iterations
+	a.DataLossIterations += other.DataLossIterations
+	a.LossEventNum.Merge(other.LossEventNum)
+	a.FailedStripesNum.Merge(other.FailedStripesNum)
+	a.LostChunkNum.Merge(other.LostChunkNum)
+	a.DataBytesLost.Merge(other.DataBytesLost)
+	a.ParityBytesLost.Merge(other.ParityBytesLost)
+	a.NOMDL.Merge(other.NOMDL)
+	a.ParityNOMDL.Merge(other.ParityNOMDL)
+	a.BlockedRatio.Merge(other.BlockedRatio)
+	a.SingleChunkRepairRatio.Merge(other.SingleChunkRepairRatio)
+	a.LocalRepairRatio.Merge(other.LocalRepairRatio)
+	a.RepairTrafficBytes.Merge(other.RepairTrafficBytes)
+	a.RepairDiskReadBytes.Merge(other.RepairDiskReadBytes)
+	a.RepairSavedBytes.Merge(other.RepairSavedBytes)
+	a.MeanRepairHours.Merge(other.MeanRepairHours)
+	a.MeanDegradedHours.Merge(other.MeanDegradedHours)
+	a.PowerDataLossIterations += other.PowerDataLossIterations
+	a.PowerOutageNum.Merge(other.PowerOutageNum)
+	a.PowerBlockedRatio.Merge(other.PowerBlockedRatio)
+}
+
+// PDL 发生数据丢失的迭代占比，即数据丢失概率
+func (a *Aggregate) PDL() float64 {
+	return a.mean(float64(a.DataLossIterations))
+}
+
+func (a *Aggregate) MeanLostChunkNum() float64 {
+	return a.mean(a.LostChunkNum.Sum)
+}
+
+func (a *Aggregate) MeanBlockedRatio() float64 {
+	return a.mean(a.BlockedRatio.Sum)
+}
+
+func (a *Aggregate) MeanSingleChunkRepairRatio() float64 {
+	return a.mean(a.SingleChunkRepairRatio.Sum)
+}
+
+func (a *Aggregate) MeanNOMDL() float64 {
+	return a.mean(a.NOMDL.Sum)
+}
+
+func (a *Aggregate) mean(sum float64) float64 {
+	if a.Iterations == 0 {
+		return 0
+	}
+	return sum / float64(a.Iterations)
+}
+
+// PDLEstimate 数据丢失概率及其 Wilson 置信区间，confidenceLevel<=0 时使用 DefaultConfidenceLevel
+func (a *Aggregate) PDLEstimate(confidenceLevel float64) Estimate {
+	confidenceLevel = normalizeConfidenceLevel(confidenceLevel)
+	lower, upper := util.WilsonInterval(a.DataLossIterations, a.Iterations, confidenceLevel)
+	return Estimate{Mean: a.PDL(), Lower: lower, Upper: upper, HalfWidth: (upper - lower) / 2}
+}
+
+// PowerPDLEstimate 由断电引起数据丢失的概率及其 Wilson 置信区间
+func (a *Aggregate) PowerPDLEstimate(confidenceLevel float64) Estimate {
+	confidenceLevel = normalizeConfidenceLevel(confidenceLevel)
+	lower, upper := util.WilsonInterval(a.PowerDataLossIterations, a.Iterations, confidenceLevel)
+	return Estimate{Mean: a.mean(float64(a.PowerDataLossIterations)), Lower: lower, Upper: upper, HalfWidth: (upper - lower) / 2}
+}
+
+// PDLRelativeError PDL 置信区间半宽相对于 PDL 的比值
+func (a *Aggregate) PDLRelativeError(confidenceLevel float64) float64 {
+	if a.DataLossIterations == 0 {
+		return math.Inf(1)
+	}
+	return a.PDLEstimate(confidenceLevel).HalfWidth / a.PDL()
+}
+
+// Converged 判断 PDL 的相对误差是否已低于目标值，targetRelativeError<=0 时表示不启用停止规则
+func (a *Aggregate) Converged(confidenceLevel, targetRelativeError float64) bool {
+	if targetRelativeError <= 0 || a.DataLossIterations < minDataLossIterationsToStop {
+		return false
+	}
+	return a.PDLRelativeError(confidenceLevel) < targetRelativeError
+}
+
+// Report 按置信水平 confidenceLevel 生成统计报告，confidenceLevel<=0 时使用 DefaultConfidenceLevel
+func (a *Aggregate) Report(confidenceLevel float64) *DurabilityReport {
+	confidenceLevel = normalizeConfidenceLevel(confidenceLevel)
+	return &DurabilityReport{
+		Iterations:             a.Iterations,
+		DataLossIterations:     a.DataLossIterations,
+		ConfidenceLevel:        confidenceLevel,
+		PDL:                    a.PDLEstimate(confidenceLevel),
+		PDLRelativeError:       a.PDLRelativeError(confidenceLevel),
+		LossEventNum:           a.LossEventNum.Estimate(a.Iterations, confidenceLevel),
+		FailedStripesNum:       a.FailedStripesNum.Estimate(a.Iterations, confidenceLevel),
+		LostChunkNum:           a.LostChunkNum.Estimate(a.Iterations, confidenceLevel),
+		DataBytesLost:          a.DataBytesLost.Estimate(a.Iterations, confidenceLevel),
+		ParityBytesLost:        a.ParityBytesLost.Estimate(a.Iterations, confidenceLevel),
+		NOMDL:                  a.NOMDL.Estimate(a.Iterations, confidenceLevel),
+		ParityNOMDL:            a.ParityNOMDL.Estimate(a.Iterations, confidenceLevel),
+		BlockedRatio:           a.BlockedRatio.Estimate(a.Iterations, confidenceLevel),
+		SingleChunkRepairRatio: a.SingleChunkRepairRatio.Estimate(a.Iterations, confidenceLevel),
+		LocalRepairRatio:       a.LocalRepairRatio.Estimate(a.Iterations, confidenceLevel),
+		RepairTrafficBytes:     a.RepairTrafficBytes.Estimate(a.Iterations, confidenceLevel),
+		RepairDiskReadBytes:    a.RepairDiskReadBytes.Estimate(a.Iterations, confidenceLevel),
+		RepairSavedBytes:       a.RepairSavedBytes.Estimate(a.Iterations, confidenceLevel),
+		MeanRepairHours:        a.MeanRepairHours.Estimate(a.Iterations, confidenceLevel),
+		MeanDegradedHours:      a.MeanDegradedHours.Estimate(a.Iterations, confidenceLevel),
+
+		PowerDataLossIterations: a.PowerDataLossIterations,
+		PowerPDL:                a.PowerPDLEstimate(confidenceLevel),
+		PowerOutageNum:          a.PowerOutageNum.Estimate(a.Iterations, confidenceLevel),
+		PowerBlockedRatio:       a.PowerBlockedRatio.Estimate(a.Iterations, confidenceLevel),
+	}
+}
+
+func normalizeConfidenceLevel(confidenceLevel float64) float64 {
+	if confidenceLevel <= 0 {
+		return DefaultConfidenceLevel
+	}
+	return confidenceLevel
+}
+
+func (r DurabilityReport) MarshalJSON() ([]byte, error) {
+	type report DurabilityReport
+	return json.Marshal(struct {
+		*report
+		PDLRelativeError jsonFloat
+	}{(*report)(&r), jsonFloat(r.PDLRelativeError)})
+}
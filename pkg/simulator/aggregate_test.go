@@ -0,0 +1,125 @@
+package simulator
+
+import (
+	"ECDC_SIM/internal/pkg/util"
+	"context"
+	"encoding/json"
+	"github.com/gogap/logrus"
+	"io"
+	"math"
+	"testing"
+)
+
+func TestAggregate_Report(t *testing.T) {
+	tests := []struct {
+		name      string
+		results   []*SimResult
+		wantPDL   float64
+		wantLost  float64
+		wantNOMDL float64
+	}{
+		{name: "TestNoLoss", results: []*SimResult{{}, {}, {}, {}}, wantPDL: 0, wantLost: 0},
+		{name: "TestHalfLoss", results: []*SimResult{
+			{DataLoss: true, FailedStripesNum: 1, LostChunkNum: 4, DataBytesLost: 3 << 20, NOMDL: 8},
+			{},
+			{DataLoss: true, FailedStripesNum: 1, LostChunkNum: 4, DataBytesLost: 3 << 20, NOMDL: 8},
+			{},
+		}, wantPDL: 0.5, wantLost: 2, wantNOMDL: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aggregate := NewAggregate()
+			for _, result := range tt.results {
+				aggregate.Add(result)
+			}
+			report := aggregate.Report(0)
+			if report.ConfidenceLevel != DefaultConfidenceLevel {
+				t.Errorf("Report() ConfidenceLevel = %v, want %v", report.ConfidenceLevel, DefaultConfidenceLevel)
+			}
+			if report.PDL.Mean != tt.wantPDL || report.LostChunkNum.Mean != tt.wantLost {
+				t.Errorf("Report() PDL = %v, LostChunkNum = %v, want %v, %v", report.PDL.Mean, report.LostChunkNum.Mean, tt.wantPDL, tt.wantLost)
+			}
+			if report.NOMDL.Mean != tt.wantNOMDL {
+				t.Errorf("Report() NOMDL = %v, want %v", report.NOMDL.Mean, tt.wantNOMDL)
+			}
+			if report.PDL.Lower > report.PDL.Mean || report.PDL.Upper < report.PDL.Mean {
+				t.Errorf("Report() PDL interval [%v, %v] does not contain %v", report.PDL.Lower, report.PDL.Upper, report.PDL.Mean)
+			}
+		})
+	}
+}
+
+func TestAggregate_Merge(t *testing.T) {
+	whole, first, second := NewAggregate(), NewAggregate(), NewAggregate()
+	results := []*SimResult{{DataLoss: true, LostChunkNum: 4, BlockedRatio: 0.125}, {BlockedRatio: 0.25}, {BlockedRatio: 0.5}}
+	for idx, result := range results {
+		whole.Add(result)
+		if idx == 0 {
+			first.Add(result)
+		} else {
+			second.Add(result)
+		}
+	}
+	first.Merge(second)
+	if *first != *whole {
+		t.Errorf("Merge() = %+v, want %+v", first, whole)
+	}
+}
+
+func TestAggregate_Converged(t *testing.T) {
+	tests := []struct {
+		name   string
+		loss   int
+		total  int
+		target float64
+		want   bool
+	}{
+		{name: "TestDisabled", loss: 500, total: 1000, target: 0, want: false},
+		{name: "TestTooFewLosses", loss: 5, total: 5, target: 0.5, want: false},
+		{name: "TestReached", loss: 500, total: 1000, target: 0.1, want: true},
+		{name: "TestNotReached", loss: 20, total: 1000, target: 0.1, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aggregate := &Aggregate{Iterations: tt.total, DataLossIterations: tt.loss}
+			if got := aggregate.Converged(DefaultConfidenceLevel, tt.target); got != tt.want {
+				t.Errorf("Converged() = %v, want %v, relative error %v", got, tt.want, aggregate.PDLRelativeError(DefaultConfidenceLevel))
+			}
+		})
+	}
+	if got := NewAggregate().PDLRelativeError(DefaultConfidenceLevel); !math.IsInf(got, 1) {
+		t.Errorf("PDLRelativeError() = %v, want +Inf", got)
+	}
+}
+
+func TestSimulator_RunStoppingRule(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	dcConf, ecConf, rConf := newTestConf()
+	dcConf.StripesNum, dcConf.ChunkNum = 20, 20*ecConf.N
+	dcConf.DFailD = util.NewWeibull(1, 2000, 0)
+	dcConf.MaxCrossRackRepairBandwidth = 0.01
+	rConf.TargetRelativeError = 0.5
+	got, err := mustNewSimulator(t, dcConf, ecConf, rConf).Run(context.Background(), 10000, 4)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if got.Iterations == 10000 || !got.Converged(DefaultConfidenceLevel, rConf.TargetRelativeError) {
+		t.Errorf("Run() did not stop early, report=%+v", got.Report(0))
+	}
+}
+
+func TestDurabilityReport_MarshalJSON(t *testing.T) {
+	aggregate := NewAggregate()
+	aggregate.Add(&SimResult{BlockedRatio: 0.5})
+	data, err := json.Marshal(aggregate.Report(0))
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var got map[string]interface{}
+	if err = json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if got["PDLRelativeError"] != nil || got["Iterations"] != float64(1) {
+		t.Errorf("json.Marshal() = %s", data)
+	}
+}
@@ -0,0 +1,92 @@
+package simulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gogap/logrus"
+	"os"
+	"path/filepath"
+)
+
+// checkpointVersion 检查点格式版本，格式或模拟语义变化时递增，旧版本的检查点不能用于恢复
+const checkpointVersion = 11
+
+// CheckpointHandler 接收检查点时刻的汇总结果副本
+type CheckpointHandler func(aggregate *Aggregate) error
+
+// saveCheckpoint 以汇总结果的副本调用检查点回调，避免回调持有 Run 继续修改的结果
+func (s *Simulator) saveCheckpoint(aggregate *Aggregate) error {
+	snapshot := *aggregate
+	logrus.Infof("[Simulator.saveCheckpoint] checkpoint after %d iterations", snapshot.Iterations)
+	return s.checkpoint(&snapshot)
+}
+
+// Checkpoint 长时间运行的检查点。每次迭代的随机源只由种子与迭代序号决定，
+// 因此已完成的迭代数（Aggregate.Iterations）与种子即可完整描述随机数生成器的状态
+type Checkpoint struct {
+	Version    int
+	ConfigHash string
+	Seed       int64
+	Aggregate  *Aggregate
+	// RecordsOffset 检查点时逐迭代结果文件已写入的字节数，恢复时截断到该长度后继续追加，为 0 时表示未导出
+	RecordsOffset int64
+	// EventsOffset 检查点时事件日志已写入的字节数，用法与 RecordsOffset 相同
+	EventsOffset int64
+}
+
+func NewCheckpoint(configHash string, seed int64, aggregate *Aggregate) *Checkpoint {
+	return &Checkpoint{Version: checkpointVersion, ConfigHash: configHash, Seed: seed, Aggregate: aggregate}
+}
+
+// Save 写入检查点文件，先写临时文件再重命名，进程在写入过程中退出时不会破坏上一个检查点
+func (c *Checkpoint) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(data); err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadCheckpoint 读取检查点文件
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	checkpoint := new(Checkpoint)
+	if err = json.Unmarshal(data, checkpoint); err != nil {
+		return nil, fmt.Errorf("checkpoint %s: %w", path, err)
+	}
+	if checkpoint.Version != checkpointVersion {
+		return nil, fmt.Errorf("checkpoint %s: version %d, want %d", path, checkpoint.Version, checkpointVersion)
+	}
+	if checkpoint.Aggregate == nil {
+		return nil, fmt.Errorf("checkpoint %s: missing aggregate", path)
+	}
+	return checkpoint, nil
+}
+
+// Check 检查检查点是否属于给定的配置与种子
+func (c *Checkpoint) Check(configHash string, seed int64) error {
+	if c.ConfigHash != configHash {
+		return fmt.Errorf("checkpoint config hash %s does not match the configuration %s", c.ConfigHash, configHash)
+	}
+	if c.Seed != seed {
+		return fmt.Errorf("checkpoint seed %d does not match the seed %d", c.Seed, seed)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package simulator
+
+import (
+	"ECDC_SIM/internal/pkg/util"
+	"context"
+	"github.com/gogap/logrus"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCheckpoint_SaveLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "run.ckpt")
+	aggregate := NewAggregate()
+	aggregate.Add(&SimResult{DataLoss: true, LostChunkNum: 4, NOMDL: 0.1, BlockedRatio: 1e-7})
+	aggregate.Add(&SimResult{BlockedRatio: 3e-7})
+	checkpoint := NewCheckpoint("abc", 7, aggregate)
+	checkpoint.RecordsOffset = 42
+	if err := checkpoint.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	got, err := LoadCheckpoint(path)
+	if err != nil {
+		t.Fatalf("LoadCheckpoint() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, checkpoint) {
+		t.Errorf("LoadCheckpoint() = %+v, want %+v", got, checkpoint)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("Save() left %d files, want 1", len(entries))
+	}
+
+	tests := []struct {
+		name       string
+		configHash string
+		seed       int64
+		wantErr    bool
+	}{
+		{name: "TestMatch", configHash: "abc", seed: 7},
+		{name: "TestOtherConfig", configHash: "abd", seed: 7, wantErr: true},
+		{name: "TestOtherSeed", configHash: "abc", seed: 8, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := got.Check(tt.configHash, tt.seed); (err != nil) != tt.wantErr {
+				t.Errorf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	checkpoint.Version = checkpointVersion + 1
+	if err = checkpoint.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = LoadCheckpoint(path); err == nil {
+		t.Error("LoadCheckpoint() with another version want error")
+	}
+	if _, err = LoadCheckpoint(filepath.Join(dir, "missing")); err == nil {
+		t.Error("LoadCheckpoint() of a missing file want error")
+	}
+}
+
+func TestSimulator_Resume(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	dcConf, ecConf, rConf := newTestConf()
+	dcConf.DFailD = util.NewWeibull(1, 2000, 0)
+	rConf.Seed = 11
+	want, err := mustNewSimulator(t, dcConf, ecConf, rConf).Run(context.Background(), 12, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "run.ckpt")
+	sim := mustNewSimulator(t, dcConf, ecConf, rConf)
+	var checkpoints []int
+	sim.SetCheckpointHandler(0, func(aggregate *Aggregate) error {
+		checkpoints = append(checkpoints, aggregate.Iterations)
+		return NewCheckpoint("abc", rConf.Seed, aggregate).Save(path)
+	})
+	if _, err = sim.Run(context.Background(), 5, 2); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(checkpoints, []int{1, 2, 3, 4, 5, 5}) {
+		t.Errorf("checkpoints = %v, want one per iteration and one at the end", checkpoints)
+	}
+
+	checkpoint, err := LoadCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := mustNewSimulator(t, dcConf, ecConf, rConf).Resume(context.Background(), checkpoint.Aggregate, 12, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Resume() = %+v, want %+v", got, want)
+	}
+	again, err := mustNewSimulator(t, dcConf, ecConf, rConf).Resume(context.Background(), got, 12, 4)
+	if err != nil || !reflect.DeepEqual(again, want) {
+		t.Errorf("Resume() of a finished run = %+v, %v, want %+v", again, err, want)
+	}
+}
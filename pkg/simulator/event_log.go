@@ -0,0 +1,86 @@
+package simulator
+
+import (
+	"ECDC_SIM/internal/pkg/event_trigger"
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// EventHandler 在 Run 中按迭代序号依次接收每次迭代处理的事件记录及其结果，返回错误时 Run 停止并返回该错误
+type EventHandler func(iteration int, events []*event_trigger.EventRecord, result *SimResult) error
+
+// SetEventHandler 设置 Run 的事件记录回调。设置后每次迭代都记录处理的全部事件，会明显降低模拟速度
+func (s *Simulator) SetEventHandler(handler EventHandler) {
+	s.onEvents = handler
+	s.recordEvents = handler != nil
+}
+
+// IterationEvent 事件日志中的一行，即第 Iteration 次迭代处理的一个事件
+type IterationEvent struct {
+	Iteration int `json:"iteration"`
+	*event_trigger.EventRecord
+}
+
+// WriteEventLog 以每行一个事件的 NDJSON 输出一次迭代的事件记录
+func WriteEventLog(w io.Writer, iteration int, events []*event_trigger.EventRecord) error {
+	encoder := json.NewEncoder(w)
+	for _, event := range events {
+		if err := encoder.Encode(&IterationEvent{Iteration: iteration, EventRecord: event}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadEventLog 从事件日志中读取第 iteration 次迭代的事件记录，日志中没有该迭代时返回错误
+func ReadEventLog(r io.Reader, iteration int) ([]*event_trigger.EventRecord, error) {
+	events := make([]*event_trigger.EventRecord, 0)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		event := new(IterationEvent)
+		if err := json.Unmarshal(scanner.Bytes(), event); err != nil {
+			return nil, fmt.Errorf("event log line %d: %w", line, err)
+		}
+		if event.Iteration == iteration && event.EventRecord != nil {
+			events = append(events, event.EventRecord)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("event log has no events of iteration %d", iteration)
+	}
+	return events, nil
+}
+
+// ReplayIteration 以事件记录代替事件队列重新执行第 iteration 次迭代，返回迭代结果与重放时的事件记录。
+// 数据放置仍由种子与迭代序号决定，因此配置与种子需与记录时相同
+func (s *Simulator) ReplayIteration(ctx context.Context, iteration int, events []*event_trigger.EventRecord) (*SimResult, []*event_trigger.EventRecord, error) {
+	if events == nil {
+		events = make([]*event_trigger.EventRecord, 0)
+	}
+	if err := s.eventManager.SetReplay(events); err != nil {
+		return nil, nil, err
+	}
+	recordEvents := s.recordEvents
+	s.recordEvents = true
+	defer func() {
+		s.recordEvents = recordEvents
+		_ = s.eventManager.SetReplay(nil)
+	}()
+	result, err := s.RunIteration(ctx, iteration)
+	if err != nil {
+		return nil, nil, err
+	}
+	replayed := result.events
+	result.events = nil
+	return result, replayed, nil
+}
@@ -0,0 +1,152 @@
+package simulator
+
+import (
+	"ECDC_SIM/internal/pkg/data_center"
+	"ECDC_SIM/internal/pkg/event_trigger"
+	"ECDC_SIM/internal/pkg/util"
+	"bytes"
+	"context"
+	"github.com/gogap/logrus"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSimulator_ReplayIteration(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	tests := []struct {
+		name       string
+		useNetwork bool
+		transient  bool
+		stripe     bool
+		policy     event_trigger.RepairPolicy
+		sharing    data_center.BandwidthSharing
+		power      bool
+		slots      int
+	}{
+		{name: "TestNetwork", useNetwork: true},
+		{name: "TestRepairDistribution", useNetwork: false},
+		{name: "TestTransientFailure", useNetwork: true, transient: true},
+		{name: "TestStripeRepair", useNetwork: true, stripe: true},
+		{name: "TestBatchedRepair", useNetwork: true, stripe: true, policy: event_trigger.RepairBatched},
+		{name: "TestBandwidthSharing", useNetwork: true, sharing: data_center.BandwidthWeighted},
+		{name: "TestPowerUpFailure", useNetwork: true, power: true},
+		{name: "TestConcurrentStripeRepair", useNetwork: true, stripe: true, sharing: data_center.BandwidthMaxMin, slots: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dcConf, ecConf, rConf := newTestConf()
+			dcConf.DFailD = util.NewWeibull(1, 2000, 0)
+			dcConf.UseNetwork, dcConf.BandwidthSharing = tt.useNetwork, tt.sharing
+			dcConf.DRepairD = util.NewWeibull(1, 24, 0)
+			rConf.Seed, rConf.EnableTransientFailure, rConf.StripeRepair = 5, tt.transient, tt.stripe
+			rConf.RepairPolicy, rConf.RepairBatchInterval, rConf.StripeRepairSlots = tt.policy, 24, tt.slots
+			if tt.power {
+				// 恢复供电时抽样的永久故障设备在回放时应与记录时相同
+				dcConf.POutageD, dcConf.PRestoreD = util.NewWeibull(1, 2000, 0), util.NewWeibull(1, 12, 0)
+				dcConf.PowerDomainsNum, dcConf.PowerUpFailure = 3, data_center.PowerUpFailure{NodeRatio: 0.1, DiskRatio: 0.2}
+				rConf.UsePowerOutage, rConf.ContinueAfterLoss = true, true
+			}
+			if tt.stripe {
+				// 按条带修复时每个条带的修复都是一个事件，缩短任务时间以控制事件数
+				dcConf.MissionTime = 8760
+			}
+			sim := mustNewSimulator(t, dcConf, ecConf, rConf)
+			recorded := make(map[int][]*event_trigger.EventRecord)
+			results := make(map[int]*SimResult)
+			sim.SetEventHandler(func(iteration int, events []*event_trigger.EventRecord, result *SimResult) error {
+				recorded[iteration], results[iteration] = events, result
+				return nil
+			})
+			if _, err := sim.Run(context.Background(), 4, 2); err != nil {
+				t.Fatal(err)
+			}
+
+			buf := new(bytes.Buffer)
+			for iteration := 0; iteration < 4; iteration++ {
+				if len(recorded[iteration]) != results[iteration].EventNum {
+					t.Errorf("iteration %d recorded %d events, handled %d", iteration, len(recorded[iteration]), results[iteration].EventNum)
+				}
+				if err := WriteEventLog(buf, iteration, recorded[iteration]); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for iteration := 0; iteration < 4; iteration++ {
+				events, err := ReadEventLog(bytes.NewReader(buf.Bytes()), iteration)
+				if err != nil {
+					t.Fatal(err)
+				}
+				replaySim := mustNewSimulator(t, dcConf, ecConf, rConf)
+				got, replayed, err := replaySim.ReplayIteration(context.Background(), iteration, events)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(got, results[iteration]) {
+					t.Errorf("iteration %d replay = %+v, want %+v", iteration, got, results[iteration])
+				}
+				if !reflect.DeepEqual(replayed, recorded[iteration]) {
+					t.Errorf("iteration %d replayed events differ from the recorded events", iteration)
+				}
+				// 回放结束后恢复正常模拟
+				if got, err = replaySim.RunIteration(context.Background(), iteration); err != nil || !reflect.DeepEqual(got, results[iteration]) {
+					t.Errorf("iteration %d after replay = %+v, %v, want %+v", iteration, got, err, results[iteration])
+				}
+			}
+		})
+	}
+}
+
+func TestEventRecord_Changes(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	dcConf, ecConf, rConf := newTestConf()
+	sim := mustNewSimulator(t, dcConf, ecConf, rConf)
+	events := []*event_trigger.EventRecord{
+		{Time: 1, Event: "NodeTransientFail", Devices: []int{4}},
+		{Time: 2, Event: "NodeTransientRepair", Devices: []int{4}},
+		{Time: 3, Event: "DiskFail", Devices: []int{7}},
+	}
+	_, replayed, err := sim.ReplayIteration(context.Background(), 0, events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]event_trigger.StateChange{
+		{{Device: "Disk", Id: 4, From: "Normal", To: "Unavailable"}, {Device: "Node", Id: 4, From: "Normal", To: "Unavailable"}},
+		{{Device: "Disk", Id: 4, From: "Unavailable", To: "Normal"}, {Device: "Node", Id: 4, From: "Unavailable", To: "Normal"}},
+		{{Device: "Disk", Id: 7, From: "Normal", To: "Crashed"}},
+		{},
+	}
+	if len(replayed) != len(want) || replayed[len(want)-1].Event != "MissionEnd" {
+		t.Fatalf("replayed %d events, want %d ending with MissionEnd", len(replayed), len(want))
+	}
+	for idx, event := range replayed {
+		if !reflect.DeepEqual(event.Changes, want[idx]) {
+			t.Errorf("event %d changes = %+v, want %+v", idx, event.Changes, want[idx])
+		}
+	}
+
+	if _, _, err = sim.ReplayIteration(context.Background(), 0, []*event_trigger.EventRecord{{Event: "Reboot"}}); err == nil {
+		t.Error("ReplayIteration() with an unknown event want error")
+	}
+}
+
+func TestReadEventLog(t *testing.T) {
+	log := `{"iteration":1,"time":2,"event":"DiskFail","devices":[3],"changes":[]}
+
+{"iteration":2,"time":5,"event":"DiskRepair","devices":[3],"bandwidth":[125],"changes":[]}
+`
+	got, err := ReadEventLog(strings.NewReader(log), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*event_trigger.EventRecord{{Time: 5, Event: "DiskRepair", Devices: []int{3}, Bandwidth: []float64{125}, Changes: []event_trigger.StateChange{}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadEventLog() = %+v, want %+v", got, want)
+	}
+	if _, err = ReadEventLog(strings.NewReader(log), 3); err == nil {
+		t.Error("ReadEventLog() of a missing iteration want error")
+	}
+	if _, err = ReadEventLog(strings.NewReader("{bad\n"), 0); err == nil {
+		t.Error("ReadEventLog() of a malformed line want error")
+	}
+}
@@ -0,0 +1,168 @@
+package simulator
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// IterationRecord 一次迭代的导出记录，带有配置哈希、随机种子与迭代序号，可据此复现该次迭代
+type IterationRecord struct {
+	ConfigHash string
+	Seed       int64
+	Iteration  int
+	*SimResult
+}
+
+func NewIterationRecord(configHash string, seed int64, iteration int, result *SimResult) *IterationRecord {
+	return &IterationRecord{ConfigHash: configHash, Seed: seed, Iteration: iteration, SimResult: result}
+}
+
+// csvHeader CSV 的列名，与 IterationRecord.csvRow 的顺序一致。LossEvents 只在 NDJSON 中输出，CSV 中只给出事件数
+var csvHeader = []string{
+	"ConfigHash", "Seed", "Iteration", "DataLoss", "LossEventNum", "FailedStripesNum", "LostChunkNum", "LostDataChunkNum",
+	"LostParityChunkNum", "DataBytesLost", "ParityBytesLost", "NOMDL", "ParityNOMDL", "BlockedRatio", "SingleChunkRepairRatio",
+	"LocalRepairRatio", "RepairTrafficBytes", "RepairDiskReadBytes", "RepairSavedBytes", "MeanRepairHours", "MeanDegradedHours",
+	"PowerOutageNum", "PowerBlockedRatio", "PowerDataLoss",
+}
+
+func (r *IterationRecord) csvRow() []string {
+	formatFloat := func(f float64) string {
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	return []string{
+		r.ConfigHash, strconv.FormatInt(r.Seed, 10), strconv.Itoa(r.Iteration), strconv.FormatBool(r.DataLoss),
+		strconv.Itoa(len(r.LossEvents)), strconv.Itoa(r.FailedStripesNum), strconv.Itoa(r.LostChunkNum),
+		strconv.Itoa(r.LostDataChunkNum), strconv.Itoa(r.LostParityChunkNum), strconv.FormatInt(r.DataBytesLost, 10),
+		strconv.FormatInt(r.ParityBytesLost, 10), formatFloat(r.NOMDL), formatFloat(r.ParityNOMDL),
+		formatFloat(r.BlockedRatio), formatFloat(r.SingleChunkRepairRatio), formatFloat(r.LocalRepairRatio),
+		strconv.FormatInt(r.RepairTrafficBytes, 10), strconv.FormatInt(r.RepairDiskReadBytes, 10),
+		strconv.FormatInt(r.RepairSavedBytes, 10), formatFloat(r.MeanRepairHours), formatFloat(r.MeanDegradedHours),
+		strconv.Itoa(r.PowerOutageNum), formatFloat(r.PowerBlockedRatio), strconv.FormatBool(r.PowerDataLoss),
+	}
+}
+
+func (r *IterationRecord) MarshalJSON() ([]byte, error) {
+	lossEvents := r.LossEvents
+	if lossEvents == nil {
+		lossEvents = []*LossEvent{}
+	}
+	return json.Marshal(struct {
+		ConfigHash             string
+		Seed                   int64
+		Iteration              int
+		DataLoss               bool
+		FailedStripesNum       int
+		LostChunkNum           int
+		LostDataChunkNum       int
+		LostParityChunkNum     int
+		DataBytesLost          int64
+		ParityBytesLost        int64
+		NOMDL                  jsonFloat
+		ParityNOMDL            jsonFloat
+		BlockedRatio           jsonFloat
+		SingleChunkRepairRatio jsonFloat
+		LocalRepairRatio       jsonFloat
+		RepairTrafficBytes     int64
+		RepairDiskReadBytes    int64
+		RepairSavedBytes       int64
+		MeanRepairHours        jsonFloat
+		MeanDegradedHours      jsonFloat
+		PowerOutageNum         int
+		PowerBlockedRatio      jsonFloat
+		PowerDataLoss          bool
+		LossEvents             []*LossEvent
+	}{
+		r.ConfigHash, r.Seed, r.Iteration, r.DataLoss, r.FailedStripesNum, r.LostChunkNum, r.LostDataChunkNum,
+		r.LostParityChunkNum, r.DataBytesLost, r.ParityBytesLost, jsonFloat(r.NOMDL), jsonFloat(r.ParityNOMDL),
+		jsonFloat(r.BlockedRatio), jsonFloat(r.SingleChunkRepairRatio), jsonFloat(r.LocalRepairRatio), r.RepairTrafficBytes,
+		r.RepairDiskReadBytes, r.RepairSavedBytes, jsonFloat(r.MeanRepairHours), jsonFloat(r.MeanDegradedHours), r.PowerOutageNum,
+		jsonFloat(r.PowerBlockedRatio), r.PowerDataLoss, lossEvents,
+	})
+}
+
+// RecordWriter 逐条输出迭代记录，Flush 将缓冲的内容写入底层 io.Writer
+type RecordWriter interface {
+	Write(record *IterationRecord) error
+	Flush() error
+}
+
+// NewRecordWriter 按格式创建 RecordWriter，format 为 ndjson 或 csv
+func NewRecordWriter(w io.Writer, format string) (RecordWriter, error) {
+	switch format {
+	case "ndjson":
+		return NewNDJSONWriter(w), nil
+	case "csv":
+		return NewCSVWriter(w), nil
+	default:
+		return nil, fmt.Errorf("unknown record format %q, want ndjson or csv", format)
+	}
+}
+
+// AppendRecordWriter 与 NewRecordWriter 相同，用于向已有记录的文件继续追加，CSV 不再重复输出表头
+func AppendRecordWriter(w io.Writer, format string) (RecordWriter, error) {
+	writer, err := NewRecordWriter(w, format)
+	if csvWriter, ok := writer.(*CSVWriter); ok {
+		csvWriter.wroteHeader = true
+	}
+	return writer, err
+}
+
+// NDJSONWriter 每行输出一条 JSON 记录
+type NDJSONWriter struct {
+	encoder *json.Encoder
+}
+
+func NewNDJSONWriter(w io.Writer) *NDJSONWriter {
+	return &NDJSONWriter{encoder: json.NewEncoder(w)}
+}
+
+func (w *NDJSONWriter) Write(record *IterationRecord) error {
+	return w.encoder.Encode(record)
+}
+
+func (w *NDJSONWriter) Flush() error {
+	return nil
+}
+
+// CSVWriter 以 CSV 输出记录，首次写入前输出表头
+type CSVWriter struct {
+	writer      *csv.Writer
+	wroteHeader bool
+}
+
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	return &CSVWriter{writer: csv.NewWriter(w)}
+}
+
+func (w *CSVWriter) Write(record *IterationRecord) error {
+	if !w.wroteHeader {
+		if err := w.writer.Write(csvHeader); err != nil {
+			return err
+		}
+		w.wroteHeader = true
+	}
+	return w.writer.Write(record.csvRow())
+}
+
+func (w *CSVWriter) Flush() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+// Summary 汇总结果的导出格式
+type Summary struct {
+	ConfigHash         string
+	Seed               int64
+	RackFaultTolerance int // 每个条带可容忍同时故障的机架数，由放置方式决定
+	Report             *DurabilityReport
+}
+
+// WriteSummary 以缩进的 JSON 输出汇总结果
+func WriteSummary(w io.Writer, summary *Summary) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(summary)
+}
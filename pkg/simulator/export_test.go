@@ -0,0 +1,129 @@
+package simulator
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"github.com/gogap/logrus"
+	"io"
+	"math"
+	"strings"
+	"testing"
+)
+
+func newTestRecord() *IterationRecord {
+	result := &SimResult{BlockedRatio: math.NaN(), SingleChunkRepairRatio: 0.5}
+	result.addLossEvent(&LossEvent{Time: 10, FailedStripes: []int{3}, LostChunkNum: 4, LostDataChunkNum: 3, LostParityChunkNum: 1,
+		DataBytesLost: 3 << 20, ParityBytesLost: 1 << 20}, 1)
+	return NewIterationRecord("abc", 7, 2, result)
+}
+
+func TestNDJSONWriter(t *testing.T) {
+	buf := new(bytes.Buffer)
+	w, err := NewRecordWriter(buf, "ndjson")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, record := range []*IterationRecord{newTestRecord(), NewIterationRecord("abc", 7, 3, new(SimResult))} {
+		if err = w.Write(record); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err = w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %s", len(lines), buf)
+	}
+	var got struct {
+		ConfigHash   string
+		Seed         int64
+		Iteration    int
+		DataLoss     bool
+		LostChunkNum int
+		BlockedRatio *float64
+		LossEvents   []*LossEvent
+	}
+	if err = json.Unmarshal([]byte(lines[0]), &got); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v, line=%s", err, lines[0])
+	}
+	if got.ConfigHash != "abc" || got.Seed != 7 || got.Iteration != 2 || !got.DataLoss || got.LostChunkNum != 4 ||
+		got.BlockedRatio != nil || len(got.LossEvents) != 1 || got.LossEvents[0].Time != 10 {
+		t.Errorf("record = %+v", got)
+	}
+	if !strings.Contains(lines[1], `"LossEvents":[]`) {
+		t.Errorf("record without loss = %s, want empty LossEvents", lines[1])
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	buf := new(bytes.Buffer)
+	w, err := NewRecordWriter(buf, "csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Write(newTestRecord()); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err = w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || len(rows[0]) != len(rows[1]) {
+		t.Fatalf("rows = %v", rows)
+	}
+	got := make(map[string]string)
+	for idx, name := range rows[0] {
+		got[name] = rows[1][idx]
+	}
+	want := map[string]string{"ConfigHash": "abc", "Seed": "7", "Iteration": "2", "DataLoss": "true", "LossEventNum": "1",
+		"LostChunkNum": "4", "DataBytesLost": "3145728", "NOMDL": "3.145728e+06", "BlockedRatio": "NaN"}
+	for name, value := range want {
+		if got[name] != value {
+			t.Errorf("%s = %s, want %s", name, got[name], value)
+		}
+	}
+	if _, err = NewRecordWriter(buf, "xml"); err == nil {
+		t.Error("NewRecordWriter(xml) want error")
+	}
+}
+
+func TestSimulator_SetResultHandler(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	dcConf, ecConf, rConf := newTestConf()
+	sim := mustNewSimulator(t, dcConf, ecConf, rConf)
+	var iterations []int
+	sim.SetResultHandler(func(iteration int, result *SimResult) error {
+		iterations = append(iterations, iteration)
+		return nil
+	})
+	if _, err := sim.Run(context.Background(), 6, 3); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	for idx, iteration := range iterations {
+		if idx != iteration {
+			t.Fatalf("handler iterations = %v, want 0..5 in order", iterations)
+		}
+	}
+	if len(iterations) != 6 {
+		t.Fatalf("handler called %d times, want 6", len(iterations))
+	}
+
+	handlerErr := errors.New("disk full")
+	sim.SetResultHandler(func(iteration int, result *SimResult) error {
+		if iteration == 1 {
+			return handlerErr
+		}
+		return nil
+	})
+	got, err := sim.Run(context.Background(), 6, 3)
+	if !errors.Is(err, handlerErr) || got.Iterations != 2 {
+		t.Errorf("Run() = %d iterations, %v, want 2, %v", got.Iterations, err, handlerErr)
+	}
+}
@@ -0,0 +1,51 @@
+package simulator
+
+import (
+	"fmt"
+	"time"
+)
+
+// ctxCheckEvents RunIteration 每处理该数量的事件检查一次 ctx 是否被取消
+const ctxCheckEvents = 256
+
+// ProgressHandler 接收 Run 的进度
+type ProgressHandler func(progress Progress)
+
+// Progress Run 的运行进度，统计只包含已按序合并的迭代
+type Progress struct {
+	Iterations         int // 已完成的迭代数，从检查点恢复时包含检查点之前的迭代
+	TargetIterations   int
+	DataLossIterations int
+	Events             int64 // 本次运行处理的事件数
+	Elapsed            time.Duration
+	EventsPerSecond    float64
+	PDL                Estimate
+
+	startIterations int
+	startTime       time.Time
+}
+
+// update 用当前的汇总结果更新进度并返回其副本
+func (p *Progress) update(aggregate *Aggregate, confidenceLevel float64) Progress {
+	p.Iterations, p.DataLossIterations = aggregate.Iterations, aggregate.DataLossIterations
+	p.Elapsed = time.Since(p.startTime)
+	if seconds := p.Elapsed.Seconds(); seconds > 0 {
+		p.EventsPerSecond = float64(p.Events) / seconds
+	}
+	p.PDL = aggregate.PDLEstimate(confidenceLevel)
+	return *p
+}
+
+// IterationsPerSecond 本次运行的迭代速度
+func (p Progress) IterationsPerSecond() float64 {
+	if seconds := p.Elapsed.Seconds(); seconds > 0 {
+		return float64(p.Iterations-p.startIterations) / seconds
+	}
+	return 0
+}
+
+func (p Progress) String() string {
+	return fmt.Sprintf("iterations %d/%d, %.3g iterations/s, %.3g events/s, data loss %d, PDL %.4g [%.4g, %.4g], elapsed %v",
+		p.Iterations, p.TargetIterations, p.IterationsPerSecond(), p.EventsPerSecond, p.DataLossIterations,
+		p.PDL.Mean, p.PDL.Lower, p.PDL.Upper, p.Elapsed.Round(time.Second))
+}
@@ -0,0 +1,75 @@
+package simulator
+
+import (
+	"context"
+	"errors"
+	"github.com/gogap/logrus"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSimulator_RunIterationCanceled(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	dcConf, ecConf, rConf := newTestConf()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	got, err := mustNewSimulator(t, dcConf, ecConf, rConf).RunIteration(ctx, 0)
+	if got != nil || !errors.Is(err, context.Canceled) {
+		t.Errorf("RunIteration() = %+v, %v, want context.Canceled", got, err)
+	}
+}
+
+func TestSimulator_RunTimeBudget(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	dcConf, ecConf, rConf := newTestConf()
+	rConf.TimeBudget = 300 * time.Millisecond
+	start := time.Now()
+	got, err := mustNewSimulator(t, dcConf, ecConf, rConf).Run(context.Background(), 1000000, 2)
+	if err != nil {
+		t.Fatalf("Run() error = %v, want a clean stop", err)
+	}
+	if got.Iterations >= 1000000 {
+		t.Errorf("Run() iterations = %d, want the budget to stop it", got.Iterations)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Run() took %v with a budget of %v", elapsed, rConf.TimeBudget)
+	}
+}
+
+func TestSimulator_RunDeadline(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	dcConf, ecConf, rConf := newTestConf()
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	got, err := mustNewSimulator(t, dcConf, ecConf, rConf).Run(ctx, 1000000, 2)
+	if !errors.Is(err, context.DeadlineExceeded) || got == nil || got.Iterations >= 1000000 {
+		t.Errorf("Run() = %+v, %v, want partial aggregate and context.DeadlineExceeded", got, err)
+	}
+}
+
+func TestSimulator_SetProgressHandler(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	dcConf, ecConf, rConf := newTestConf()
+	sim := mustNewSimulator(t, dcConf, ecConf, rConf)
+	var reports []Progress
+	sim.SetProgressHandler(0, func(progress Progress) {
+		reports = append(reports, progress)
+	})
+	if _, err := sim.Run(context.Background(), 5, 2); err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 6 {
+		t.Fatalf("progress reported %d times, want one per iteration and one at the end", len(reports))
+	}
+	for idx, progress := range reports[:5] {
+		if progress.Iterations != idx+1 || progress.TargetIterations != 5 || progress.Events <= 0 {
+			t.Errorf("progress[%d] = %+v", idx, progress)
+		}
+	}
+	last := reports[5]
+	if last.Iterations != 5 || last.Events < reports[4].Events || last.EventsPerSecond <= 0 || !strings.Contains(last.String(), "iterations 5/5") {
+		t.Errorf("last progress = %s", last)
+	}
+}
@@ -2,43 +2,172 @@ package simulator
 
 import (
 	"ECDC_SIM/internal/pkg/data_center"
+	"ECDC_SIM/internal/pkg/enum_error"
 	"ECDC_SIM/internal/pkg/event_trigger"
+	"ECDC_SIM/internal/pkg/util"
+	"context"
+	"errors"
 	"github.com/gogap/logrus"
+	"math"
+	"math/rand"
+	"runtime"
+	"sync"
+	"time"
 )
 
 type Simulator struct {
 	dcConf       *data_center.DCConf
 	ecConf       *data_center.ErasureCodeConf
 	runningConf  *event_trigger.RunningConfig
+	dcManager    *data_center.DCManager
 	eventManager *event_trigger.EventManager
+	onResult     ResultHandler
+	checkpoint   CheckpointHandler
+	// checkpointInterval 两次检查点之间的最短时间间隔
+	checkpointInterval time.Duration
+	progress           ProgressHandler
+	progressInterval   time.Duration
+	onEvents           EventHandler
+	recordEvents       bool // 迭代时记录处理的事件，fork 出的模拟器同样记录
 }
 
+// ResultHandler 在 Run 中按迭代序号依次接收每次迭代的结果，返回错误时 Run 停止并返回该错误
+type ResultHandler func(iteration int, result *SimResult) error
+
 type SimResult struct {
+	DataLoss               bool
 	FailedStripesNum       int
 	LostChunkNum           int
+	LostDataChunkNum       int
+	LostParityChunkNum     int
+	DataBytesLost          int64   // 丢失的用户数据字节数，每个数据块 B/K 字节
+	ParityBytesLost        int64   // 丢失块中除用户数据外的冗余字节数，与 DataBytesLost 之和为丢失块的总字节数
+	NOMDL                  float64 // 每 TB 用户数据丢失的数据字节数
+	ParityNOMDL            float64 // 每 TB 用户数据丢失的校验字节数
 	BlockedRatio           float64
 	SingleChunkRepairRatio float64
+	LocalRepairRatio       float64      // 读取少于 K 个块（如 LRC 局部组）完成修复的条带占修复条带的比例
+	RepairTrafficBytes     int64        // 修复读取的字节数
+	RepairDiskReadBytes    int64        // 修复时协助块从磁盘读取的字节数，再生码的协助块可能读取多于传输的数据
+	RepairSavedBytes       int64        // 按条带修复时丢失多个块的条带一并读取 K 个块重建，比用同一组可用块逐个修复各丢失块少读取的字节数，不与 EAGER 策略比较
+	MeanRepairHours        float64      // 磁盘修复的平均用时（小时），从开始修复算起，独占带宽时不含排队时间
+	MeanDegradedHours      float64      // 条带从首个块丢失到全部块修复的平均用时（小时），按磁盘修复时条带要等整块磁盘修复完成
+	EventNum               int          // 本次迭代处理的事件数
+	LossEvents             []*LossEvent // 数据丢失事件，默认只包含首次丢失，ContinueAfterLoss 模式下包含任务期内的全部丢失
+	PowerOutageNum         int          // UsePowerOutage 模式下发生的断电次数
+	PowerBlockedRatio      float64      // 断电造成的离线时间占比，已包含在 BlockedRatio 中
+	PowerDataLoss          bool         // 存在由恢复供电时的永久故障引起的数据丢失
+
+	events []*event_trigger.EventRecord // 记录事件时本次迭代处理的事件
 }
 
-func NewSimulator(dcConf *data_center.DCConf, ecConf *data_center.ErasureCodeConf, rConf *event_trigger.RunningConfig) *Simulator {
-	data_center.InitDCManager(dcConf, ecConf)
+// LossEvent 一次数据丢失事件
+type LossEvent struct {
+	Time               float64
+	FailedStripes      []int
+	LostChunkNum       int
+	LostDataChunkNum   int
+	LostParityChunkNum int
+	DataBytesLost      int64
+	ParityBytesLost    int64
+	DelayedStripesNum  int  // 丢失时因块所在磁盘不可用而推迟修复的条带数，这些条带尚未丢失，不计入丢失的条带与块
+	PowerOutage        bool // 在恢复供电时检查到的丢失，由恢复供电时的节点与磁盘永久故障引起
+}
+
+// BytesLost 丢失的数据与校验字节总数
+func (r *SimResult) BytesLost() int64 {
+	return r.DataBytesLost + r.ParityBytesLost
+}
+
+// NewSimulator 创建模拟器，模拟器持有独立的数据中心实例，同一进程中可同时存在多个不同配置的模拟器。
+// 配置无效时返回 enum_error.ConfigErrors，列出所有违反的约束
+func NewSimulator(dcConf *data_center.DCConf, ecConf *data_center.ErasureCodeConf, rConf *event_trigger.RunningConfig) (*Simulator, error) {
+	if err := ValidateConf(dcConf, ecConf, rConf); err != nil {
+		return nil, err
+	}
+	dcManager := data_center.NewDCManager(dcConf, ecConf)
 	return &Simulator{
 		dcConf:       dcConf,
 		ecConf:       ecConf,
-		eventManager: event_trigger.NewEventManager(rConf),
+		runningConf:  rConf,
+		dcManager:    dcManager,
+		eventManager: event_trigger.NewEventManager(rConf, dcManager),
+	}, nil
+}
+
+// ValidateConf 检查三份配置及其相互之间的约束，全部有效时返回 nil
+func ValidateConf(dcConf *data_center.DCConf, ecConf *data_center.ErasureCodeConf, rConf *event_trigger.RunningConfig) error {
+	var errs enum_error.ConfigErrors
+	errs = append(errs, ecConf.Validate()...)
+	errs = append(errs, dcConf.Validate(ecConf)...)
+	errs = append(errs, rConf.Validate(dcConf, ecConf)...)
+	return errs.Err()
+}
+
+// fork 创建一个配置相同但拥有独立数据中心实例的模拟器，供并行迭代使用
+func (s *Simulator) fork() *Simulator {
+	dcManager := data_center.NewDCManager(s.dcConf, s.ecConf)
+	return &Simulator{
+		dcConf:       s.dcConf,
+		ecConf:       s.ecConf,
+		runningConf:  s.runningConf,
+		dcManager:    dcManager,
+		eventManager: event_trigger.NewEventManager(s.runningConf, dcManager),
+		recordEvents: s.recordEvents,
 	}
 }
 
-func (s *Simulator) Reset() {
-	data_center.GetDCManager().Reset()
-	s.eventManager.ResetEventManager()
+// SetResultHandler 设置 Run 的逐迭代结果回调，handler 在合并结果的 goroutine 中调用，无需加锁
+func (s *Simulator) SetResultHandler(handler ResultHandler) {
+	s.onResult = handler
+}
+
+// SetProgressHandler 设置 Run 的进度回调，每隔至少 interval 时间以及结束时调用一次，handler 在合并结果的 goroutine 中调用
+func (s *Simulator) SetProgressHandler(interval time.Duration, handler ProgressHandler) {
+	s.progressInterval, s.progress = interval, handler
+}
+
+// SetCheckpointHandler 设置 Run 的检查点回调。Run 每隔至少 interval 时间以及结束时（包括被取消时）
+// 以当前已按序完成部分的汇总结果调用 handler，handler 返回错误时 Run 停止并返回该错误
+func (s *Simulator) SetCheckpointHandler(interval time.Duration, handler CheckpointHandler) {
+	s.checkpointInterval, s.checkpoint = interval, handler
+}
+
+// Reset 使用随机源 r 重置数据放置与故障事件
+func (s *Simulator) Reset(r *rand.Rand) {
+	s.dcManager.Reset(r)
+	s.eventManager.ResetEventManager(r)
+}
+
+// RackFaultTolerance 每个条带可容忍同时故障的机架数
+func (s *Simulator) RackFaultTolerance() int {
+	return s.dcManager.RackFaultTolerance()
 }
 
-func (s *Simulator) RunIteration(iteration int) *SimResult {
-	s.Reset()
+// RunIteration 执行第 iteration 次迭代，随机源由 RunningConfig.Seed 与 iteration 共同决定，因此结果可复现。
+// 默认在首次数据丢失时返回；开启 RunningConfig.ContinueAfterLoss 时记录每次数据丢失并继续模拟到 MissionTime。
+// 每处理 ctxCheckEvents 个事件检查一次 ctx，被取消时放弃本次迭代并返回 ctx.Err()
+func (s *Simulator) RunIteration(ctx context.Context, iteration int) (*SimResult, error) {
+	s.Reset(util.NewIterationRand(s.runningConf.Seed, iteration))
+	s.eventManager.SetPowerUpRand(util.NewStreamRand(s.runningConf.Seed, iteration, "power-up"))
 	var currentTime float64
+	result := new(SimResult)
+	if s.recordEvents {
+		s.eventManager.SetEventRecorder(func(record *event_trigger.EventRecord) {
+			result.events = append(result.events, record)
+		})
+	} else {
+		s.eventManager.SetEventRecorder(nil)
+	}
 	logrus.Infof("[Simulator.RunIteration] ite=%d", iteration)
 	for {
+		if result.EventNum%ctxCheckEvents == 0 {
+			if err := ctx.Err(); err != nil {
+				logrus.Infof("[Simulator.RunIteration] ite=%d canceled after %d events", iteration, result.EventNum)
+				return nil, err
+			}
+		}
+		result.EventNum++
 		eventExecRes := s.eventManager.HandleNextEvent(currentTime)
 		currentTime = eventExecRes.EventTime
 		logrus.Infof("[Simulator.RunIteration] event res:%+v", eventExecRes)
@@ -46,23 +175,220 @@ func (s *Simulator) RunIteration(iteration int) *SimResult {
 			break
 		}
 		switch eventExecRes.EventType {
-		case event_trigger.EventDiskFail, event_trigger.EventNodeFail:
-			dataLoss, failedStripesNum, lostChunkNum := data_center.GetDCManager().CheckDataLoss()
-			if dataLoss {
-				failedStripesNum += s.eventManager.GetDelayedRepairDictLength()
-				lostChunkNum += s.eventManager.GetDelayedRepairDictLength()
-				return &SimResult{
-					FailedStripesNum:       failedStripesNum,
-					LostChunkNum:           lostChunkNum,
-					BlockedRatio:           data_center.GetDCManager().GetBlockedRatio(currentTime),
-					SingleChunkRepairRatio: s.eventManager.GetSingleChunkRepairRatio(),
+		case event_trigger.EventDiskFail, event_trigger.EventNodeFail, event_trigger.EventPowerRestore:
+			dataLoss, lossInfo := s.dcManager.CheckDataLoss()
+			if !dataLoss {
+				break
+			}
+			lossEvent := s.newLossEvent(currentTime, lossInfo)
+			lossEvent.PowerOutage = eventExecRes.EventType == event_trigger.EventPowerRestore
+			if !s.runningConf.ContinueAfterLoss {
+				result.addLossEvent(lossEvent, s.dcManager.GetUsableCapacityTB())
+				s.finishResult(result, currentTime)
+				return result, nil
+			}
+			logrus.Infof("[Simulator.RunIteration] ite=%d, data loss at time=%+v, stripes=%d", iteration, currentTime, len(lossInfo.FailedStripes))
+			result.addLossEvent(lossEvent, s.dcManager.GetUsableCapacityTB())
+			s.dcManager.MarkStripesLost(lossInfo.FailedStripes)
+		case event_trigger.EventDiskRepair, event_trigger.EventStripeRepair:
+			if s.runningConf.ContinueAfterLoss && s.runningConf.RestoreLostStripes && s.dcManager.GetLostStripesNum() > 0 {
+				s.dcManager.RestoreRecoverableStripes()
+			}
+		}
+	}
+	if !result.DataLoss {
+		logrus.Infof("[Simulator.RunIteration] ite=%d, no data loss happen", iteration)
+	}
+	s.finishResult(result, currentTime)
+	return result, nil
+}
+
+// finishResult 迭代结束时计算按时间平均的指标
+func (s *Simulator) finishResult(result *SimResult, currentTime float64) {
+	result.BlockedRatio = s.dcManager.GetBlockedRatio(currentTime)
+	result.SingleChunkRepairRatio = s.eventManager.GetSingleChunkRepairRatio()
+	result.LocalRepairRatio = s.eventManager.GetLocalRepairRatio()
+	result.RepairTrafficBytes = int64(s.eventManager.GetRepairReadChunkNum() * float64(s.dcManager.GetChunkBytes()))
+	result.RepairDiskReadBytes = int64(s.eventManager.GetRepairDiskReadChunkNum() * float64(s.dcManager.GetChunkBytes()))
+	result.RepairSavedBytes = int64(s.eventManager.GetRepairReadSavedChunkNum() * float64(s.dcManager.GetChunkBytes()))
+	result.MeanRepairHours = s.eventManager.GetMeanDiskRepairTime()
+	result.MeanDegradedHours = s.eventManager.GetMeanStripeDegradedTime()
+	result.PowerOutageNum = s.eventManager.GetPowerOutageNum()
+	result.PowerBlockedRatio = s.eventManager.GetPowerBlockedRatio(currentTime)
+}
+
+// newLossEvent 根据丢失的块数计算丢失字节数。每个数据块含 B/K 字节用户数据，丢失块的其余字节都计为校验字节
+func (s *Simulator) newLossEvent(currentTime float64, lossInfo *data_center.DataLossInfo) *LossEvent {
+	dataBytesLost := int64(math.Round(float64(lossInfo.LostDataChunkNum) * s.dcManager.GetDataBytesPerChunk()))
+	chunkBytesLost := int64(lossInfo.LostChunkNum) * s.dcManager.GetChunkBytes()
+	return &LossEvent{
+		Time:               currentTime,
+		FailedStripes:      lossInfo.FailedStripes,
+		LostChunkNum:       lossInfo.LostChunkNum,
+		LostDataChunkNum:   lossInfo.LostDataChunkNum,
+		LostParityChunkNum: lossInfo.LostParityChunkNum,
+		DataBytesLost:      dataBytesLost,
+		ParityBytesLost:    chunkBytesLost - dataBytesLost,
+		DelayedStripesNum:  s.eventManager.GetDelayedRepairDictLength(),
+	}
+}
+
+// addLossEvent 将一次数据丢失累加到迭代结果中，usableCapacity 为用户数据总量（TB），用于计算 NOMDL
+func (r *SimResult) addLossEvent(lossEvent *LossEvent, usableCapacity float64) {
+	r.DataLoss = true
+	r.PowerDataLoss = r.PowerDataLoss || lossEvent.PowerOutage
+	r.FailedStripesNum += len(lossEvent.FailedStripes)
+	r.LostChunkNum += lossEvent.LostChunkNum
+	r.LostDataChunkNum += lossEvent.LostDataChunkNum
+	r.LostParityChunkNum += lossEvent.LostParityChunkNum
+	r.DataBytesLost += lossEvent.DataBytesLost
+	r.ParityBytesLost += lossEvent.ParityBytesLost
+	if usableCapacity > 0 {
+		r.NOMDL = float64(r.DataBytesLost) / usableCapacity
+		r.ParityNOMDL = float64(r.ParityBytesLost) / usableCapacity
+	}
+	r.LossEvents = append(r.LossEvents, lossEvent)
+}
+
+type iterationResult struct {
+	iteration int
+	result    *SimResult
+}
+
+// Run 将至多 iterations 次迭代分配到 workers 个 goroutine 上并行执行，workers<=0 时使用 CPU 核数。
+// 每个 goroutine 持有独立的数据中心实例，结果按迭代序号依次合并，因此汇总结果与 workers 无关。
+// 设置了 RunningConfig.TargetRelativeError 时，PDL 相对误差达到目标后提前结束。
+// ctx 被取消时停止分发新的迭代并中断正在执行的迭代，返回已按序完成部分的汇总结果与 ctx.Err()。
+// 设置了 RunningConfig.TimeBudget 时，运行时间达到预算后同样停止，但视为正常结束，返回已完成部分的汇总结果
+func (s *Simulator) Run(ctx context.Context, iterations, workers int) (*Aggregate, error) {
+	return s.Resume(ctx, NewAggregate(), iterations, workers)
+}
+
+// Resume 从检查点的汇总结果 aggregate 继续运行，即从第 aggregate.Iterations 次迭代开始，直到共完成 iterations 次迭代。
+// 每次迭代的随机源只由种子与迭代序号决定，因此结果与不中断地运行 Run 完全相同。aggregate 会被原地更新
+func (s *Simulator) Resume(ctx context.Context, aggregate *Aggregate, iterations, workers int) (*Aggregate, error) {
+	start := aggregate.Iterations
+	if iterations <= start || aggregate.Converged(s.runningConf.ConfidenceLevel, s.runningConf.TargetRelativeError) {
+		return aggregate, nil
+	}
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > iterations-start {
+		workers = iterations - start
+	}
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if s.runningConf.TimeBudget > 0 {
+		var cancelBudget context.CancelFunc
+		runCtx, cancelBudget = context.WithTimeout(runCtx, s.runningConf.TimeBudget)
+		defer cancelBudget()
+	}
+
+	iterationCh := make(chan int)
+	resultCh := make(chan iterationResult, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		sim := s
+		if w > 0 {
+			sim = s.fork()
+		}
+		wg.Add(1)
+		go func(sim *Simulator) {
+			defer wg.Done()
+			for ite := range iterationCh {
+				result, err := sim.RunIteration(runCtx, ite)
+				if err != nil {
+					return
+				}
+				select {
+				case resultCh <- iterationResult{iteration: ite, result: result}:
+				case <-runCtx.Done():
+					return
+				}
+			}
+		}(sim)
+	}
+	go func() {
+		defer close(iterationCh)
+		for ite := start; ite < iterations; ite++ {
+			select {
+			case iterationCh <- ite:
+			case <-runCtx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(resultCh)
+	}()
+
+	pending := make(map[int]*SimResult)
+	next := start
+	stopped := false
+	var handlerErr error
+	lastCheckpoint := time.Now()
+	progress := &Progress{TargetIterations: iterations, startIterations: start, startTime: lastCheckpoint}
+	lastProgress := lastCheckpoint
+	for res := range resultCh {
+		if stopped {
+			continue
+		}
+		pending[res.iteration] = res.result
+		for result, ok := pending[next]; ok && !stopped; result, ok = pending[next] {
+			aggregate.Add(result)
+			progress.Events += int64(result.EventNum)
+			delete(pending, next)
+			if s.onResult != nil {
+				if handlerErr = s.onResult(next, result); handlerErr != nil {
+					stopped = true
+					cancel()
+				}
+			}
+			if !stopped && s.onEvents != nil {
+				if handlerErr = s.onEvents(next, result.events, result); handlerErr != nil {
+					stopped = true
+					cancel()
 				}
 			}
+			result.events = nil
+			next++
+			if !stopped && aggregate.Converged(s.runningConf.ConfidenceLevel, s.runningConf.TargetRelativeError) {
+				logrus.Infof("[Simulator.Run] PDL converged after %d iterations", aggregate.Iterations)
+				stopped = true
+				cancel()
+			}
+			if !stopped && s.checkpoint != nil && time.Since(lastCheckpoint) >= s.checkpointInterval {
+				if handlerErr = s.saveCheckpoint(aggregate); handlerErr != nil {
+					stopped = true
+					cancel()
+				}
+				lastCheckpoint = time.Now()
+			}
+			if s.progress != nil && time.Since(lastProgress) >= s.progressInterval {
+				s.progress(progress.update(aggregate, s.runningConf.ConfidenceLevel))
+				lastProgress = time.Now()
+			}
+		}
+	}
+	if handlerErr != nil {
+		return aggregate, handlerErr
+	}
+	if s.checkpoint != nil {
+		if err := s.saveCheckpoint(aggregate); err != nil {
+			return aggregate, err
 		}
 	}
-	logrus.Infof("[Simulator.RunIteration] ite=%d, no data loss happen", iteration)
-	return &SimResult{
-		BlockedRatio:           data_center.GetDCManager().GetBlockedRatio(currentTime),
-		SingleChunkRepairRatio: s.eventManager.GetSingleChunkRepairRatio(),
+	if s.progress != nil {
+		s.progress(progress.update(aggregate, s.runningConf.ConfidenceLevel))
+	}
+	if err := ctx.Err(); err != nil {
+		logrus.Warnf("[Simulator.Run] interrupted after %d iterations, err=%+v", aggregate.Iterations, err)
+		return aggregate, err
+	}
+	if errors.Is(runCtx.Err(), context.DeadlineExceeded) && aggregate.Iterations < iterations {
+		logrus.Infof("[Simulator.Run] time budget %v used up after %d iterations", s.runningConf.TimeBudget, aggregate.Iterations)
 	}
+	return aggregate, nil
 }
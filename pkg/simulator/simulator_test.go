@@ -2,11 +2,17 @@ package simulator
 
 import (
 	"ECDC_SIM/internal/pkg/data_center"
+	"ECDC_SIM/internal/pkg/enum_error"
 	"ECDC_SIM/internal/pkg/event_trigger"
+	"ECDC_SIM/internal/pkg/gf256"
 	"ECDC_SIM/internal/pkg/util"
+	"context"
+	"errors"
 	"github.com/gogap/logrus"
+	"io"
 	"math"
 	"os"
+	"reflect"
 	"strconv"
 	"testing"
 	"time"
@@ -64,12 +70,956 @@ func TestNewSimulator(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			logFile, _ := os.OpenFile("../../output/test_log/"+strconv.Itoa(int(time.Now().UnixNano()))+".log", os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
 			logrus.SetOutput(logFile)
-			got := NewSimulator(tt.args.dcConf, tt.args.ecConf, tt.args.rConf)
+			got, err := NewSimulator(tt.args.dcConf, tt.args.ecConf, tt.args.rConf)
+			if err != nil {
+				t.Fatalf("NewSimulator() error = %v", err)
+			}
 			iteration := 1
 			for ite := 0; ite < iteration; ite++ {
-				result := got.RunIteration(ite)
+				result, err := got.RunIteration(context.Background(), ite)
+				if err != nil {
+					t.Fatalf("RunIteration() error = %v", err)
+				}
 				t.Log(result)
 			}
 		})
 	}
 }
+
+func mustNewSimulator(t *testing.T, dcConf *data_center.DCConf, ecConf *data_center.ErasureCodeConf, rConf *event_trigger.RunningConfig) *Simulator {
+	t.Helper()
+	sim, err := NewSimulator(dcConf, ecConf, rConf)
+	if err != nil {
+		t.Fatalf("NewSimulator() error = %v", err)
+	}
+	return sim
+}
+
+func newTestConf() (*data_center.DCConf, *data_center.ErasureCodeConf, *event_trigger.RunningConfig) {
+	return &data_center.DCConf{
+		RacksNum:                    12,
+		StripesNum:                  200,
+		DisksPerNode:                1,
+		DiskCapacity:                int(math.Pow(2, 10)),
+		NodesPerRack:                4,
+		ChunkNum:                    200 * 9,
+		ChunkSize:                   256,
+		NFailD:                      util.NewWeibull(1, 9125, 0),
+		NTFailD:                     util.NewWeibull(1, 2890.8, 0),
+		NTRepairD:                   util.NewWeibull(1, 0.25, 0),
+		DFailD:                      util.NewWeibull(1.12, 8760, 0),
+		RFailD:                      util.NewWeibull(1.0, 87600, 0),
+		RRepairD:                    util.NewWeibull(1.0, 24, 10),
+		MaxCrossRackRepairBandwidth: 125,
+		MaxIntraRackRepairBandwidth: 125,
+		MissionTime:                 87600,
+		UseNetwork:                  true,
+	}, &data_center.ErasureCodeConf{
+		CodeType:       data_center.RS,
+		ChunkPlaceType: data_center.FLAT,
+		N:              9,
+		K:              6,
+	}, &event_trigger.RunningConfig{}
+}
+
+func TestSimulator_Run(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	tests := []struct {
+		name       string
+		iterations int
+		workers    int
+	}{
+		{name: "TestRunSerial", iterations: 8, workers: 1},
+		{name: "TestRunParallel", iterations: 16, workers: 4},
+		{name: "TestRunMoreWorkersThanIterations", iterations: 2, workers: 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dcConf, ecConf, rConf := newTestConf()
+			got, err := mustNewSimulator(t, dcConf, ecConf, rConf).Run(context.Background(), tt.iterations, tt.workers)
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if got.Iterations != tt.iterations {
+				t.Errorf("Run() iterations = %d, want %d", got.Iterations, tt.iterations)
+			}
+			t.Log(got.PDL(), got.MeanLostChunkNum(), got.MeanBlockedRatio())
+		})
+	}
+}
+
+func TestSimulator_RunCanceled(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	dcConf, ecConf, rConf := newTestConf()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	got, err := mustNewSimulator(t, dcConf, ecConf, rConf).Run(ctx, 100, 2)
+	if err != context.Canceled {
+		t.Errorf("Run() error = %v, want %v", err, context.Canceled)
+	}
+	if got.Iterations >= 100 {
+		t.Errorf("Run() iterations = %d, want partial result", got.Iterations)
+	}
+}
+
+func TestSimulator_Independent(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	dcConfA, ecConfA, rConfA := newTestConf()
+	dcConfB, ecConfB, rConfB := newTestConf()
+	dcConfB.RacksNum = 16
+	ecConfB.N, ecConfB.K = 14, 10
+	dcConfB.ChunkNum = dcConfB.StripesNum * ecConfB.N
+	simA := mustNewSimulator(t, dcConfA, ecConfA, rConfA)
+	simB := mustNewSimulator(t, dcConfB, ecConfB, rConfB)
+	simA.Reset(util.NewIterationRand(0, 0))
+	simB.Reset(util.NewIterationRand(0, 0))
+	if simA.dcManager == simB.dcManager {
+		t.Fatal("simulators share the same DCManager")
+	}
+	tests := []struct {
+		name      string
+		sim       *Simulator
+		wantDisks int
+		wantN     int
+	}{
+		{name: "TestSimulatorA", sim: simA, wantDisks: 12 * 4, wantN: 9},
+		{name: "TestSimulatorB", sim: simB, wantDisks: 16 * 4, wantN: 14},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sim.dcManager.DiskManager().GetDiskNum(); got != tt.wantDisks {
+				t.Errorf("GetDiskNum() = %d, want %d", got, tt.wantDisks)
+			}
+			if got := len(tt.sim.dcManager.GetStripesLocation(0)); got != tt.wantN {
+				t.Errorf("len(GetStripesLocation(0)) = %d, want %d", got, tt.wantN)
+			}
+			if tt.sim.eventManager.DCManager() != tt.sim.dcManager {
+				t.Error("EventManager is not bound to the simulator's DCManager")
+			}
+		})
+	}
+}
+
+func TestSimulator_Reproducible(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	tests := []struct {
+		name      string
+		seed      int64
+		iteration int
+	}{
+		{name: "TestSeed0Iteration0", seed: 0, iteration: 0},
+		{name: "TestSeed42Iteration7", seed: 42, iteration: 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dcConf, ecConf, rConf := newTestConf()
+			rConf.Seed = tt.seed
+			first, err := mustNewSimulator(t, dcConf, ecConf, rConf).RunIteration(context.Background(), tt.iteration)
+			if err != nil {
+				t.Fatal(err)
+			}
+			second, err := mustNewSimulator(t, dcConf, ecConf, rConf).RunIteration(context.Background(), tt.iteration)
+			if err != nil || !reflect.DeepEqual(first, second) {
+				t.Errorf("RunIteration() = %+v, rerun = %+v", first, second)
+			}
+		})
+	}
+	t.Run("TestRunIndependentOfWorkers", func(t *testing.T) {
+		dcConf, ecConf, rConf := newTestConf()
+		rConf.Seed = 1
+		serial, _ := mustNewSimulator(t, dcConf, ecConf, rConf).Run(context.Background(), 12, 1)
+		parallel, _ := mustNewSimulator(t, dcConf, ecConf, rConf).Run(context.Background(), 12, 4)
+		if !reflect.DeepEqual(serial, parallel) {
+			t.Errorf("Run() serial = %+v, parallel = %+v", serial, parallel)
+		}
+	})
+}
+
+func TestSimResult_addLossEvent(t *testing.T) {
+	dcConf, ecConf, rConf := newTestConf()
+	sim := mustNewSimulator(t, dcConf, ecConf, rConf)
+	// 200 个条带 * 6 个数据块 * 256MB = 0.29296875TB 用户数据
+	usableCapacity := sim.dcManager.GetUsableCapacityTB()
+	if usableCapacity != 0.29296875 {
+		t.Fatalf("GetUsableCapacityTB() = %v, want 0.29296875", usableCapacity)
+	}
+	lossEvents := []*LossEvent{
+		sim.newLossEvent(10, &data_center.DataLossInfo{
+			FailedStripes: []int{3}, LostChunkNum: 4, LostDataChunkNum: 3, LostParityChunkNum: 1,
+		}),
+		sim.newLossEvent(20, &data_center.DataLossInfo{
+			FailedStripes: []int{5, 8}, LostChunkNum: 8, LostDataChunkNum: 4, LostParityChunkNum: 4,
+		}),
+	}
+	tests := []struct {
+		name       string
+		lossEvents []*LossEvent
+		want       *SimResult
+	}{
+		{name: "TestSingleLoss", lossEvents: lossEvents[:1], want: &SimResult{
+			DataLoss: true, FailedStripesNum: 1, LostChunkNum: 4, LostDataChunkNum: 3, LostParityChunkNum: 1,
+			DataBytesLost: 3 * 256 << 20, ParityBytesLost: 256 << 20,
+			NOMDL: float64(3*256<<20) / usableCapacity, ParityNOMDL: float64(256<<20) / usableCapacity,
+			LossEvents: lossEvents[:1],
+		}},
+		{name: "TestMultipleLoss", lossEvents: lossEvents, want: &SimResult{
+			DataLoss: true, FailedStripesNum: 3, LostChunkNum: 12, LostDataChunkNum: 7, LostParityChunkNum: 5,
+			DataBytesLost: 7 * 256 << 20, ParityBytesLost: 5 * 256 << 20,
+			NOMDL: float64(7*256<<20) / usableCapacity, ParityNOMDL: float64(5*256<<20) / usableCapacity,
+			LossEvents: lossEvents,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := new(SimResult)
+			for _, lossEvent := range tt.lossEvents {
+				got.addLossEvent(lossEvent, usableCapacity)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("addLossEvent() = %+v, want %+v", got, tt.want)
+			}
+			if got.BytesLost() != tt.want.DataBytesLost+tt.want.ParityBytesLost {
+				t.Errorf("BytesLost() = %d", got.BytesLost())
+			}
+		})
+	}
+}
+
+func TestSimulator_newLossEvent(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	// MBR 的块大小 α 大于 B/K，丢失整个条带时丢失的用户数据为 B，丢失块的总字节数与之比为存储开销
+	tests := []struct {
+		name          string
+		codeType      data_center.ErasureCodeType
+		wantDataBytes int64
+	}{
+		{name: "TestRS", codeType: data_center.RS, wantDataBytes: 6 * 256 << 20},
+		// MBR(9,6,d=8) 的存储开销为 144/66，B/K = 256MB * 9 * 66 / 144 / 6 = 176MB
+		{name: "TestMBR", codeType: data_center.MBR, wantDataBytes: 6 * 176 << 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dcConf, ecConf, rConf := newTestConf()
+			ecConf.CodeType = tt.codeType
+			sim := mustNewSimulator(t, dcConf, ecConf, rConf)
+			code, errs := data_center.NewErasureCode(ecConf)
+			if len(errs) != 0 {
+				t.Fatal(errs)
+			}
+			got := sim.newLossEvent(10, &data_center.DataLossInfo{
+				FailedStripes: []int{3}, LostChunkNum: 9, LostDataChunkNum: 6, LostParityChunkNum: 3,
+			})
+			if got.DataBytesLost != tt.wantDataBytes || got.DataBytesLost+got.ParityBytesLost != 9*256<<20 {
+				t.Fatalf("newLossEvent() data bytes %d, parity bytes %d, want %d of %d", got.DataBytesLost, got.ParityBytesLost,
+					tt.wantDataBytes, 9*256<<20)
+			}
+			if overhead := float64(got.DataBytesLost+got.ParityBytesLost) / float64(got.DataBytesLost); math.Abs(overhead-code.StorageOverhead()) > 1e-9 {
+				t.Errorf("bytes lost / data bytes lost = %g, want StorageOverhead() %g", overhead, code.StorageOverhead())
+			}
+			// 丢失全部条带时 NOMDL 的分子等于用户数据总量
+			if usable := sim.dcManager.GetUsableCapacityTB() * data_center.BytesPerTB; math.Abs(usable-float64(200*got.DataBytesLost)) > 1 {
+				t.Errorf("GetUsableCapacityTB() = %g bytes, want %d", usable, 200*got.DataBytesLost)
+			}
+		})
+	}
+}
+
+func TestSimulator_ContinueAfterLoss(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	tests := []struct {
+		name    string
+		restore bool
+	}{
+		{name: "TestMarkStripesLost", restore: false},
+		{name: "TestRestoreLostStripes", restore: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dcConf, ecConf, rConf := newTestConf()
+			dcConf.StripesNum, dcConf.ChunkNum = 20, 20*ecConf.N
+			dcConf.DFailD = util.NewWeibull(1, 2000, 0)
+			dcConf.MaxCrossRackRepairBandwidth = 0.01
+			rConf.ContinueAfterLoss, rConf.RestoreLostStripes = true, tt.restore
+			got, err := mustNewSimulator(t, dcConf, ecConf, rConf).RunIteration(context.Background(), 0)
+			if err != nil || !got.DataLoss || len(got.LossEvents) == 0 {
+				t.Fatalf("RunIteration() = %+v, want data loss", got)
+			}
+			failedStripesNum, lastTime := 0, 0.0
+			seen := make(map[int]bool)
+			for _, lossEvent := range got.LossEvents {
+				if lossEvent.Time < lastTime || lossEvent.Time > dcConf.MissionTime {
+					t.Errorf("LossEvent time %v out of order", lossEvent.Time)
+				}
+				lastTime = lossEvent.Time
+				failedStripesNum += len(lossEvent.FailedStripes)
+				for _, stripeId := range lossEvent.FailedStripes {
+					if seen[stripeId] && !tt.restore {
+						t.Errorf("stripe %d lost twice", stripeId)
+					}
+					seen[stripeId] = true
+				}
+			}
+			if failedStripesNum != got.FailedStripesNum {
+				t.Errorf("FailedStripesNum = %d, sum of events = %d", got.FailedStripesNum, failedStripesNum)
+			}
+		})
+	}
+}
+
+func TestSimulator_FirstLossEvent(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	// 首次丢失时停止与继续模拟记录的首次丢失相同，推迟修复的条带不计入丢失的条带与块。
+	// 共享带宽时磁盘故障后立即开始修复，已无法恢复的条带同时被推迟修复
+	dcConf, ecConf, rConf := newTestConf()
+	dcConf.DFailD, dcConf.BandwidthSharing = util.NewWeibull(1, 2000, 0), data_center.BandwidthMaxMin
+	ecConf.N, ecConf.K, dcConf.ChunkNum = 4, 3, 200*4
+	stopSim := mustNewSimulator(t, dcConf, ecConf, rConf)
+	continueConf := *rConf
+	continueConf.ContinueAfterLoss = true
+	continueSim := mustNewSimulator(t, dcConf, ecConf, &continueConf)
+	delayed := false
+	for iteration := 0; iteration < 10; iteration++ {
+		stopped, err := stopSim.RunIteration(context.Background(), iteration)
+		if err != nil {
+			t.Fatal(err)
+		}
+		continued, err := continueSim.RunIteration(context.Background(), iteration)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !stopped.DataLoss {
+			continue
+		}
+		first := stopped.LossEvents[0]
+		if !reflect.DeepEqual(first, continued.LossEvents[0]) {
+			t.Errorf("iteration %d first loss = %+v, want %+v after continuing", iteration, first, continued.LossEvents[0])
+		}
+		if stopped.FailedStripesNum != len(first.FailedStripes) || stopped.LostChunkNum != first.LostChunkNum ||
+			stopped.DataBytesLost != first.DataBytesLost {
+			t.Errorf("iteration %d result = %+v, want the totals of %+v", iteration, stopped, first)
+		}
+		delayed = delayed || first.DelayedStripesNum > 0
+	}
+	if !delayed {
+		t.Error("no loss happened with delayed stripes")
+	}
+}
+
+func TestNewSimulator_Invalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		modify     func(dcConf *data_center.DCConf, ecConf *data_center.ErasureCodeConf, rConf *event_trigger.RunningConfig)
+		wantKind   error
+		wantFields []string
+	}{
+		{name: "TestKNotLessThanN", modify: func(dcConf *data_center.DCConf, ecConf *data_center.ErasureCodeConf, rConf *event_trigger.RunningConfig) {
+			ecConf.K = 9
+		}, wantKind: enum_error.ParamsInconsistentError, wantFields: []string{"ErasureCodeConf.K"}},
+		{name: "TestChunkNumMismatch", modify: func(dcConf *data_center.DCConf, ecConf *data_center.ErasureCodeConf, rConf *event_trigger.RunningConfig) {
+			dcConf.ChunkNum = 100
+		}, wantKind: enum_error.ParamsInconsistentError, wantFields: []string{"DCConf.ChunkNum"}},
+		{name: "TestCapacityOverflow", modify: func(dcConf *data_center.DCConf, ecConf *data_center.ErasureCodeConf, rConf *event_trigger.RunningConfig) {
+			dcConf.DiskCapacity = 1
+		}, wantKind: enum_error.ParamsCapacityExceedError, wantFields: []string{"DCConf.ChunkNum"}},
+		{name: "TestMissingRepairDistribution", modify: func(dcConf *data_center.DCConf, ecConf *data_center.ErasureCodeConf, rConf *event_trigger.RunningConfig) {
+			dcConf.UseNetwork = false
+		}, wantKind: enum_error.ParamsMissingError, wantFields: []string{"DCConf.DRepairD"}},
+		{name: "TestZeroBandwidth", modify: func(dcConf *data_center.DCConf, ecConf *data_center.ErasureCodeConf, rConf *event_trigger.RunningConfig) {
+			dcConf.MaxCrossRackRepairBandwidth, dcConf.MaxIntraRackRepairBandwidth = 0, 0
+		}, wantKind: enum_error.ParamsOutOfRangeError, wantFields: []string{"DCConf.MaxCrossRackRepairBandwidth", "DCConf.MaxIntraRackRepairBandwidth"}},
+		{name: "TestTooFewRacks", modify: func(dcConf *data_center.DCConf, ecConf *data_center.ErasureCodeConf, rConf *event_trigger.RunningConfig) {
+			dcConf.RacksNum = 8
+		}, wantKind: enum_error.ParamsInconsistentError, wantFields: []string{"DCConf.RacksNum"}},
+		{name: "TestLRCGroupsNotDividingK", modify: func(dcConf *data_center.DCConf, ecConf *data_center.ErasureCodeConf, rConf *event_trigger.RunningConfig) {
+			ecConf.CodeType, ecConf.L = data_center.LRC, 4
+		}, wantKind: enum_error.ParamsInconsistentError, wantFields: []string{"ErasureCodeConf.L", "ErasureCodeConf.N"}},
+		{name: "TestLRCTooFewRacks", modify: func(dcConf *data_center.DCConf, ecConf *data_center.ErasureCodeConf, rConf *event_trigger.RunningConfig) {
+			ecConf.CodeType, ecConf.L, ecConf.G, dcConf.RacksNum = data_center.LRC, 2, 1, 3
+		}, wantKind: enum_error.ParamsInconsistentError, wantFields: []string{"DCConf.RacksNum"}},
+		{name: "TestHierarchicalWithoutChunksPerRack", modify: func(dcConf *data_center.DCConf, ecConf *data_center.ErasureCodeConf, rConf *event_trigger.RunningConfig) {
+			ecConf.ChunkPlaceType = data_center.HIERARCHICAL
+		}, wantKind: enum_error.ParamsOutOfRangeError, wantFields: []string{"ErasureCodeConf.ChunksPerRack"}},
+		{name: "TestHierarchicalTooFewRacks", modify: func(dcConf *data_center.DCConf, ecConf *data_center.ErasureCodeConf, rConf *event_trigger.RunningConfig) {
+			ecConf.ChunkPlaceType, ecConf.ChunksPerRack, dcConf.RacksNum, dcConf.NodesPerRack = data_center.HIERARCHICAL, 2, 4, 12
+		}, wantKind: enum_error.ParamsInconsistentError, wantFields: []string{"DCConf.RacksNum"}},
+		{name: "TestTransientWithoutDistribution", modify: func(dcConf *data_center.DCConf, ecConf *data_center.ErasureCodeConf, rConf *event_trigger.RunningConfig) {
+			rConf.EnableTransientFailure, dcConf.NTRepairD = true, nil
+		}, wantKind: enum_error.ParamsMissingError, wantFields: []string{"DCConf.NTRepairD"}},
+		{name: "TestStripeRepairSlotsWithoutSharing", modify: func(dcConf *data_center.DCConf, ecConf *data_center.ErasureCodeConf, rConf *event_trigger.RunningConfig) {
+			rConf.StripeRepair, rConf.StripeRepairSlots = true, 4
+		}, wantKind: enum_error.ParamsInconsistentError, wantFields: []string{"RunningConfig.StripeRepairSlots"}},
+		{name: "TestSerialStripeRepairWithSharing", modify: func(dcConf *data_center.DCConf, ecConf *data_center.ErasureCodeConf, rConf *event_trigger.RunningConfig) {
+			rConf.StripeRepair, dcConf.BandwidthSharing = true, data_center.BandwidthMaxMin
+		}, wantKind: enum_error.ParamsInconsistentError, wantFields: []string{"DCConf.BandwidthSharing"}},
+		// RS(9,6) 最多容忍 3 个丢失块
+		{name: "TestLazyThresholdAboveTolerance", modify: func(dcConf *data_center.DCConf, ecConf *data_center.ErasureCodeConf, rConf *event_trigger.RunningConfig) {
+			rConf.StripeRepair, rConf.RepairPolicy, rConf.RepairThreshold = true, event_trigger.RepairLazy, 4
+		}, wantKind: enum_error.ParamsInconsistentError, wantFields: []string{"RunningConfig.RepairThreshold"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dcConf, ecConf, rConf := newTestConf()
+			tt.modify(dcConf, ecConf, rConf)
+			got, err := NewSimulator(dcConf, ecConf, rConf)
+			if got != nil || !errors.Is(err, tt.wantKind) || !errors.Is(err, enum_error.ParamsInvalidError) {
+				t.Fatalf("NewSimulator() = %v, %v, want %v", got, err, tt.wantKind)
+			}
+			var errs enum_error.ConfigErrors
+			if !errors.As(err, &errs) || len(errs) != len(tt.wantFields) {
+				t.Fatalf("NewSimulator() error = %v, want fields %v", err, tt.wantFields)
+			}
+			for idx, field := range tt.wantFields {
+				if errs[idx].Field != field {
+					t.Errorf("ConfigError.Field = %s, want %s", errs[idx].Field, field)
+				}
+			}
+		})
+	}
+}
+
+func TestSimulator_RunWithoutNetwork(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	// 不模拟网络时磁盘修复不等待带宽，修复时间服从 DRepairD，平均约为其均值 24 小时
+	dcConf, ecConf, rConf := newTestConf()
+	dcConf.UseNetwork = false
+	dcConf.DRepairD = util.NewWeibull(1, 24, 0)
+	rConf.ContinueAfterLoss = true
+	got, err := mustNewSimulator(t, dcConf, ecConf, rConf).Run(context.Background(), 4, 2)
+	if err != nil || got.Iterations != 4 {
+		t.Fatalf("Run() = %+v, %v", got, err)
+	}
+	if mean := got.MeanRepairHours.Sum / 4; mean < 20 || mean > 28 {
+		t.Errorf("MeanRepairHours = %g, want about 24 from DRepairD", mean)
+	}
+}
+
+func TestSimulator_RunIterationTrace(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	// 任务期内永久失效机架 0~3 的全部节点，FLAT 放置下同时落在这 4 个机架上的条带丢失 4 个块，超过 RS(9,6) 的容错能力
+	rackFailures := make([]event_trigger.TraceRecord, 0)
+	for nodeId := 0; nodeId < 16; nodeId++ {
+		rackFailures = append(rackFailures, event_trigger.TraceRecord{Time: 10, Event: "NodeFail", Device: nodeId})
+	}
+	tests := []struct {
+		name         string
+		records      []event_trigger.TraceRecord
+		wantDataLoss bool
+	}{
+		{name: "TestEmptyTrace", records: nil},
+		{name: "TestSingleDisk", records: []event_trigger.TraceRecord{{Time: 5, Event: "DiskFail", Device: 7}, {Time: 6, Event: "NodeTransientFail", Device: 1},
+			{Time: 7, Event: "NodeTransientRepair", Device: 1}, {Time: 8, Event: "RackFail", Device: 2}, {Time: 9, Event: "RackRepair", Device: 2}}},
+		{name: "TestFourRacks", records: rackFailures, wantDataLoss: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dcConf, ecConf, rConf := newTestConf()
+			rConf.UseTrace, rConf.Trace = true, &event_trigger.Trace{Records: tt.records}
+			got, err := mustNewSimulator(t, dcConf, ecConf, rConf).RunIteration(context.Background(), 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.DataLoss != tt.wantDataLoss {
+				t.Fatalf("RunIteration() DataLoss = %t, want %t", got.DataLoss, tt.wantDataLoss)
+			}
+			if tt.wantDataLoss && got.LossEvents[0].Time != 10 {
+				t.Errorf("LossEvent time = %v, want 10", got.LossEvents[0].Time)
+			}
+		})
+	}
+	t.Run("TestMissingTrace", func(t *testing.T) {
+		dcConf, ecConf, rConf := newTestConf()
+		rConf.UseTrace = true
+		if _, err := NewSimulator(dcConf, ecConf, rConf); !errors.Is(err, enum_error.ParamsMissingError) {
+			t.Errorf("NewSimulator() error = %v, want ParamsMissingError", err)
+		}
+	})
+}
+
+func TestSimulator_TraceDiskRepair(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	tests := []struct {
+		name           string
+		records        []event_trigger.TraceRecord
+		wantRepairHour float64 // 为 0 时修复用时由修复带宽计算
+	}{
+		{name: "TestRecordedRepair", records: []event_trigger.TraceRecord{{Time: 5, Event: "DiskFail", Device: 7}, {Time: 105, Event: "DiskRepair", Device: 7}},
+			wantRepairHour: 100},
+		// 故障之前的修复记录不起作用，修复过程仍由模拟器计算
+		{name: "TestEarlierRepair", records: []event_trigger.TraceRecord{{Time: 2, Event: "DiskRepair", Device: 7}, {Time: 5, Event: "DiskFail", Device: 7}}},
+		{name: "TestOtherDisk", records: []event_trigger.TraceRecord{{Time: 5, Event: "DiskFail", Device: 7}, {Time: 105, Event: "DiskRepair", Device: 8}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dcConf, ecConf, rConf := newTestConf()
+			rConf.UseTrace, rConf.Trace = true, &event_trigger.Trace{Records: tt.records}
+			got, err := mustNewSimulator(t, dcConf, ecConf, rConf).RunIteration(context.Background(), 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantRepairHour > 0 && got.MeanRepairHours != tt.wantRepairHour {
+				t.Errorf("MeanRepairHours = %g, want %g from the trace", got.MeanRepairHours, tt.wantRepairHour)
+			}
+			if tt.wantRepairHour == 0 && (got.MeanRepairHours <= 0 || got.MeanRepairHours >= 100) {
+				t.Errorf("MeanRepairHours = %g, want a repair computed from the bandwidth", got.MeanRepairHours)
+			}
+		})
+	}
+	t.Run("TestStripeRepair", func(t *testing.T) {
+		dcConf, ecConf, rConf := newTestConf()
+		rConf.UseTrace, rConf.StripeRepair = true, true
+		rConf.Trace = &event_trigger.Trace{Records: tests[0].records}
+		if _, err := NewSimulator(dcConf, ecConf, rConf); !errors.Is(err, enum_error.ParamsInconsistentError) {
+			t.Errorf("NewSimulator() error = %v, want ParamsInconsistentError", err)
+		}
+	})
+}
+
+func TestSimulator_PowerOutageTrace(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	tests := []struct {
+		name            string
+		powerDomainsNum int
+		domainId        int
+		wantOfflineDisk int
+	}{
+		{name: "TestDataCenter", powerDomainsNum: 0, domainId: 0, wantOfflineDisk: 48},
+		{name: "TestSecondOfTwoDomains", powerDomainsNum: 2, domainId: 1, wantOfflineDisk: 24},
+		{name: "TestRackDomains", powerDomainsNum: 12, domainId: 5, wantOfflineDisk: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dcConf, ecConf, rConf := newTestConf()
+			dcConf.PowerDomainsNum = tt.powerDomainsNum
+			rConf.UsePowerOutage, rConf.UseTrace = true, true
+			rConf.Trace = &event_trigger.Trace{Records: []event_trigger.TraceRecord{
+				{Time: 10, Event: "PowerOutage", Device: tt.domainId},
+				{Time: 15, Event: "PowerOutage", Device: tt.domainId},
+				{Time: 20, Event: "PowerRestore", Device: tt.domainId},
+			}}
+			got, err := mustNewSimulator(t, dcConf, ecConf, rConf).RunIteration(context.Background(), 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			endTime := math.Nextafter(dcConf.MissionTime, math.Inf(1))
+			wantRatio := float64(tt.wantOfflineDisk) * 10 / (float64(dcConf.ChunkNum) * endTime)
+			if got.DataLoss || got.PowerOutageNum != 1 || math.Abs(got.PowerBlockedRatio-wantRatio) > 1e-15 {
+				t.Errorf("RunIteration() = %+v, want one outage and PowerBlockedRatio %g", got, wantRatio)
+			}
+			if math.Abs(got.BlockedRatio-wantRatio) > 1e-15 {
+				t.Errorf("BlockedRatio = %g, want the outage only %g", got.BlockedRatio, wantRatio)
+			}
+		})
+	}
+}
+
+func TestSimulator_PowerOutage(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	tests := []struct {
+		name              string
+		diskRatio         float64
+		wantPowerDataLoss bool
+	}{
+		{name: "TestCleanPowerUp", diskRatio: 0},
+		{name: "TestPowerUpFailures", diskRatio: 0.6, wantPowerDataLoss: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dcConf, ecConf, rConf := newTestConf()
+			dcConf.POutageD, dcConf.PRestoreD = util.NewWeibull(1, 2000, 0), util.NewWeibull(1, 12, 0)
+			dcConf.PowerDomainsNum, dcConf.PowerUpFailure.DiskRatio = 4, tt.diskRatio
+			rConf.UsePowerOutage, rConf.Seed = true, 3
+			aggregate, err := mustNewSimulator(t, dcConf, ecConf, rConf).Run(context.Background(), 4, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			report := aggregate.Report(0)
+			if report.PowerOutageNum.Mean < 1 || report.PowerBlockedRatio.Mean <= 0 || report.PowerBlockedRatio.Mean > report.BlockedRatio.Mean {
+				t.Errorf("report = %+v, want outages counted in both blocked ratios", report)
+			}
+			// 普通故障也可能先造成丢失，只要求恢复供电时的永久故障引起了丢失
+			if gotPowerDataLoss := report.PowerDataLossIterations > 0; gotPowerDataLoss != tt.wantPowerDataLoss {
+				t.Errorf("PowerDataLossIterations = %d, want power data loss %t", report.PowerDataLossIterations, tt.wantPowerDataLoss)
+			}
+		})
+	}
+	t.Run("TestMissingDistribution", func(t *testing.T) {
+		dcConf, ecConf, rConf := newTestConf()
+		rConf.UsePowerOutage = true
+		if _, err := NewSimulator(dcConf, ecConf, rConf); !errors.Is(err, enum_error.ParamsMissingError) {
+			t.Errorf("NewSimulator() error = %v, want ParamsMissingError", err)
+		}
+	})
+}
+
+func TestSimulator_LRC(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	tests := []struct {
+		name     string
+		racksNum int
+	}{
+		{name: "TestRackPerChunk", racksNum: 12},
+		{name: "TestFewerRacksThanN", racksNum: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dcConf, ecConf, rConf := newTestConf()
+			ecConf.CodeType, ecConf.L, ecConf.G = data_center.LRC, 2, 1
+			dcConf.RacksNum, dcConf.NodesPerRack = tt.racksNum, 48/tt.racksNum
+			sim := mustNewSimulator(t, dcConf, ecConf, rConf)
+			got, err := sim.RunIteration(context.Background(), 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// 同一条带的块位于不同磁盘，同一局部组与全部全局校验块分别位于不同机架
+			chunkGroup := make(map[int]int)
+			for group, members := range sim.dcManager.ErasureCode().(data_center.LocalGroupCode).LocalGroups() {
+				for _, idx := range members {
+					chunkGroup[idx] = group
+				}
+			}
+			for stripeId := 0; stripeId < dcConf.StripesNum; stripeId++ {
+				location := sim.dcManager.GetStripesLocation(stripeId)
+				disks, groupRacks := make(map[int]bool), make(map[int]map[int]bool)
+				for idx, diskId := range location {
+					group, ok := chunkGroup[idx]
+					if !ok {
+						group = -1
+					}
+					if groupRacks[group] == nil {
+						groupRacks[group] = make(map[int]bool)
+					}
+					rackId := sim.dcManager.GetRackIdByDiskId(diskId)
+					if disks[diskId] || groupRacks[group][rackId] {
+						t.Fatalf("stripe %d placed on %v shares a disk or a rack inside group %d", stripeId, location, group)
+					}
+					disks[diskId], groupRacks[group][rackId] = true, true
+				}
+			}
+			// 两个全局校验块以外的块在局部组完好时只读取局部组修复
+			if got.LocalRepairRatio < 0.5 || got.LocalRepairRatio > 1 || got.RepairTrafficBytes <= 0 {
+				t.Errorf("RunIteration() = %+v, want mostly local repairs", got)
+			}
+		})
+	}
+
+	t.Run("TestLessTrafficThanRS", func(t *testing.T) {
+		dcConf, ecConf, rConf := newTestConf()
+		rsResult, err := mustNewSimulator(t, dcConf, ecConf, rConf).Run(context.Background(), 4, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ecConf.CodeType, ecConf.L, ecConf.G = data_center.LRC, 2, 1
+		lrcResult, err := mustNewSimulator(t, dcConf, ecConf, rConf).Run(context.Background(), 4, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lrcResult.RepairTrafficBytes.Sum >= rsResult.RepairTrafficBytes.Sum || rsResult.LocalRepairRatio.Sum != 0 {
+			t.Errorf("LRC repair traffic %g, RS %g, want LRC to read less", lrcResult.RepairTrafficBytes.Sum, rsResult.RepairTrafficBytes.Sum)
+		}
+	})
+}
+
+func TestSimulator_Hierarchical(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	tests := []struct {
+		name          string
+		chunksPerRack int
+		wantRacks     int
+		wantTolerance int
+	}{
+		{name: "TestThreePerRack", chunksPerRack: 3, wantRacks: 3, wantTolerance: 1},
+		{name: "TestTwoPerRack", chunksPerRack: 2, wantRacks: 5, wantTolerance: 1},
+		{name: "TestOnePerRack", chunksPerRack: 1, wantRacks: 9, wantTolerance: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dcConf, ecConf, rConf := newTestConf()
+			// 每个节点两块磁盘，机架数少于 N
+			dcConf.RacksNum, dcConf.NodesPerRack, dcConf.DisksPerNode = tt.wantRacks, 3, 2
+			ecConf.ChunkPlaceType, ecConf.ChunksPerRack = data_center.HIERARCHICAL, tt.chunksPerRack
+			sim := mustNewSimulator(t, dcConf, ecConf, rConf)
+			if got := sim.RackFaultTolerance(); got != tt.wantTolerance {
+				t.Errorf("RackFaultTolerance() = %d, want %d", got, tt.wantTolerance)
+			}
+			got, err := sim.RunIteration(context.Background(), 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for stripeId := 0; stripeId < dcConf.StripesNum; stripeId++ {
+				location := sim.dcManager.GetStripesLocation(stripeId)
+				disks, rackChunks := make(map[int]bool), make(map[int]int)
+				for _, diskId := range location {
+					if disks[diskId] {
+						t.Fatalf("stripe %d placed on %v shares disk %d", stripeId, location, diskId)
+					}
+					disks[diskId] = true
+					rackChunks[sim.dcManager.GetRackIdByDiskId(diskId)]++
+				}
+				if len(rackChunks) != tt.wantRacks {
+					t.Fatalf("stripe %d uses racks %v, want %d racks", stripeId, rackChunks, tt.wantRacks)
+				}
+				for rackId, chunkNum := range rackChunks {
+					if chunkNum > tt.chunksPerRack || rackId >= dcConf.RacksNum {
+						t.Fatalf("stripe %d has %d chunks in rack %d, want at most %d", stripeId, chunkNum, rackId, tt.chunksPerRack)
+					}
+				}
+			}
+			if got.RepairTrafficBytes <= 0 {
+				t.Errorf("RunIteration() = %+v, want repairs", got)
+			}
+		})
+	}
+}
+
+func TestSimulator_Replication(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	tests := []struct {
+		name          string
+		placeType     data_center.ChunkPlaceType
+		racksNum      int
+		wantRacks     int
+		wantTolerance int
+	}{
+		{name: "TestFlat", placeType: data_center.FLAT, racksNum: 12, wantRacks: 3, wantTolerance: 2},
+		{name: "TestHierarchical", placeType: data_center.HIERARCHICAL, racksNum: 4, wantRacks: 1, wantTolerance: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dcConf, ecConf, rConf := newTestConf()
+			dcConf.RacksNum, dcConf.NodesPerRack, dcConf.DisksPerNode = tt.racksNum, 48/tt.racksNum, 2
+			ecConf.CodeType, ecConf.N, ecConf.K = data_center.REPLICATION, 3, 1
+			ecConf.ChunkPlaceType, ecConf.ChunksPerRack = tt.placeType, 3
+			dcConf.ChunkNum = dcConf.StripesNum * ecConf.N
+			sim := mustNewSimulator(t, dcConf, ecConf, rConf)
+			if got := sim.RackFaultTolerance(); got != tt.wantTolerance {
+				t.Errorf("RackFaultTolerance() = %d, want %d", got, tt.wantTolerance)
+			}
+			got, err := sim.RunIteration(context.Background(), 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// 同一对象的副本位于不同节点，FLAT 时位于不同机架
+			for stripeId := 0; stripeId < dcConf.StripesNum; stripeId++ {
+				location := sim.dcManager.GetStripesLocation(stripeId)
+				nodes, racks := make(map[int]bool), make(map[int]bool)
+				for _, diskId := range location {
+					nodeId := sim.dcManager.GetNodeIdByDiskId(diskId)
+					if nodes[nodeId] {
+						t.Fatalf("object %d placed on %v shares node %d", stripeId, location, nodeId)
+					}
+					nodes[nodeId], racks[sim.dcManager.GetRackIdByDiskId(diskId)] = true, true
+				}
+				if len(racks) != tt.wantRacks {
+					t.Fatalf("object %d uses racks %v, want %d racks", stripeId, racks, tt.wantRacks)
+				}
+			}
+			if got.RepairTrafficBytes <= 0 || got.LocalRepairRatio != 0 {
+				t.Errorf("RunIteration() = %+v, want repairs reading one replica", got)
+			}
+		})
+	}
+
+	t.Run("TestLessTrafficThanRS", func(t *testing.T) {
+		dcConf, ecConf, rConf := newTestConf()
+		rsResult, err := mustNewSimulator(t, dcConf, ecConf, rConf).Run(context.Background(), 4, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ecConf.CodeType, ecConf.N, ecConf.K = data_center.REPLICATION, 3, 1
+		dcConf.ChunkNum = dcConf.StripesNum * ecConf.N
+		repResult, err := mustNewSimulator(t, dcConf, ecConf, rConf).Run(context.Background(), 4, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if repResult.RepairTrafficBytes.Sum >= rsResult.RepairTrafficBytes.Sum {
+			t.Errorf("replication repair traffic %g, RS %g, want replication to read less", repResult.RepairTrafficBytes.Sum, rsResult.RepairTrafficBytes.Sum)
+		}
+	})
+}
+
+func TestSimulator_LinearMatchesRS(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	dcConf, ecConf, rConf := newTestConf()
+	rConf.ContinueAfterLoss = true
+	// 系统 Cauchy 生成矩阵是 MDS 码，可恢复性与修复读取的块均与 RS 相同
+	rsResult, err := mustNewSimulator(t, dcConf, ecConf, rConf).RunIteration(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecConf.CodeType, ecConf.Generator = data_center.LINEAR, gf256.SystematicCauchy(ecConf.N, ecConf.K)
+	linearResult, err := mustNewSimulator(t, dcConf, ecConf, rConf).RunIteration(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(linearResult, rsResult) || rsResult.RepairTrafficBytes == 0 {
+		t.Errorf("RunIteration() with LINEAR = %+v, want the RS result %+v", linearResult, rsResult)
+	}
+}
+
+func TestSimulator_Regenerating(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	// 依次为 RS(9,6)、MSR(9,6,d=8)（修复读取 8/3 个块）与 MBR(9,6,d=8)（修复读取 1 个块），修复流量与修复时间依次减少
+	codeTypes := []data_center.ErasureCodeType{data_center.RS, data_center.MSR, data_center.MBR}
+	var last *Aggregate
+	for _, codeType := range codeTypes {
+		dcConf, ecConf, rConf := newTestConf()
+		ecConf.CodeType = codeType
+		got, err := mustNewSimulator(t, dcConf, ecConf, rConf).Run(context.Background(), 4, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.MeanRepairHours.Sum <= 0 {
+			t.Fatalf("Run() with %s = %+v, want repairs", codeType, got)
+		}
+		if last != nil && (got.RepairTrafficBytes.Sum >= last.RepairTrafficBytes.Sum || got.MeanRepairHours.Sum >= last.MeanRepairHours.Sum) {
+			t.Errorf("%s repair traffic %g over %g hours, want less than %g over %g hours", codeType,
+				got.RepairTrafficBytes.Sum, got.MeanRepairHours.Sum, last.RepairTrafficBytes.Sum, last.MeanRepairHours.Sum)
+		}
+		last = got
+	}
+}
+
+func TestSimulator_ClayDiskRead(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	// CLAY 与 MSR 的修复流量相同，但 MSR 的协助块读取整个块，CLAY 只读取要传输的数据
+	results := make(map[data_center.ErasureCodeType]*Aggregate)
+	for _, codeType := range []data_center.ErasureCodeType{data_center.MSR, data_center.CLAY} {
+		dcConf, ecConf, rConf := newTestConf()
+		ecConf.CodeType = codeType
+		got, err := mustNewSimulator(t, dcConf, ecConf, rConf).Run(context.Background(), 4, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		results[codeType] = got
+	}
+	msr, clay := results[data_center.MSR], results[data_center.CLAY]
+	if clay.RepairTrafficBytes.Sum != msr.RepairTrafficBytes.Sum || clay.RepairTrafficBytes.Sum <= 0 {
+		t.Errorf("CLAY repair traffic %g, MSR %g, want equal and positive", clay.RepairTrafficBytes.Sum, msr.RepairTrafficBytes.Sum)
+	}
+	if clay.RepairDiskReadBytes.Sum >= msr.RepairDiskReadBytes.Sum || clay.RepairDiskReadBytes.Sum != clay.RepairTrafficBytes.Sum {
+		t.Errorf("CLAY disk read %g, MSR %g, want CLAY to read only the transferred %g", clay.RepairDiskReadBytes.Sum,
+			msr.RepairDiskReadBytes.Sum, clay.RepairTrafficBytes.Sum)
+	}
+}
+
+func TestSimulator_StripeRepair(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	// 按条带修复时每个条带在自己的块重建后即恢复完好，平均脆弱窗口短于整块磁盘修复完成才恢复
+	var diskMode *Aggregate
+	for _, stripeRepair := range []bool{false, true} {
+		dcConf, ecConf, rConf := newTestConf()
+		dcConf.DFailD, dcConf.MissionTime = util.NewWeibull(1, 2000, 0), 17520
+		rConf.ContinueAfterLoss, rConf.StripeRepair = true, stripeRepair
+		got, err := mustNewSimulator(t, dcConf, ecConf, rConf).Run(context.Background(), 4, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.MeanDegradedHours.Sum <= 0 || got.MeanRepairHours.Sum <= 0 || got.RepairTrafficBytes.Sum <= 0 {
+			t.Fatalf("Run() with StripeRepair=%v = %+v, want repairs", stripeRepair, got)
+		}
+		if !stripeRepair {
+			diskMode = got
+			continue
+		}
+		if got.MeanDegradedHours.Sum >= diskMode.MeanDegradedHours.Sum {
+			t.Errorf("stripe repair degraded time %g, want less than disk repair %g", got.MeanDegradedHours.Sum, diskMode.MeanDegradedHours.Sum)
+		}
+	}
+}
+
+func TestSimulator_ConcurrentStripeRepair(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	run := func(t *testing.T, sharing data_center.BandwidthSharing, slots int) *Aggregate {
+		dcConf, ecConf, rConf := newTestConf()
+		dcConf.DFailD, dcConf.MissionTime, dcConf.BandwidthSharing = util.NewWeibull(1, 2000, 0), 17520, sharing
+		rConf.ContinueAfterLoss, rConf.StripeRepair, rConf.StripeRepairSlots = true, true, slots
+		got, err := mustNewSimulator(t, dcConf, ecConf, rConf).Run(context.Background(), 4, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+	// 同时修复多个条带时各修复分享带宽，修复的进程与逐个修复不同
+	serial := run(t, data_center.BandwidthExclusive, 1)
+	got := run(t, data_center.BandwidthMaxMin, 8)
+	if got.MeanDegradedHours.Sum <= 0 || got.MeanRepairHours.Sum <= 0 || got.RepairTrafficBytes.Sum <= 0 {
+		t.Fatalf("Run() with 8 stripe repair slots = %+v, want repairs", got)
+	}
+	if got.MeanDegradedHours.Sum == serial.MeanDegradedHours.Sum {
+		t.Errorf("degraded time with 8 slots = %g, want different from one slot", got.MeanDegradedHours.Sum)
+	}
+}
+
+func TestSimulator_RepairPolicy(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	// 推迟修复使更多条带的多个丢失块一并重建，修复流量减少而脆弱窗口变长
+	tests := []struct {
+		name      string
+		policy    event_trigger.RepairPolicy
+		threshold int
+		interval  float64
+	}{
+		{name: "TestLazy", policy: event_trigger.RepairLazy, threshold: 2},
+		{name: "TestBatched", policy: event_trigger.RepairBatched, interval: 168},
+	}
+	run := func(t *testing.T, policy event_trigger.RepairPolicy, threshold int, interval float64) *Aggregate {
+		dcConf, ecConf, rConf := newTestConf()
+		dcConf.DFailD, dcConf.MissionTime = util.NewWeibull(1, 2000, 0), 17520
+		rConf.ContinueAfterLoss, rConf.StripeRepair = true, true
+		rConf.RepairPolicy, rConf.RepairThreshold, rConf.RepairBatchInterval = policy, threshold, interval
+		got, err := mustNewSimulator(t, dcConf, ecConf, rConf).Run(context.Background(), 4, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+	eager := run(t, event_trigger.RepairEager, 0, 0)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := run(t, tt.policy, tt.threshold, tt.interval)
+			if got.RepairSavedBytes.Sum <= eager.RepairSavedBytes.Sum || got.RepairTrafficBytes.Sum >= eager.RepairTrafficBytes.Sum {
+				t.Errorf("%s repair traffic %g, saved %g, want less than eager %g, saved more than %g", tt.policy,
+					got.RepairTrafficBytes.Sum, got.RepairSavedBytes.Sum, eager.RepairTrafficBytes.Sum, eager.RepairSavedBytes.Sum)
+			}
+			if got.MeanDegradedHours.Sum <= eager.MeanDegradedHours.Sum {
+				t.Errorf("%s degraded time %g, want more than eager %g", tt.policy, got.MeanDegradedHours.Sum, eager.MeanDegradedHours.Sum)
+			}
+		})
+	}
+}
+
+func TestSimulator_BandwidthSharing(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	// 独占带宽时修复排队等待，开始后以全部带宽进行；共享带宽时修复立即开始，与同时进行的修复分享带宽，单个修复用时更长
+	tests := []struct {
+		name    string
+		sharing data_center.BandwidthSharing
+	}{
+		{name: "TestMaxMin", sharing: data_center.BandwidthMaxMin},
+		{name: "TestWeighted", sharing: data_center.BandwidthWeighted},
+	}
+	run := func(t *testing.T, sharing data_center.BandwidthSharing) *Aggregate {
+		dcConf, ecConf, rConf := newTestConf()
+		dcConf.DFailD, dcConf.MissionTime, dcConf.BandwidthSharing = util.NewWeibull(1, 2000, 0), 17520, sharing
+		// 降低跨机架带宽使修复经常同时进行
+		dcConf.MaxCrossRackRepairBandwidth = 5
+		rConf.ContinueAfterLoss = true
+		got, err := mustNewSimulator(t, dcConf, ecConf, rConf).Run(context.Background(), 4, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.MeanRepairHours.Sum <= 0 || got.RepairTrafficBytes.Sum <= 0 {
+			t.Fatalf("Run() with BandwidthSharing=%s = %+v, want repairs", sharing, got)
+		}
+		return got
+	}
+	exclusive := run(t, data_center.BandwidthExclusive)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := run(t, tt.sharing)
+			if got.MeanRepairHours.Sum <= exclusive.MeanRepairHours.Sum {
+				t.Errorf("%s repair time %g, want more than exclusive %g", tt.sharing, got.MeanRepairHours.Sum, exclusive.MeanRepairHours.Sum)
+			}
+		})
+	}
+}
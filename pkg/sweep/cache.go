@@ -0,0 +1,99 @@
+package sweep
+
+import (
+	"ECDC_SIM/internal/pkg/config"
+	"ECDC_SIM/pkg/simulator"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// cacheVersion 缓存格式版本，格式或模拟语义变化时递增，使旧的缓存失效
+const cacheVersion = 11
+
+// Cache 以配置的哈希为键，将已完成网格点的汇总结果保存为目录下的 JSON 文件
+type Cache struct {
+	dir string
+}
+
+type cacheEntry struct {
+	Version   int
+	Point     map[string]string
+	Config    *config.Config
+	Aggregate *simulator.Aggregate
+}
+
+func NewCache(dir string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Cache{dir: dir}, nil
+}
+
+// Key 网格点在缓存中的键，由配置的哈希与决定汇总结果的迭代次数、停止规则、时间预算组成
+func Key(cfg *config.Config) (string, error) {
+	hash, err := cfg.Hash()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s/%d/%g/%s", hash, cfg.Running.Iterations, cfg.Running.TargetRelativeError, cfg.Running.TimeBudget)))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+// Get 读取配置对应的汇总结果，不存在时 ok 为 false
+func (c *Cache) Get(cfg *config.Config) (aggregate *simulator.Aggregate, ok bool, err error) {
+	key, err := Key(cfg)
+	if err != nil {
+		return nil, false, err
+	}
+	data, err := os.ReadFile(c.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	entry := new(cacheEntry)
+	if err = json.Unmarshal(data, entry); err != nil {
+		return nil, false, fmt.Errorf("cache %s: %w", c.path(key), err)
+	}
+	if entry.Version != cacheVersion || entry.Aggregate == nil {
+		return nil, false, nil
+	}
+	return entry.Aggregate, true, nil
+}
+
+// Put 保存配置对应的汇总结果，先写临时文件再重命名，中断时不会留下不完整的缓存
+func (c *Cache) Put(cfg *config.Config, point map[string]string, aggregate *simulator.Aggregate) error {
+	key, err := Key(cfg)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(&cacheEntry{Version: cacheVersion, Point: point, Config: cfg, Aggregate: aggregate}, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(c.dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path(key))
+}
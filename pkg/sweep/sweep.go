@@ -0,0 +1,201 @@
+package sweep
+
+import (
+	"ECDC_SIM/internal/pkg/config"
+	"ECDC_SIM/pkg/simulator"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gogap/logrus"
+	"strings"
+	"time"
+)
+
+// Axis 扫描的一个轴。Names 含多个字段时为联合轴，每个取值同时设置所有字段，如 N/K=9/6,14/10
+type Axis struct {
+	Names  []string
+	Values [][]string
+}
+
+// ParseAxis 解析 "name=v1,v2,..." 形式的轴；联合轴写作 "n1/n2=a1/a2,b1/b2"。
+// 取值本身含逗号（如 Weibull 分布 "shape,scale,location"）时，用分号分隔各取值
+func ParseAxis(value string) (Axis, error) {
+	names, values, ok := strings.Cut(value, "=")
+	if !ok || names == "" || values == "" {
+		return Axis{}, fmt.Errorf("want name=v1,v2,..., got %q", value)
+	}
+	axis := Axis{Names: strings.Split(names, "/")}
+	sep := ","
+	if strings.Contains(values, ";") {
+		sep = ";"
+	}
+	for _, v := range strings.Split(values, sep) {
+		fields := strings.Split(v, "/")
+		if len(fields) != len(axis.Names) {
+			return Axis{}, fmt.Errorf("axis %s: value %q has %d fields, want %d", names, v, len(fields), len(axis.Names))
+		}
+		axis.Values = append(axis.Values, fields)
+	}
+	return axis, nil
+}
+
+func (a Axis) String() string {
+	values := make([]string, 0, len(a.Values))
+	for _, v := range a.Values {
+		values = append(values, strings.Join(v, "/"))
+	}
+	return strings.Join(a.Names, "/") + "=" + strings.Join(values, ",")
+}
+
+// Names 返回所有轴的字段名，按轴的顺序排列
+func Names(axes []Axis) []string {
+	names := make([]string, 0, len(axes))
+	for _, axis := range axes {
+		names = append(names, axis.Names...)
+	}
+	return names
+}
+
+// Grid 生成所有轴取值的笛卡尔积，每个点为字段名到取值的映射
+func Grid(axes []Axis) []map[string]string {
+	points := []map[string]string{{}}
+	for _, axis := range axes {
+		next := make([]map[string]string, 0, len(points)*len(axis.Values))
+		for _, point := range points {
+			for _, values := range axis.Values {
+				p := make(map[string]string, len(point)+len(values))
+				for name, value := range point {
+					p[name] = value
+				}
+				for idx, name := range axis.Names {
+					p[name] = values[idx]
+				}
+				next = append(next, p)
+			}
+		}
+		points = next
+	}
+	return points
+}
+
+// Setter 将 name=value 写入配置，默认为 (*config.Config).Set
+type Setter func(cfg *config.Config, name, value string) error
+
+// Sweep 在基准配置上按轴生成网格，并对每个网格点运行一次模拟
+type Sweep struct {
+	Base  *config.Config
+	Axes  []Axis
+	Set   Setter // 为 nil 时使用 (*config.Config).Set
+	Cache *Cache // 为 nil 时不缓存
+	// OnResult 每个网格点完成（或命中缓存、配置无效）后调用，可用于输出进度
+	OnResult func(result *Result)
+	// OnIteration 按迭代序号接收网格点每次迭代的结果，命中缓存的点不会调用
+	OnIteration func(point *Result, iteration int, result *simulator.SimResult) error
+	// OnProgress 每隔至少 ProgressInterval 时间接收正在运行的网格点的进度
+	OnProgress       func(point *Result, progress simulator.Progress)
+	ProgressInterval time.Duration
+}
+
+// Result 一个网格点的结果。Err 不为 nil 时表示该点配置无效而被跳过
+type Result struct {
+	Point      map[string]string
+	ConfigHash string
+	Config     *config.Config              `json:"-"`
+	Aggregate  *simulator.Aggregate        `json:"-"`
+	Report     *simulator.DurabilityReport `json:",omitempty"`
+	Cached     bool
+	Err        error `json:"-"`
+}
+
+// Points 生成每个网格点的配置，字段名或取值无效时返回错误
+func (s *Sweep) Points() ([]*Result, error) {
+	if len(s.Axes) == 0 {
+		return nil, errors.New("sweep: at least one axis is required")
+	}
+	set := s.Set
+	if set == nil {
+		set = (*config.Config).Set
+	}
+	grid := Grid(s.Axes)
+	points := make([]*Result, 0, len(grid))
+	for _, point := range grid {
+		cfg := s.Base.Clone()
+		for _, name := range Names(s.Axes) {
+			if err := set(cfg, name, point[name]); err != nil {
+				return nil, fmt.Errorf("sweep: %w", err)
+			}
+		}
+		hash, err := cfg.Hash()
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, &Result{Point: point, ConfigHash: hash, Config: cfg})
+	}
+	return points, nil
+}
+
+// Run 依次运行所有网格点。已缓存的点直接读取结果，完成的点写入缓存；
+// ctx 被取消时返回已完成的点以及当前点的部分结果（不写入缓存），并返回 ctx 的错误
+func (s *Sweep) Run(ctx context.Context) ([]*Result, error) {
+	points, err := s.Points()
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*Result, 0, len(points))
+	for _, point := range points {
+		runErr := s.runPoint(ctx, point)
+		results = append(results, point)
+		if s.OnResult != nil {
+			s.OnResult(point)
+		}
+		if runErr != nil {
+			return results, runErr
+		}
+	}
+	return results, nil
+}
+
+// runPoint 运行单个网格点，配置无效时记录在 point.Err 中并返回 nil
+func (s *Sweep) runPoint(ctx context.Context, point *Result) error {
+	cfg := point.Config
+	if s.Cache != nil {
+		aggregate, ok, err := s.Cache.Get(cfg)
+		if err != nil {
+			return err
+		}
+		if ok {
+			logrus.Infof("[Sweep.runPoint] point %v loaded from cache", point.Point)
+			point.Aggregate, point.Report, point.Cached = aggregate, aggregate.Report(cfg.Running.ConfidenceLevel), true
+			return nil
+		}
+	}
+	dcConf, ecConf, runningConf, err := cfg.Build()
+	if err != nil {
+		point.Err = err
+		return nil
+	}
+	sim, err := simulator.NewSimulator(dcConf, ecConf, runningConf)
+	if err != nil {
+		point.Err = err
+		return nil
+	}
+	if s.OnIteration != nil {
+		sim.SetResultHandler(func(iteration int, result *simulator.SimResult) error {
+			return s.OnIteration(point, iteration, result)
+		})
+	}
+	if s.OnProgress != nil {
+		sim.SetProgressHandler(s.ProgressInterval, func(progress simulator.Progress) {
+			s.OnProgress(point, progress)
+		})
+	}
+	aggregate, err := sim.Run(ctx, cfg.Running.Iterations, cfg.Running.Workers)
+	point.Aggregate, point.Report = aggregate, aggregate.Report(runningConf.ConfidenceLevel)
+	if err != nil {
+		return err
+	}
+	if s.Cache != nil {
+		return s.Cache.Put(cfg, point.Point, aggregate)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package sweep
+
+import (
+	"ECDC_SIM/internal/pkg/config"
+	"context"
+	"errors"
+	"github.com/gogap/logrus"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func newTestConfig() *config.Config {
+	cfg := config.Default()
+	cfg.Topology.Racks, cfg.Topology.NodesPerRack, cfg.Topology.Stripes = 12, 4, 200
+	cfg.Running.Iterations = 2
+	return cfg
+}
+
+func TestParseAxis(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    Axis
+		wantErr bool
+	}{
+		{name: "TestSingle", value: "RacksNum=16,32", want: Axis{Names: []string{"RacksNum"}, Values: [][]string{{"16"}, {"32"}}}},
+		{name: "TestJoint", value: "N/K=9/6,14/10", want: Axis{Names: []string{"N", "K"}, Values: [][]string{{"9", "6"}, {"14", "10"}}}},
+		{name: "TestSemicolon", value: "DFailD=1,100,0;1.2,100,0", want: Axis{Names: []string{"DFailD"}, Values: [][]string{{"1,100,0"}, {"1.2,100,0"}}}},
+		{name: "TestNoValues", value: "N", wantErr: true},
+		{name: "TestFieldCountMismatch", value: "N/K=9", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAxis(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseAxis() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseAxis() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrid(t *testing.T) {
+	axes := []Axis{
+		{Names: []string{"n", "k"}, Values: [][]string{{"9", "6"}, {"14", "10"}}},
+		{Names: []string{"racks"}, Values: [][]string{{"16"}, {"32"}}},
+	}
+	want := []map[string]string{
+		{"n": "9", "k": "6", "racks": "16"}, {"n": "9", "k": "6", "racks": "32"},
+		{"n": "14", "k": "10", "racks": "16"}, {"n": "14", "k": "10", "racks": "32"},
+	}
+	if got := Grid(axes); !reflect.DeepEqual(got, want) {
+		t.Errorf("Grid() = %v, want %v", got, want)
+	}
+}
+
+func TestSweep_Points(t *testing.T) {
+	axis, _ := ParseAxis("ErasureCodeConf.N/ErasureCodeConf.K=9/6,10/7")
+	sw := &Sweep{Base: newTestConfig(), Axes: []Axis{axis}}
+	points, err := sw.Points()
+	if err != nil {
+		t.Fatalf("Points() error = %v", err)
+	}
+	if len(points) != 2 || points[1].Config.ErasureCode.N != 10 || points[1].Config.ErasureCode.K != 7 {
+		t.Errorf("Points() = %+v", points)
+	}
+	if sw.Base.ErasureCode.N != 9 {
+		t.Errorf("Points() modified the base configuration")
+	}
+	unknown, _ := ParseAxis("foo=1")
+	if _, err = (&Sweep{Base: newTestConfig(), Axes: []Axis{unknown}}).Points(); err == nil {
+		t.Error("Points() with unknown field want error")
+	}
+	if _, err = (&Sweep{Base: newTestConfig()}).Points(); err == nil {
+		t.Error("Points() without axes want error")
+	}
+}
+
+func TestSweep_Run(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	axis, _ := ParseAxis("K=6,9")
+	cache, err := NewCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sw := &Sweep{Base: newTestConfig(), Axes: []Axis{axis}, Cache: cache}
+	results, err := sw.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(results) != 2 || results[0].Err != nil || results[0].Report.Iterations != 2 || results[0].Cached {
+		t.Fatalf("Run() results[0] = %+v", results[0])
+	}
+	if results[1].Err == nil || results[1].Report != nil {
+		t.Errorf("Run() results[1] = %+v, want invalid point", results[1])
+	}
+
+	again, err := sw.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !again[0].Cached || !reflect.DeepEqual(again[0].Aggregate, results[0].Aggregate) {
+		t.Errorf("Run() cached = %+v, want %+v", again[0].Aggregate, results[0].Aggregate)
+	}
+}
+
+func TestSweep_RunCanceled(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	axis, _ := ParseAxis("topology.racks=12,16")
+	dir := t.TempDir()
+	cache, _ := NewCache(dir)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err := (&Sweep{Base: newTestConfig(), Axes: []Axis{axis}, Cache: cache}).Run(ctx)
+	if !errors.Is(err, context.Canceled) || len(results) != 1 {
+		t.Fatalf("Run() = %v, %v, want one partial result and context.Canceled", results, err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Run() cached %d unfinished points", len(entries))
+	}
+}
+
+func TestKey(t *testing.T) {
+	cfg := newTestConfig()
+	key, err := Key(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	workers := cfg.Clone()
+	workers.Running.Workers = 8
+	seed := cfg.Clone()
+	seed.Running.Seed = 1
+	iterations := cfg.Clone()
+	iterations.Running.Iterations++
+	if got, _ := Key(workers); got != key {
+		t.Errorf("Key() depends on the number of workers")
+	}
+	if got, _ := Key(seed); got == key {
+		t.Errorf("Key() ignores the seed")
+	}
+	if got, _ := Key(iterations); got == key {
+		t.Errorf("Key() ignores the number of iterations")
+	}
+	if filepath.Base(key) != key || len(key) != 64 {
+		t.Errorf("Key() = %s", key)
+	}
+}
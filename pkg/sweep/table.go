@@ -0,0 +1,30 @@
+package sweep
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// WriteTable 以文本表格输出扫描结果，每个网格点一行，配置无效的点不输出
+func WriteTable(w io.Writer, axes []Axis, results []*Result) error {
+	names := Names(axes)
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	for _, name := range names {
+		fmt.Fprintf(tw, "%s\t", name)
+	}
+	fmt.Fprintln(tw, "iterations\tloss\tPDL\tPDL lower\tPDL upper\tlost chunks\tNOMDL\tblocked ratio\trepair traffic\tcached")
+	for _, result := range results {
+		if result.Err != nil || result.Report == nil {
+			continue
+		}
+		for _, name := range names {
+			fmt.Fprintf(tw, "%s\t", result.Point[name])
+		}
+		report := result.Report
+		fmt.Fprintf(tw, "%d\t%d\t%.6g\t%.6g\t%.6g\t%.6g\t%.6g\t%.6g\t%.6g\t%t\n", report.Iterations, report.DataLossIterations,
+			report.PDL.Mean, report.PDL.Lower, report.PDL.Upper, report.LostChunkNum.Mean, report.NOMDL.Mean, report.BlockedRatio.Mean,
+			report.RepairTrafficBytes.Mean, result.Cached)
+	}
+	return tw.Flush()
+}